@@ -0,0 +1,32 @@
+package secp256k1
+
+import "encoding/binary"
+
+// aadTag is the tagged hash tag used for binding a message to its associated data
+const aadTag = "go-secp256k1/aad"
+
+// AADHash computes the hash that SignWithContext signs and VerifyWithContext verifies:
+// `TaggedHash("go-secp256k1/aad", len(msg) || msg || aad)`, where len(msg) is a 64 bit big endian integer.
+// The length prefix makes sure moving bytes between msg and aad changes the hash.
+func AADHash(msg, aad []byte) *Hash {
+	msgLen := [8]byte{}
+	binary.BigEndian.PutUint64(msgLen[:], uint64(len(msg)))
+	return TaggedHash(aadTag, msgLen[:], msg, aad)
+}
+
+// SignWithContext creates a schnorr signature over the message bound to the associated data (e.g. a channel id or a timestamp),
+// so the signature is only valid with the same associated data. VerifyWithContext is the verifying side of this.
+// Notice: unlike SchnorrSign this takes the message itself and hashes it.
+func (key *SchnorrKeyPair) SignWithContext(msg, aad []byte) (*SchnorrSignature, error) {
+	return key.SchnorrSign(AADHash(msg, aad))
+}
+
+// SignWithContextRemote is like SignWithContext, but signs with a RemoteSigner (see SignWithSigner).
+func SignWithContextRemote(signer RemoteSigner, msg, aad []byte) (*SchnorrSignature, error) {
+	return SignWithSigner(signer, AADHash(msg, aad))
+}
+
+// VerifyWithContext verifies a schnorr signature created by SignWithContext over the message and the associated data.
+func (key *SchnorrPublicKey) VerifyWithContext(msg, aad []byte, signature *SchnorrSignature) bool {
+	return key.SchnorrVerify(AADHash(msg, aad), signature)
+}
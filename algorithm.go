@@ -0,0 +1,103 @@
+package secp256k1
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// AlgorithmSchnorr is the VerifyWithAlgorithm identifier of a BIP-340 schnorr signature with a 32 byte x-only public key
+	AlgorithmSchnorr byte = 0x01
+	// AlgorithmECDSA is the VerifyWithAlgorithm identifier of a 64 byte compact ECDSA signature with a 33 byte compressed public key
+	AlgorithmECDSA byte = 0x02
+)
+
+// VerifyWithAlgorithm parses the public key and signature according to algo (AlgorithmSchnorr or AlgorithmECDSA) and verifies the signature.
+// It returns an error if the algorithm is unknown or the public key or signature don't parse, e.g. because of a length mismatch,
+// and false without an error if they parse but the signature is invalid.
+// Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+func VerifyWithAlgorithm(algo byte, pubkey []byte, hash *Hash, sig []byte) (bool, error) {
+	switch algo {
+	case AlgorithmSchnorr:
+		schnorrPubKey, err := DeserializeSchnorrPubKey(pubkey)
+		if err != nil {
+			return false, err
+		}
+		signature, err := DeserializeSchnorrSignatureFromSlice(sig)
+		if err != nil {
+			return false, err
+		}
+		return schnorrPubKey.SchnorrVerify(hash, signature), nil
+	case AlgorithmECDSA:
+		ecdsaPubKey, err := DeserializeECDSAPubKeyCompressedOnly(pubkey)
+		if err != nil {
+			return false, err
+		}
+		signature, err := DeserializeECDSASignatureFromSlice(sig)
+		if err != nil {
+			return false, err
+		}
+		return ecdsaPubKey.ECDSAVerify(hash, signature), nil
+	default:
+		return false, errors.Errorf("unknown signature algorithm 0x%02x", algo)
+	}
+}
+
+// ErrAlgorithmNotAllowed is returned by VerifyWithPolicy when the signature's algorithm isn't in the policy's AllowedAlgorithms.
+var ErrAlgorithmNotAllowed = errors.New("the signature algorithm isn't allowed by the policy")
+
+// ErrZeroHash is returned by VerifyWithPolicy when the hash is all zeros and the policy rejects it.
+var ErrZeroHash = errors.New("the hash is all zeros")
+
+// VerifyPolicy configures the checks VerifyWithPolicy does on top of verifying the signature.
+// The zero value allows both algorithms and the zero hash, and rejects high-S ECDSA signatures.
+type VerifyPolicy struct {
+	// AllowHighS accepts ECDSA signatures whose S is in the upper half of the group order by normalizing them before verifying,
+	// libsecp256k1 only verifies lower-S signatures. Otherwise they're rejected with ErrSignatureHighS.
+	// Schnorr signatures are never malleable this way.
+	AllowHighS bool
+	// RejectZeroHash rejects the all zeros hash with ErrZeroHash, it's usually a sign of a hash that was never computed.
+	RejectZeroHash bool
+	// AllowedAlgorithms restricts the algorithms (AlgorithmSchnorr, AlgorithmECDSA) that are accepted, nil allows both.
+	AllowedAlgorithms []byte
+}
+
+// VerifyWithPolicy verifies the signature like VerifyWithAlgorithm, after enforcing the policy.
+// The algorithm is determined by the public key's length: 32 bytes is an x-only schnorr public key
+// and 33 bytes is a compressed ECDSA public key.
+// Policy violations are returned as errors (ErrAlgorithmNotAllowed, ErrZeroHash, ErrSignatureHighS),
+// and false without an error means the signature is well formed but invalid.
+// Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+func VerifyWithPolicy(policy VerifyPolicy, pubkey []byte, hash *Hash, sig []byte) (bool, error) {
+	var algo byte
+	switch len(pubkey) {
+	case SerializedSchnorrPublicKeySize:
+		algo = AlgorithmSchnorr
+	case SerializedECDSAPublicKeySize:
+		algo = AlgorithmECDSA
+	default:
+		return false, errors.Errorf("a public key has to be %d (schnorr) or %d (ECDSA) bytes, instead got %d",
+			SerializedSchnorrPublicKeySize, SerializedECDSAPublicKeySize, len(pubkey))
+	}
+	if policy.AllowedAlgorithms != nil && bytes.IndexByte(policy.AllowedAlgorithms, algo) == -1 {
+		return false, errors.Wrapf(ErrAlgorithmNotAllowed, "algorithm 0x%02x", algo)
+	}
+	if policy.RejectZeroHash && *hash == (Hash{}) {
+		return false, errors.WithStack(ErrZeroHash)
+	}
+	if algo == AlgorithmECDSA {
+		signature, err := DeserializeECDSASignatureFromSlice(sig)
+		if err != nil {
+			return false, err
+		}
+		if signature.IsHighS() {
+			if !policy.AllowHighS {
+				return false, errors.WithStack(ErrSignatureHighS)
+			}
+			signature.NormalizeS()
+			sig = signature.Serialize()[:]
+		}
+	}
+	return VerifyWithAlgorithm(algo, pubkey, hash, sig)
+}
@@ -0,0 +1,87 @@
+package secp256k1
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"math/big"
+	"strings"
+)
+
+// base58Alphabet is the Bitcoin base58 alphabet, it leaves out 0, O, I and l which are easy to mix up.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var bigRadix = big.NewInt(58)
+
+// Base58CheckEncode encodes `version || payload || checksum` in base58, where the checksum is the first 4 bytes of
+// SHA256(SHA256(version || payload)). The version can be any length, e.g. 1 byte for bitcoin addresses.
+func Base58CheckEncode(payload []byte, version []byte) string {
+	data := make([]byte, 0, len(version)+len(payload)+4)
+	data = append(data, version...)
+	data = append(data, payload...)
+	checksum := base58Checksum(data)
+	return base58Encode(append(data, checksum[:]...))
+}
+
+// Base58CheckDecode decodes a string encoded with Base58CheckEncode and verifies its checksum.
+// The version is assumed to be the first byte, callers with a longer version prefix should split it off the payload themselves.
+func Base58CheckDecode(s string) (version, payload []byte, err error) {
+	data, err := base58Decode(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < 5 {
+		return nil, nil, errors.Errorf("a base58check string has to decode to at least 5 bytes, instead got %d", len(data))
+	}
+	checksum := base58Checksum(data[:len(data)-4])
+	if !bytes.Equal(checksum[:], data[len(data)-4:]) {
+		return nil, nil, errors.New("invalid base58check checksum")
+	}
+	return data[:1], data[1 : len(data)-4], nil
+}
+
+func base58Checksum(data []byte) [4]byte {
+	hash := doubleSHA256(data)
+	checksum := [4]byte{}
+	copy(checksum[:], hash[:4])
+	return checksum
+}
+
+// base58Encode encodes the data as a big endian number in base58, each leading zero byte is encoded as a '1'.
+func base58Encode(data []byte) string {
+	number := new(big.Int).SetBytes(data)
+	mod := new(big.Int)
+	encoded := make([]byte, 0, len(data)*138/100+1)
+	for number.Sign() > 0 {
+		number.DivMod(number, bigRadix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+// base58Decode is the inverse of base58Encode.
+func base58Decode(s string) ([]byte, error) {
+	number := new(big.Int)
+	digit := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		index := strings.IndexByte(base58Alphabet, s[i])
+		if index == -1 {
+			return nil, errors.Errorf("invalid base58 character '%c' at position %d", s[i], i)
+		}
+		number.Mul(number, bigRadix)
+		number.Add(number, digit.SetInt64(int64(index)))
+	}
+	leadingZeros := 0
+	for leadingZeros < len(s) && s[leadingZeros] == base58Alphabet[0] {
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), number.Bytes()...), nil
+}
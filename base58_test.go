@@ -0,0 +1,64 @@
+package secp256k1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestBase58Check(t *testing.T) {
+	tests := []struct {
+		version []byte
+		payload []byte
+		encoded string
+	}{
+		// A WIF private key
+		{[]byte{0x80}, decodeHex("0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d"), "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ"},
+		// A P2PKH address, the zero version byte is encoded as a leading '1'
+		{[]byte{0x00}, decodeHex("010966776006953d5567439e5e39f86a0d273bee"), "16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvM"},
+	}
+	for i, test := range tests {
+		encoded := Base58CheckEncode(test.payload, test.version)
+		if encoded != test.encoded {
+			t.Errorf("test %d: expected '%s', got '%s'", i, test.encoded, encoded)
+		}
+		version, payload, err := Base58CheckDecode(test.encoded)
+		if err != nil {
+			t.Fatalf("test %d: %s", i, err)
+		}
+		if !bytes.Equal(version, test.version) || !bytes.Equal(payload, test.payload) {
+			t.Errorf("test %d: expected version %x and payload %x, got %x and %x", i, test.version, test.payload, version, payload)
+		}
+	}
+
+	r := rand.New(rand.NewSource(50))
+	for i := 0; i < loopsN; i++ {
+		payload := make([]byte, r.Intn(40))
+		r.Read(payload)
+		// Leading zeros have to survive the round trip.
+		if i%3 == 0 && len(payload) > 2 {
+			payload[0], payload[1] = 0, 0
+		}
+		version := []byte{byte(r.Intn(3))}
+		encoded := Base58CheckEncode(payload, version)
+		decodedVersion, decodedPayload, err := Base58CheckDecode(encoded)
+		if err != nil {
+			t.Fatalf("Failed decoding '%s': %s", encoded, err)
+		}
+		if !bytes.Equal(decodedVersion, version) || !bytes.Equal(decodedPayload, payload) {
+			t.Fatalf("Expected version %x and payload %x, got %x and %x", version, payload, decodedVersion, decodedPayload)
+		}
+	}
+
+	for _, invalid := range []string{
+		"",
+		"1111",
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTj", // A changed character breaks the checksum
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyT0", // '0' isn't in the alphabet
+	} {
+		_, _, err := Base58CheckDecode(invalid)
+		if err == nil {
+			t.Errorf("Expected an error decoding '%s'", invalid)
+		}
+	}
+}
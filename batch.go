@@ -0,0 +1,163 @@
+package secp256k1
+
+import (
+	gocontext "context"
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/pkg/errors"
+)
+
+// batchTag is the tagged hash tag used for deriving the batch verification coefficients
+const batchTag = "go-secp256k1/batch"
+
+// batchChunkSize is the amount of signatures SchnorrBatchVerifyContext verifies between checks of the context
+const batchChunkSize = 256
+
+// SchnorrBatchVerifyDeterministic verifies all the schnorr signatures at once, it returns true only if every signature is valid.
+// It uses the BIP-340 batch verification equation `(s_1 + a_2*s_2 + ... + a_u*s_u)*G == R_1 + a_2*R_2 + ... + a_u*R_u + e_1*P_1 + (a_2*e_2)*P_2 + ... + (a_u*e_u)*P_u`,
+// with coefficients derived deterministically from the seed and a hash of the whole batch, so every node gets the same result for the same batch.
+// Because the coefficients commit to the batch, a signer can't pick signatures that cancel out even if the seed is known in advance.
+// An empty batch is valid, an uninitialized public key or mismatched lengths are an error.
+// Notice: the hashes *MUST* be hashes of messages you hashed yourself.
+func SchnorrBatchVerifyDeterministic(pubkeys []*SchnorrPublicKey, hashes []*Hash, signatures []*SchnorrSignature, seed []byte) (bool, error) {
+	if len(pubkeys) != len(hashes) || len(pubkeys) != len(signatures) {
+		return false, errors.Errorf("the number of public keys (%d), hashes (%d) and signatures (%d) should be the same",
+			len(pubkeys), len(hashes), len(signatures))
+	}
+	if len(pubkeys) == 0 {
+		return true, nil
+	}
+
+	serializedPubkeys := make([]*SerializedSchnorrPublicKey, len(pubkeys))
+	batchHasher := sha256.New()
+	for i := range pubkeys {
+		if pubkeys[i] == nil || hashes[i] == nil || signatures[i] == nil {
+			return false, errors.Errorf("entry number %d of the batch is nil", i)
+		}
+		serialized, err := pubkeys[i].Serialize()
+		if err != nil {
+			return false, err
+		}
+		serializedPubkeys[i] = serialized
+		batchHasher.Write(serialized[:])
+		batchHasher.Write(hashes[i][:])
+		batchHasher.Write(signatures[i].signature[:])
+	}
+	batchHash := batchHasher.Sum(nil)
+
+	gScalar := [32]byte{}
+	scalars := make([][32]byte, 0, 2*len(pubkeys))
+	points := make([]*ECDSAPublicKey, 0, 2*len(pubkeys))
+	for i := range pubkeys {
+		r, s := signatures[i].Split()
+		if _, overflowed := reduceScalar(&s); overflowed || signatures[i].hasZeroR() {
+			return false, nil
+		}
+		// R is the point with the even Y coordinate, deserializing fails if r isn't a valid x coordinate.
+		compressedR := SerializedECDSAPublicKey{0x02}
+		copy(compressedR[1:], r[:])
+		pointR, err := DeserializeECDSAPubKey(compressedR[:])
+		if err != nil {
+			return false, nil
+		}
+		pointP, err := pubkeys[i].toECDSA()
+		if err != nil {
+			return false, err
+		}
+		challenge := TaggedHash("BIP0340/challenge", r[:], serializedPubkeys[i][:], hashes[i][:])
+		e, _ := reduceScalar((*[32]byte)(challenge))
+
+		coefficient := [32]byte{31: 1}
+		if i > 0 {
+			counter := [4]byte{}
+			binary.BigEndian.PutUint32(counter[:], uint32(i))
+			coefficient, _ = reduceScalar((*[32]byte)(TaggedHash(batchTag, seed, batchHash, counter[:])))
+		}
+
+		// Move everything to one side: `a_i*R_i + (a_i*e_i)*P_i - (a_i*s_i)*G` summed over the batch must be the point at infinity.
+		gScalar = ScalarAdd(gScalar, ScalarNegate(ScalarMul(coefficient, s)))
+		scalars = append(scalars, coefficient, ScalarMul(coefficient, e))
+		points = append(points, pointR, pointP)
+	}
+
+	_, isInfinity, err := multiScalarMultInternal(&gScalar, scalars, points)
+	if err != nil {
+		return false, err
+	}
+	return isInfinity, nil
+}
+
+// SchnorrBatchVerifyContext is like SchnorrBatchVerifyDeterministic (without a seed), but verifies the batch in chunks
+// and checks ctx between them, so a huge batch can be aborted. If ctx is done it returns ctx.Err().
+func SchnorrBatchVerifyContext(ctx gocontext.Context, pubkeys []*SchnorrPublicKey, hashes []*Hash, signatures []*SchnorrSignature) (bool, error) {
+	if len(pubkeys) != len(hashes) || len(pubkeys) != len(signatures) {
+		return false, errors.Errorf("the number of public keys (%d), hashes (%d) and signatures (%d) should be the same",
+			len(pubkeys), len(hashes), len(signatures))
+	}
+	for start := 0; start < len(pubkeys) || start == 0; start += batchChunkSize {
+		err := ctx.Err()
+		if err != nil {
+			return false, err
+		}
+		end := start + batchChunkSize
+		if end > len(pubkeys) {
+			end = len(pubkeys)
+		}
+		valid, err := SchnorrBatchVerifyDeterministic(pubkeys[start:end], hashes[start:end], signatures[start:end], nil)
+		if err != nil || !valid {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// batchAttestationTag is the tagged hash tag of the hash signed by BatchVerifyAttested
+const batchAttestationTag = "go-secp256k1/batch-attestation"
+
+// BatchAttestationHash computes the hash of the batch contents that BatchVerifyAttested signs:
+// `TaggedHash("go-secp256k1/batch-attestation", SerializeBatchForVerify(pubkeys, hashes, signatures))`.
+func BatchAttestationHash(pubkeys []*SchnorrPublicKey, hashes []*Hash, signatures []*SchnorrSignature) (*Hash, error) {
+	serialized, err := SerializeBatchForVerify(pubkeys, hashes, signatures)
+	if err != nil {
+		return nil, err
+	}
+	return TaggedHash(batchAttestationTag, serialized), nil
+}
+
+// BatchVerifyAttested batch verifies the signatures and, only if all of them are valid, returns the verifier's
+// 64 byte schnorr signature over BatchAttestationHash of the batch, so systems that trust the verifier can check
+// the one attestation with VerifyBatchAttestation instead of every signature.
+// It's an error if the batch doesn't verify.
+// Notice: the attestation is only as trustworthy as the verifier, and the hashes *MUST* be hashes of messages you hashed yourself.
+func BatchVerifyAttested(verifierKey *SchnorrKeyPair, pubkeys []*SchnorrPublicKey, hashes []*Hash, signatures []*SchnorrSignature) ([]byte, error) {
+	attestationHash, err := BatchAttestationHash(pubkeys, hashes, signatures)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := SchnorrBatchVerifyDeterministic(pubkeys, hashes, signatures, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("the batch doesn't verify, refusing to attest it")
+	}
+	attestation, err := verifierKey.SchnorrSign(attestationHash)
+	if err != nil {
+		return nil, err
+	}
+	return attestation.Serialize()[:], nil
+}
+
+// VerifyBatchAttestation verifies an attestation created by BatchVerifyAttested for the batch against the verifier's public key.
+func VerifyBatchAttestation(verifierPubKey *SchnorrPublicKey, pubkeys []*SchnorrPublicKey, hashes []*Hash, signatures []*SchnorrSignature,
+	attestation []byte) (bool, error) {
+	attestationHash, err := BatchAttestationHash(pubkeys, hashes, signatures)
+	if err != nil {
+		return false, err
+	}
+	signature, err := DeserializeSchnorrSignatureFromSlice(attestation)
+	if err != nil {
+		return false, err
+	}
+	return verifierPubKey.SchnorrVerify(attestationHash, signature), nil
+}
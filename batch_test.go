@@ -0,0 +1,178 @@
+package secp256k1
+
+import (
+	gocontext "context"
+	"math/rand"
+	"testing"
+)
+
+func TestSchnorrBatchVerifyDeterministic(t *testing.T) {
+	r := rand.New(rand.NewSource(49))
+	const n = 20
+	pubkeys := make([]*SchnorrPublicKey, n)
+	hashes := make([]*Hash, n)
+	signatures := make([]*SchnorrSignature, n)
+	for i := 0; i < n; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		var err error
+		pubkeys[i], err = keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[i] = &Hash{}
+		r.Read(hashes[i][:])
+		signatures[i] = mustSchnorrSign(t, keypair, hashes[i])
+	}
+	seed := []byte("block 1234")
+
+	valid, err := SchnorrBatchVerifyDeterministic(pubkeys, hashes, signatures, seed)
+	if err != nil || !valid {
+		t.Fatalf("Expected the batch to be valid, got: %t, %v", valid, err)
+	}
+	valid, err = SchnorrBatchVerifyDeterministic(pubkeys[:1], hashes[:1], signatures[:1], nil)
+	if err != nil || !valid {
+		t.Fatalf("Expected a batch of one to be valid, got: %t, %v", valid, err)
+	}
+	valid, err = SchnorrBatchVerifyDeterministic(nil, nil, nil, seed)
+	if err != nil || !valid {
+		t.Fatalf("Expected an empty batch to be valid, got: %t, %v", valid, err)
+	}
+
+	// Swapping the hashes of two signatures invalidates both.
+	swapped := append([]*Hash{}, hashes...)
+	swapped[3], swapped[7] = swapped[7], swapped[3]
+	valid, err = SchnorrBatchVerifyDeterministic(pubkeys, swapped, signatures, seed)
+	if err != nil || valid {
+		t.Fatalf("Expected the batch with swapped hashes to be invalid, got: %t, %v", valid, err)
+	}
+	for _, i := range []int{0, n - 1} {
+		corrupted := append([]*SchnorrSignature{}, signatures...)
+		serialized := *signatures[i].Serialize()
+		serialized[40] ^= 1
+		corrupted[i] = DeserializeSchnorrSignature(&serialized)
+		valid, err = SchnorrBatchVerifyDeterministic(pubkeys, hashes, corrupted, seed)
+		if err != nil || valid {
+			t.Fatalf("Expected the batch with signature %d corrupted to be invalid, got: %t, %v", i, valid, err)
+		}
+		// R isn't a valid x coordinate.
+		serialized = *signatures[i].Serialize()
+		copy(serialized[:32], decodeHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"))
+		corrupted[i] = DeserializeSchnorrSignature(&serialized)
+		valid, err = SchnorrBatchVerifyDeterministic(pubkeys, hashes, corrupted, seed)
+		if err != nil || valid {
+			t.Fatalf("Expected the batch with an invalid R in signature %d to be invalid, got: %t, %v", i, valid, err)
+		}
+	}
+
+	_, err = SchnorrBatchVerifyDeterministic(pubkeys, hashes[:n-1], signatures, seed)
+	if err == nil {
+		t.Fatalf("Expected an error for mismatched lengths")
+	}
+	uninitialized := append([]*SchnorrPublicKey{}, pubkeys...)
+	uninitialized[5] = new(SchnorrPublicKey)
+	_, err = SchnorrBatchVerifyDeterministic(uninitialized, hashes, signatures, seed)
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized public key")
+	}
+}
+
+func TestSchnorrBatchVerifyContext(t *testing.T) {
+	r := rand.New(rand.NewSource(54))
+	// More than one chunk, and one that isn't full.
+	n := batchChunkSize + 10
+	pubkeys := make([]*SchnorrPublicKey, n)
+	hashes := make([]*Hash, n)
+	signatures := make([]*SchnorrSignature, n)
+	for i := 0; i < n; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		var err error
+		pubkeys[i], err = keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[i] = &Hash{}
+		r.Read(hashes[i][:])
+		signatures[i], err = keypair.SchnorrSignWithAuxRand(hashes[i], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	valid, err := SchnorrBatchVerifyContext(gocontext.Background(), pubkeys, hashes, signatures)
+	if err != nil || !valid {
+		t.Fatalf("Expected the batch to be valid, got: %t, %v", valid, err)
+	}
+	valid, err = SchnorrBatchVerifyContext(gocontext.Background(), nil, nil, nil)
+	if err != nil || !valid {
+		t.Fatalf("Expected an empty batch to be valid, got: %t, %v", valid, err)
+	}
+	// An invalid signature in the last chunk.
+	corrupted := append([]*SchnorrSignature{}, signatures...)
+	corrupted[n-1] = corrupted[0]
+	valid, err = SchnorrBatchVerifyContext(gocontext.Background(), pubkeys, hashes, corrupted)
+	if err != nil || valid {
+		t.Fatalf("Expected the batch to be invalid, got: %t, %v", valid, err)
+	}
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	valid, err = SchnorrBatchVerifyContext(ctx, pubkeys, hashes, signatures)
+	if err != gocontext.Canceled || valid {
+		t.Fatalf("Expected gocontext.Canceled, got: %t, %v", valid, err)
+	}
+	_, err = SchnorrBatchVerifyContext(gocontext.Background(), pubkeys, hashes[:1], signatures)
+	if err == nil {
+		t.Fatalf("Expected an error for mismatched lengths")
+	}
+}
+
+func TestBatchVerifyAttested(t *testing.T) {
+	r := rand.New(rand.NewSource(209))
+	const n = 8
+	pubkeys := make([]*SchnorrPublicKey, n)
+	hashes := make([]*Hash, n)
+	signatures := make([]*SchnorrSignature, n)
+	for i := 0; i < n; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		var err error
+		pubkeys[i], err = keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[i] = &Hash{}
+		r.Read(hashes[i][:])
+		signatures[i] = mustSchnorrSign(t, keypair, hashes[i])
+	}
+	verifierKey, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifierPubKey, err := verifierKey.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attestation, err := BatchVerifyAttested(verifierKey, pubkeys, hashes, signatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := VerifyBatchAttestation(verifierPubKey, pubkeys, hashes, signatures, attestation)
+	if err != nil || !valid {
+		t.Fatalf("Expected the attestation to verify, got: %t, %v", valid, err)
+	}
+	valid, err = VerifyBatchAttestation(verifierPubKey, pubkeys[1:], hashes[1:], signatures[1:], attestation)
+	if err != nil || valid {
+		t.Fatalf("Expected the attestation to not verify for a different batch, got: %t, %v", valid, err)
+	}
+
+	swapped := append([]*Hash{}, hashes...)
+	swapped[2], swapped[5] = swapped[5], swapped[2]
+	_, err = BatchVerifyAttested(verifierKey, pubkeys, swapped, signatures)
+	if err == nil {
+		t.Fatalf("Expected an error when attesting an invalid batch")
+	}
+	_, err = BatchVerifyAttested(verifierKey, pubkeys, hashes[1:], signatures)
+	if err == nil {
+		t.Fatalf("Expected an error for mismatched lengths")
+	}
+}
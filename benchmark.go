@@ -0,0 +1,45 @@
+package secp256k1
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Benchmark measures the schnorr signing and verification throughput on the current machine,
+// it spends half of the duration signing and the other half verifying, and returns the rates per second.
+// It's meant for capacity planning at startup, not for the hot path, since it blocks for the whole duration.
+func Benchmark(duration time.Duration) (signsPerSec, verifiesPerSec float64) {
+	keypair, err := PrivateKeyFromUint64(0x5ec9256b1)
+	if err != nil {
+		panic("failed creating the benchmark private key. Should never happen")
+	}
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		panic("failed getting the benchmark public key. Should never happen")
+	}
+	hash := Hash{}
+	var signature *SchnorrSignature
+
+	signs := 0
+	start := time.Now()
+	for time.Since(start) < duration/2 || signs == 0 {
+		binary.BigEndian.PutUint64(hash[:8], uint64(signs))
+		signature, err = keypair.SchnorrSignWithAuxRand(&hash, nil)
+		if err != nil {
+			panic("failed signing with the benchmark private key. Should never happen")
+		}
+		signs++
+	}
+	signsPerSec = float64(signs) / time.Since(start).Seconds()
+
+	verifies := 0
+	start = time.Now()
+	for time.Since(start) < duration/2 || verifies == 0 {
+		if !pubkey.SchnorrVerify(&hash, signature) {
+			panic("failed verifying the benchmark signature. Should never happen")
+		}
+		verifies++
+	}
+	verifiesPerSec = float64(verifies) / time.Since(start).Seconds()
+	return signsPerSec, verifiesPerSec
+}
@@ -0,0 +1,207 @@
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// bip322MessageTag is the tag of the BIP-322 message hash
+	bip322MessageTag = "BIP0322-signed-message"
+	// taprootScriptPubKeySize is the size of a P2TR scriptPubKey: `OP_1 OP_PUSHBYTES_32 <output key>`
+	taprootScriptPubKeySize = 2 + SerializedSchnorrPublicKeySize
+	// sigHashDefault is the BIP-341 default sighash type of 64 byte signatures, which signs the same as SIGHASH_ALL
+	sigHashDefault = 0x00
+	// sigHashAll is the SIGHASH_ALL sighash type
+	sigHashAll = 0x01
+)
+
+// SignBIP322 signs the message for the P2TR scriptPubKey with the BIP-322 "simple" signature format, and returns the
+// consensus serialized witness stack of the virtual to_sign transaction (base64 encoding it is left to the caller).
+// scriptPubKey must be a P2TR output whose output key is either the keypair's public key tweaked without a script tree
+// (BIP-86, like single key wallets use) or the keypair's public key itself. The signature uses the default sighash type.
+func (key *SchnorrKeyPair) SignBIP322(message string, scriptPubKey []byte) ([]byte, error) {
+	outputKey, err := parseTaprootScriptPubKey(scriptPubKey)
+	if err != nil {
+		return nil, err
+	}
+	signer := key.clone()
+	defer func() { signer = SchnorrKeyPair{} }()
+	pubkey, err := signer.SchnorrPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if !pubkey.IsEqual(outputKey) {
+		serializedPubKey, err := pubkey.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		err = signer.Add(*TaggedHash("TapTweak", serializedPubKey[:]))
+		if err != nil {
+			return nil, err
+		}
+		if !signer.Matches(outputKey) {
+			return nil, errors.New("the scriptPubKey's output key doesn't belong to the keypair")
+		}
+	}
+	toSpend := bip322ToSpendTxID(bip322MessageHash(message), scriptPubKey)
+	signature, err := signer.SchnorrSign(bip322SigHash(&toSpend, scriptPubKey, sigHashDefault))
+	if err != nil {
+		return nil, err
+	}
+	witness := append(compactSize(1), compactSize(SerializedSchnorrSignatureSize)...)
+	return append(witness, signature.Serialize()[:]...), nil
+}
+
+// VerifyBIP322 verifies a BIP-322 "simple" signature of the message for the P2TR scriptPubKey.
+// signature is the consensus serialized witness stack of the virtual to_sign transaction, it must be a single taproot key path
+// signature: 64 bytes, or 65 bytes with the SIGHASH_ALL type. It returns an error if the scriptPubKey isn't P2TR or the
+// signature is malformed or uses any other sighash type, and false if the signature is well formed but invalid.
+// Notice: only key path spends are supported, script path spends would require executing the script.
+func VerifyBIP322(message string, scriptPubKey, signature []byte) (bool, error) {
+	outputKey, err := parseTaprootScriptPubKey(scriptPubKey)
+	if err != nil {
+		return false, err
+	}
+	witness, err := parseWitnessStack(signature)
+	if err != nil {
+		return false, err
+	}
+	if len(witness) != 1 {
+		return false, errors.Errorf("expected a single element taproot key path witness, got %d elements", len(witness))
+	}
+	sig := witness[0]
+	hashType := byte(sigHashDefault)
+	if len(sig) == SerializedSchnorrSignatureSize+1 {
+		hashType = sig[SerializedSchnorrSignatureSize]
+		if hashType != sigHashAll {
+			return false, errors.Errorf("unsupported sighash type 0x%02x, expected SIGHASH_ALL", hashType)
+		}
+		sig = sig[:SerializedSchnorrSignatureSize]
+	}
+	schnorrSignature, err := DeserializeSchnorrSignatureFromSlice(sig)
+	if err != nil {
+		return false, err
+	}
+	toSpend := bip322ToSpendTxID(bip322MessageHash(message), scriptPubKey)
+	return VerifyTaprootKeyPath(outputKey, bip322SigHash(&toSpend, scriptPubKey, hashType), schnorrSignature), nil
+}
+
+// bip322MessageHash computes the BIP-322 message hash `TaggedHash("BIP0322-signed-message", message)`
+func bip322MessageHash(message string) *Hash {
+	return TaggedHash(bip322MessageTag, []byte(message))
+}
+
+// bip322ToSpendTxID returns the txid (in internal byte order) of the BIP-322 virtual to_spend transaction,
+// which spends a fake input committing to the message hash into an output with the scriptPubKey.
+func bip322ToSpendTxID(messageHash *Hash, scriptPubKey []byte) [32]byte {
+	var tx bytes.Buffer
+	tx.Write([]byte{0, 0, 0, 0}) // version
+	tx.Write(compactSize(1))
+	tx.Write(make([]byte, 32))               // prevout hash
+	tx.Write([]byte{0xff, 0xff, 0xff, 0xff}) // prevout index
+	tx.Write(compactSize(2 + HashSize))
+	tx.Write([]byte{0x00, HashSize}) // scriptSig: OP_0 PUSH32[message hash]
+	tx.Write(messageHash[:])
+	tx.Write([]byte{0, 0, 0, 0}) // sequence
+	tx.Write(compactSize(1))
+	tx.Write(make([]byte, 8)) // value
+	tx.Write(compactSize(uint64(len(scriptPubKey))))
+	tx.Write(scriptPubKey)
+	tx.Write([]byte{0, 0, 0, 0}) // locktime
+	first := sha256.Sum256(tx.Bytes())
+	return sha256.Sum256(first[:])
+}
+
+// bip322SigHash computes the BIP-341 key path signature hash of the BIP-322 virtual to_sign transaction
+// which spends output 0 of toSpend into a single OP_RETURN output, all the versions, amounts, sequences and locktimes are zero.
+func bip322SigHash(toSpend *[32]byte, scriptPubKey []byte, hashType byte) *Hash {
+	var zeroIndex [4]byte
+	var zeroAmount [8]byte
+	shaPrevouts := sha256.Sum256(append(toSpend[:], zeroIndex[:]...))
+	shaAmounts := sha256.Sum256(zeroAmount[:])
+	shaScriptPubKeys := sha256.Sum256(append(compactSize(uint64(len(scriptPubKey))), scriptPubKey...))
+	shaSequences := sha256.Sum256(zeroIndex[:])
+	shaOutputs := sha256.Sum256(append(zeroAmount[:], append(compactSize(1), 0x6a)...)) // OP_RETURN
+	var version, lockTime, inputIndex [4]byte
+	return TaggedHash("TapSighash",
+		[]byte{0x00, hashType}, // epoch and sighash type
+		version[:], lockTime[:],
+		shaPrevouts[:], shaAmounts[:], shaScriptPubKeys[:], shaSequences[:], shaOutputs[:],
+		[]byte{0x00}, // spend type: key path without an annex
+		inputIndex[:])
+}
+
+// parseTaprootScriptPubKey returns the output key of a P2TR scriptPubKey.
+func parseTaprootScriptPubKey(scriptPubKey []byte) (*SchnorrPublicKey, error) {
+	if len(scriptPubKey) != taprootScriptPubKeySize || scriptPubKey[0] != 0x51 || scriptPubKey[1] != SerializedSchnorrPublicKeySize {
+		return nil, errors.New("the scriptPubKey isn't a P2TR output")
+	}
+	outputKey, err := DeserializeSchnorrPubKey(scriptPubKey[2:])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid taproot output key")
+	}
+	return outputKey, nil
+}
+
+// parseWitnessStack parses a consensus serialized witness stack, it must be fully consumed.
+func parseWitnessStack(data []byte) ([][]byte, error) {
+	reader := bytes.NewReader(data)
+	count, err := readCompactSize(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading the witness stack size")
+	}
+	if count > uint64(len(data)) {
+		return nil, errors.Errorf("the witness stack claims %d elements but only has %d bytes", count, len(data))
+	}
+	witness := make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		size, err := readCompactSize(reader)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed reading the size of witness element %d", i)
+		}
+		if size > uint64(reader.Len()) {
+			return nil, errors.Errorf("witness element %d claims %d bytes but only %d are left", i, size, reader.Len())
+		}
+		element := make([]byte, size)
+		_, _ = reader.Read(element)
+		witness = append(witness, element)
+	}
+	if reader.Len() != 0 {
+		return nil, errors.Errorf("%d trailing bytes after the witness stack", reader.Len())
+	}
+	return witness, nil
+}
+
+// readCompactSize reads a bitcoin variable length integer, rejecting non canonical encodings.
+func readCompactSize(reader *bytes.Reader) (uint64, error) {
+	prefix, err := reader.ReadByte()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	var size int
+	var min uint64
+	switch prefix {
+	case 0xfd:
+		size, min = 2, 0xfd
+	case 0xfe:
+		size, min = 4, 0x10000
+	case 0xff:
+		size, min = 8, 0x100000000
+	default:
+		return uint64(prefix), nil
+	}
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(reader, buf[:size]); err != nil {
+		return 0, errors.New("truncated compact size")
+	}
+	n := binary.LittleEndian.Uint64(buf)
+	if n < min {
+		return 0, errors.Errorf("non canonical compact size %d", n)
+	}
+	return n, nil
+}
@@ -0,0 +1,171 @@
+package secp256k1
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+// TestBIP322Vectors checks the message hashes and to_spend txids from BIP-322's test vectors.
+func TestBIP322Vectors(t *testing.T) {
+	// The vectors' to_spend transactions use the P2WPKH challenge of bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l.
+	scriptPubKey := decodeHex("00142b05d564e6a7a33c087f16e0f730d1440123799d")
+	tests := []struct {
+		message, messageHash, toSpend string
+	}{
+		{"", "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1",
+			"c5680aa69bb8d860bf82d4e9cd3504b55dde018de765a91bb566283c545a99a7"},
+		{"Hello World", "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a",
+			"b79d196740ad5217771c1098fc4a4b51e0535c32236c71f1ea4d61a2d603352b"},
+	}
+	for _, test := range tests {
+		messageHash := bip322MessageHash(test.message)
+		if !bytes.Equal(messageHash[:], decodeHex(test.messageHash)) {
+			t.Fatalf("Expected the message hash of '%s' to be %s, got %x", test.message, test.messageHash, messageHash[:])
+		}
+		toSpend := bip322ToSpendTxID(messageHash, scriptPubKey)
+		// txids are displayed in reverse byte order.
+		reversed := make([]byte, len(toSpend))
+		for i := range toSpend {
+			reversed[len(toSpend)-1-i] = toSpend[i]
+		}
+		if !bytes.Equal(reversed, decodeHex(test.toSpend)) {
+			t.Fatalf("Expected the to_spend txid of '%s' to be %s, got %x", test.message, test.toSpend, reversed)
+		}
+	}
+}
+
+func TestSignBIP322(t *testing.T) {
+	// The P2TR key of BIP-322's test vectors: L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k,
+	// with the BIP-86 address bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3
+	key, err := DeserializeSchnorrPrivateKeyFromSlice(decodeHex("bb051cd0dda0246f33c5a9e133ebd8e7bc02a92af6c41adc131ccd7826c5b004"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptPubKey := decodeHex("51200b34f2cc6f60d54e3fdc2d1dd053fcc393bd2db9acc8de4a7c3cc28a83d4d8e9")
+	for _, message := range []string{"", "Hello World"} {
+		signature, err := key.SignBIP322(message, scriptPubKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(signature) != 2+SerializedSchnorrSignatureSize || signature[0] != 1 || signature[1] != SerializedSchnorrSignatureSize {
+			t.Fatalf("Expected a single 64 byte element witness, got %x", signature)
+		}
+		valid, err := VerifyBIP322(message, scriptPubKey, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !valid {
+			t.Fatalf("Expected the signature of '%s' to verify", message)
+		}
+		valid, err = VerifyBIP322(message+"!", scriptPubKey, signature)
+		if err != nil || valid {
+			t.Fatalf("Expected the signature to not verify for a different message: %v", err)
+		}
+	}
+
+	// A keypair whose public key is the output key itself signs without tweaking.
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedPubKey, err := pubkey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	untweakedScriptPubKey := append([]byte{0x51, 0x20}, serializedPubKey[:]...)
+	signature, err := key.SignBIP322("Hello World", untweakedScriptPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := VerifyBIP322("Hello World", untweakedScriptPubKey, signature); err != nil || !valid {
+		t.Fatalf("Expected the signature for the untweaked key to verify: %v", err)
+	}
+	if valid, _ := VerifyBIP322("Hello World", scriptPubKey, signature); valid {
+		t.Fatalf("Expected the signature for the untweaked key to not verify for the tweaked key")
+	}
+
+	other, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.SignBIP322("Hello World", scriptPubKey); err == nil {
+		t.Fatalf("Expected an error signing for someone else's scriptPubKey")
+	}
+	if _, err := key.SignBIP322("Hello World", decodeHex("00142b05d564e6a7a33c087f16e0f730d1440123799d")); err == nil {
+		t.Fatalf("Expected an error signing for a non P2TR scriptPubKey")
+	}
+}
+
+// TestVerifyBIP322Vector checks BIP-322's P2TR signature test vector, which uses the SIGHASH_ALL type.
+func TestVerifyBIP322Vector(t *testing.T) {
+	// bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3
+	scriptPubKey := decodeHex("51200b34f2cc6f60d54e3fdc2d1dd053fcc393bd2db9acc8de4a7c3cc28a83d4d8e9")
+	signature, err := base64.StdEncoding.DecodeString("AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := VerifyBIP322("Hello World", scriptPubKey, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valid {
+		t.Fatalf("Expected BIP-322's signature of 'Hello World' to verify")
+	}
+	if valid, err := VerifyBIP322("", scriptPubKey, signature); err != nil || valid {
+		t.Fatalf("Expected BIP-322's signature of 'Hello World' to not verify for the empty message: %v", err)
+	}
+	tampered := append([]byte{}, signature...)
+	tampered[10] ^= 1
+	if valid, err := VerifyBIP322("Hello World", scriptPubKey, tampered); err != nil || valid {
+		t.Fatalf("Expected a tampered signature to not verify: %v", err)
+	}
+}
+
+func TestVerifyBIP322SigHashAll(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedPubKey, err := pubkey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptPubKey := append([]byte{0x51, 0x20}, serializedPubKey[:]...)
+	toSpend := bip322ToSpendTxID(bip322MessageHash("Hello World"), scriptPubKey)
+	sig, err := key.SchnorrSign(bip322SigHash(&toSpend, scriptPubKey, sigHashAll))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := append([]byte{1, SerializedSchnorrSignatureSize + 1}, sig.Serialize()[:]...)
+	signature = append(signature, sigHashAll)
+	if valid, err := VerifyBIP322("Hello World", scriptPubKey, signature); err != nil || !valid {
+		t.Fatalf("Expected a SIGHASH_ALL signature to verify: %v", err)
+	}
+	// The sighash type is committed to, so the same signature without it doesn't verify.
+	if valid, err := VerifyBIP322("Hello World", scriptPubKey, append([]byte{1, SerializedSchnorrSignatureSize}, sig.Serialize()[:]...)); err != nil || valid {
+		t.Fatalf("Expected the signature to not verify with the default sighash type: %v", err)
+	}
+	signature[len(signature)-1] = 0x02
+	if _, err := VerifyBIP322("Hello World", scriptPubKey, signature); err == nil {
+		t.Fatalf("Expected an error for SIGHASH_NONE")
+	}
+
+	malformed := [][]byte{
+		nil,
+		{0},
+		{1, SerializedSchnorrSignatureSize},
+		append([]byte{2, SerializedSchnorrSignatureSize}, make([]byte, SerializedSchnorrSignatureSize+1)...),
+		append([]byte{1, SerializedSchnorrSignatureSize}, make([]byte, SerializedSchnorrSignatureSize+1)...),
+		append([]byte{1, 0xfd, SerializedSchnorrSignatureSize, 0}, make([]byte, SerializedSchnorrSignatureSize)...),
+	}
+	for i, signature := range malformed {
+		if _, err := VerifyBIP322("Hello World", scriptPubKey, signature); err == nil {
+			t.Fatalf("Expected an error for malformed signature %d", i)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package secp256k1
+
+import "github.com/pkg/errors"
+
+// BlindSignerSession is the signer's side of a blind schnorr signature: a secret nonce that answers a single blinded challenge.
+// It should only be created via SchnorrKeyPair.BlindNonce. It isn't safe for concurrent use.
+type BlindSignerSession struct {
+	key         SchnorrKeyPair
+	nonceSecret [32]byte
+	used        bool
+}
+
+// BlindingState is the requester's secret state between BlindChallenge and Unblind.
+// It should only be created via BlindChallenge, and must be kept secret, it links the signature to the signing session.
+type BlindingState struct {
+	alpha     [32]byte
+	pubkey    SchnorrPublicKey
+	hash      Hash
+	r         [32]byte
+	challenge [32]byte
+}
+
+// BlindNonce starts a blind signing session, returning the session and the public nonce point R to send to the requester.
+// The requester blinds its message with BlindChallenge, and the signer answers the blinded challenge with BlindRespond.
+// The signer never sees the message or the final signature, and can't link the signature to the session.
+// Notice: this is plain blind schnorr, which is broken by the ROS attack (Benhamouda et al. 2020) if the signer has
+// many sessions open at the same time: a requester can then forge more signatures than the signer issued.
+// The signer must finish every session with BlindRespond (or discard it) before starting another one.
+func (key *SchnorrKeyPair) BlindNonce() (*BlindSignerSession, *ECDSAPublicKey, error) {
+	if !key.init {
+		return nil, nil, errors.WithStack(errNonInitializedKey)
+	}
+	nonce, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	noncePoint, err := nonce.ECDSAPublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	session := &BlindSignerSession{key: key.clone(), nonceSecret: *nonce.Serialize()}
+	*nonce = ECDSAPrivateKey{}
+	return session, noncePoint, nil
+}
+
+// BlindRespond answers the requester's blinded challenge with `s = k + e*x % Group Order`, see SchnorrRespond.
+// The session's nonce is erased afterwards, so every session answers at most one challenge, even if this fails.
+func (session *BlindSignerSession) BlindRespond(blindedChallenge [32]byte) ([32]byte, error) {
+	if session.used {
+		return [32]byte{}, errors.New("the blind signing session was already used")
+	}
+	session.used = true
+	defer func() {
+		session.nonceSecret = [32]byte{}
+		session.key = SchnorrKeyPair{}
+	}()
+	return SchnorrRespond(session.nonceSecret, blindedChallenge, &session.key)
+}
+
+// BlindChallenge blinds the hashed message for the signer's public key and nonce point,
+// returning the blinded challenge to send to the signer and the state that Unblind needs.
+// It picks random alpha and beta and computes the nonce of the final signature `R' = R + alpha*G + beta*P`
+// (retrying until it has an even Y) and the blinded challenge `e = e' + beta`, where e' is the BIP-340 challenge of R', P and msg.
+// Notice: the message *MUST* be a hash, and the blinding state must be kept secret and used with a single response.
+func BlindChallenge(noncePoint *ECDSAPublicKey, pubkey *SchnorrPublicKey, msg *Hash) (blindedChallenge [32]byte, state *BlindingState, err error) {
+	if noncePoint == nil || !noncePoint.init || pubkey == nil || !pubkey.init {
+		return [32]byte{}, nil, errors.WithStack(errNonInitializedKey)
+	}
+	if msg == nil {
+		return [32]byte{}, nil, errors.New("the message can't be nil")
+	}
+	fullPubKey, err := pubkey.toECDSA()
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	// Every attempt has a 1/2 chance of R' having an even Y, the counter only exists so this can't loop forever.
+	for counter := 0; counter < 256; counter++ {
+		alpha, err := GenerateECDSAPrivateKey()
+		if err != nil {
+			return [32]byte{}, nil, err
+		}
+		beta, err := GenerateECDSAPrivateKey()
+		if err != nil {
+			return [32]byte{}, nil, err
+		}
+		alphaG, err := alpha.ECDSAPublicKey()
+		if err != nil {
+			return [32]byte{}, nil, err
+		}
+		betaP := *fullPubKey
+		err = betaP.Mul(*beta.Serialize())
+		if err != nil {
+			return [32]byte{}, nil, err
+		}
+		blindedNonce, err := combineECDSAPublicKeys([]*ECDSAPublicKey{noncePoint, alphaG, &betaP})
+		if err != nil {
+			// R' is the point at infinity.
+			continue
+		}
+		serializedNonce, err := blindedNonce.Serialize()
+		if err != nil {
+			return [32]byte{}, nil, err
+		}
+		if serializedNonce[0] != 0x02 {
+			continue
+		}
+		state = &BlindingState{alpha: *alpha.Serialize(), pubkey: *pubkey, hash: *msg}
+		copy(state.r[:], serializedNonce[1:])
+		challenge, err := SchnorrChallenge(&state.r, pubkey, msg)
+		if err != nil {
+			return [32]byte{}, nil, err
+		}
+		state.challenge = ScalarAdd(challenge, *beta.Serialize())
+		*alpha = ECDSAPrivateKey{}
+		*beta = ECDSAPrivateKey{}
+		return state.challenge, state, nil
+	}
+	return [32]byte{}, nil, errors.New("failed blinding the nonce. Should never happen")
+}
+
+// Unblind turns the signer's response to the blinded challenge into a BIP-340 signature `(R', s + alpha)` of the message.
+// It returns an error if the resulting signature doesn't verify, i.e. the signer didn't answer honestly with the expected key.
+func Unblind(response [32]byte, state *BlindingState) (*SchnorrSignature, error) {
+	if state == nil {
+		return nil, errors.New("the blinding state is nil")
+	}
+	if _, overflowed := reduceScalar(&response); overflowed {
+		return nil, errors.New("the response has to be smaller than the group order")
+	}
+	signature := SchnorrSignatureFromRS(state.r, ScalarAdd(response, state.alpha))
+	if !state.pubkey.SchnorrVerify(&state.hash, signature) {
+		return nil, errors.New("the signer's response doesn't unblind to a valid signature")
+	}
+	return signature, nil
+}
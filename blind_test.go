@@ -0,0 +1,111 @@
+package secp256k1
+
+import "testing"
+
+func TestBlindSchnorr(t *testing.T) {
+	signer, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := signer.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < loopsN; i++ {
+		msg := Hash{byte(i), 0x29, 0x02}
+		session, noncePoint, err := signer.BlindNonce()
+		if err != nil {
+			t.Fatal(err)
+		}
+		blindedChallenge, state, err := BlindChallenge(noncePoint, pubkey, &msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := session.BlindRespond(blindedChallenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := Unblind(response, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pubkey.SchnorrVerify(&msg, signature) {
+			t.Fatalf("Expected the unblinded signature to verify")
+		}
+
+		// The signer's view of the session is unrelated to the signature.
+		r, s := signature.Split()
+		serializedNonce, err := noncePoint.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sessionR [32]byte
+		copy(sessionR[:], serializedNonce[1:])
+		if r == sessionR || s == response {
+			t.Fatalf("Expected the signature to not contain the signer's nonce or response")
+		}
+		challenge, err := SchnorrChallenge(&r, pubkey, &msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if challenge == blindedChallenge {
+			t.Fatalf("Expected the signer to not see the signature's challenge")
+		}
+
+		if _, err := session.BlindRespond(blindedChallenge); err == nil {
+			t.Fatalf("Expected an error answering a second challenge with the same session")
+		}
+	}
+}
+
+func TestBlindSchnorrWrongSigner(t *testing.T) {
+	signer, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPubKey, err := other.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Hash{0x29}
+	session, noncePoint, err := signer.BlindNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blindedChallenge, state, err := BlindChallenge(noncePoint, otherPubKey, &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := session.BlindRespond(blindedChallenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unblind(response, state); err == nil {
+		t.Fatalf("Expected an error unblinding a response by a different key")
+	}
+	if _, err := Unblind([32]byte{0: 0xff, 31: 0xff}, state); err == nil {
+		t.Fatalf("Expected an error unblinding an out of range response")
+	}
+	if _, err := Unblind(response, nil); err == nil {
+		t.Fatalf("Expected an error unblinding without a state")
+	}
+	if _, _, err := BlindChallenge(new(ECDSAPublicKey), otherPubKey, &msg); err == nil {
+		t.Fatalf("Expected an error blinding with an uninitialized nonce point")
+	}
+	if _, _, err := BlindChallenge(nil, otherPubKey, &msg); err == nil {
+		t.Fatalf("Expected an error blinding with a nil nonce point")
+	}
+	if _, _, err := BlindChallenge(noncePoint, nil, &msg); err == nil {
+		t.Fatalf("Expected an error blinding for a nil public key")
+	}
+	if _, _, err := BlindChallenge(noncePoint, otherPubKey, nil); err == nil {
+		t.Fatalf("Expected an error blinding a nil message")
+	}
+	if _, _, err := new(SchnorrKeyPair).BlindNonce(); err == nil {
+		t.Fatalf("Expected an error starting a session with an uninitialized keypair")
+	}
+}
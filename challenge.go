@@ -0,0 +1,56 @@
+package secp256k1
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// challengeTag is the tagged hash tag of a timestamped challenge
+const challengeTag = "go-secp256k1/challenge"
+
+// ChallengeTimestampSize is the length in bytes of the unix timestamp prefix of a timestamped challenge
+const ChallengeTimestampSize = 8
+
+// ErrChallengeExpired is returned by VerifyFresh when the challenge's timestamp isn't within maxAge of now.
+var ErrChallengeExpired = errors.New("the challenge's timestamp is too far from the current time")
+
+// NewTimestampedChallenge creates a challenge for VerifyFresh: the current unix time in seconds as 8 bytes big endian, followed by data.
+// data should contain a random nonce, so two challenges issued in the same second are different.
+func NewTimestampedChallenge(data []byte) []byte {
+	challenge := make([]byte, ChallengeTimestampSize+len(data))
+	binary.BigEndian.PutUint64(challenge, uint64(time.Now().Unix()))
+	copy(challenge[ChallengeTimestampSize:], data)
+	return challenge
+}
+
+// ChallengeHash returns the hash of a timestamped challenge that has to be signed for VerifyFresh:
+// `TaggedHash("go-secp256k1/challenge", challenge)`.
+func ChallengeHash(challenge []byte) *Hash {
+	return TaggedHash(challengeTag, challenge)
+}
+
+// VerifyFresh verifies a schnorr signature over ChallengeHash(challenge), after checking that the challenge's timestamp
+// is within maxAge of the current time, in either direction to allow for clock skew.
+// It returns ErrChallengeExpired if the timestamp is too old (or too far in the future),
+// an error if the challenge is shorter than its timestamp, and false without an error if the signature is invalid.
+// Notice: this only bounds replays to maxAge, the issuer still has to reject challenges it didn't issue or already accepted.
+func (key *SchnorrPublicKey) VerifyFresh(challenge []byte, signature *SchnorrSignature, maxAge time.Duration) (bool, error) {
+	return key.verifyFreshAt(challenge, signature, maxAge, time.Now())
+}
+
+func (key *SchnorrPublicKey) verifyFreshAt(challenge []byte, signature *SchnorrSignature, maxAge time.Duration, now time.Time) (bool, error) {
+	if len(challenge) < ChallengeTimestampSize {
+		return false, errors.Errorf("a timestamped challenge has to be at least %d bytes, instead got %d", ChallengeTimestampSize, len(challenge))
+	}
+	if !key.init {
+		return false, errors.WithStack(errNonInitializedKey)
+	}
+	timestamp := time.Unix(int64(binary.BigEndian.Uint64(challenge)), 0)
+	age := now.Sub(timestamp)
+	if age > maxAge || age < -maxAge {
+		return false, errors.Wrapf(ErrChallengeExpired, "the challenge was issued at %s", timestamp.UTC())
+	}
+	return key.SchnorrVerify(ChallengeHash(challenge), signature), nil
+}
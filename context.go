@@ -0,0 +1,143 @@
+package secp256k1
+
+// #include "./depend/secp256k1/include/secp256k1.h"
+import "C"
+import (
+	"runtime"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+// Context is a secp256k1 context created with only a subset of the capabilities.
+// A verify-only context doesn't build the signing tables, and a sign-only context doesn't build the (much larger) verification tables.
+// Notice: the package level functions keep using the default context which has both capabilities,
+// the Context methods are needed to actually do work with a restricted context.
+// A Context should be freed with Destroy once it's no longer used, a Context that isn't destroyed is freed by a finalizer
+// when it's garbage collected, but that can happen much later (or never, if the program exits first).
+type Context struct {
+	context   *C.secp256k1_context
+	canSign   bool
+	canVerify bool
+}
+
+var errDestroyedContext = errors.New("the context was destroyed or wasn't created via the supplied functions")
+
+// ContextHandle returns the package's default `secp256k1_context*` as an opaque pointer, so other cgo code can share it
+// instead of creating (and precomputing the tables of) another context.
+// The context is created and randomized once when the package is initialized, it's never destroyed and lives as long as the process.
+// Notice: the handle is only valid with this package's vendored build of libsecp256k1, the layout of the context isn't stable
+// across versions, so passing it to a different build of the library is undefined behavior.
+// The context is only safe for concurrent use because nothing modifies it after initialization: it *MUST NOT* be passed to
+// secp256k1_context_destroy, secp256k1_context_randomize, secp256k1_context_set_illegal_callback,
+// secp256k1_context_set_error_callback or any other function that takes a non-const context.
+func ContextHandle() unsafe.Pointer {
+	return unsafe.Pointer(context)
+}
+
+// NewVerifyOnlyContext creates a context that can only verify signatures.
+func NewVerifyOnlyContext() (*Context, error) {
+	return newContext(C.SECP256K1_CONTEXT_VERIFY, false, true)
+}
+
+// NewSignOnlyContext creates a context that can only create signatures, the context is randomized to help resist side channel attacks.
+func NewSignOnlyContext() (*Context, error) {
+	return newContext(C.SECP256K1_CONTEXT_SIGN, true, false)
+}
+
+func newContext(flags C.uint, canSign, canVerify bool) (*Context, error) {
+	ctx := &Context{canSign: canSign, canVerify: canVerify}
+	ctx.context = C.secp256k1_context_create(flags)
+	if ctx.context == nil {
+		return nil, errors.New("failed creating the context")
+	}
+	if canSign {
+		seed := [32]byte{}
+		err := readRandom(seed[:])
+		if err != nil {
+			ctx.Destroy()
+			return nil, err
+		}
+		cPtr := (*C.uchar)(&seed[0])
+		ret := C.secp256k1_context_randomize(ctx.context, cPtr)
+		if ret != 1 {
+			panic("Failed randomizing the context. Should never happen")
+		}
+	}
+	runtime.SetFinalizer(ctx, (*Context).Destroy)
+	return ctx, nil
+}
+
+// Destroy frees the memory used by the context, the context can't be used afterwards.
+// Calling it more than once is a no-op.
+func (ctx *Context) Destroy() {
+	if ctx.context == nil {
+		return
+	}
+	C.secp256k1_context_destroy(ctx.context)
+	ctx.context = nil
+	runtime.SetFinalizer(ctx, nil)
+}
+
+// SchnorrSign creates a schnorr signature using the private key and the input hashed message.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func (ctx *Context) SchnorrSign(key *SchnorrKeyPair, hash *Hash) (*SchnorrSignature, error) {
+	if ctx.context == nil {
+		return nil, errors.WithStack(errDestroyedContext)
+	}
+	if !ctx.canSign {
+		return nil, errors.New("the context can't be used for signing")
+	}
+	var auxilaryRand [32]byte
+	err := readRandom(auxilaryRand[:])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := key.schnorrSignWithContext(ctx.context, hash, &auxilaryRand)
+	// ctx must stay reachable until the C call returns, otherwise its finalizer could free the context during the call.
+	runtime.KeepAlive(ctx)
+	return signature, err
+}
+
+// ECDSASign creates an ECDSA signature using the private key and the input hashed message.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func (ctx *Context) ECDSASign(key *ECDSAPrivateKey, hash *Hash) (*ECDSASignature, error) {
+	if ctx.context == nil {
+		return nil, errors.WithStack(errDestroyedContext)
+	}
+	if !ctx.canSign {
+		return nil, errors.New("the context can't be used for signing")
+	}
+	var auxilaryRand [32]byte
+	err := readRandom(auxilaryRand[:])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := key.ecdsaSignWithContext(ctx.context, hash, &auxilaryRand)
+	runtime.KeepAlive(ctx)
+	return signature, err
+}
+
+// SchnorrVerify verifies a schnorr signature using the public key and the input hashed message.
+// Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+// This always returns false if the context can't be used for verifying.
+func (ctx *Context) SchnorrVerify(key *SchnorrPublicKey, hash *Hash, signature *SchnorrSignature) bool {
+	if ctx.context == nil || !ctx.canVerify {
+		return false
+	}
+	valid := key.schnorrVerifyWithContext(ctx.context, hash, signature)
+	runtime.KeepAlive(ctx)
+	return valid
+}
+
+// ECDSAVerify verifies a ECDSA signature using the public key and the input hashed message.
+// Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+// This always returns false if the context can't be used for verifying.
+func (ctx *Context) ECDSAVerify(key *ECDSAPublicKey, hash *Hash, signature *ECDSASignature) bool {
+	if ctx.context == nil || !ctx.canVerify {
+		return false
+	}
+	valid := key.ecdsaVerifyWithContext(ctx.context, hash, signature)
+	runtime.KeepAlive(ctx)
+	return valid
+}
@@ -0,0 +1,84 @@
+package secp256k1
+
+import (
+	"math/rand"
+	"testing"
+	"unsafe"
+)
+
+func TestRestrictedContexts(t *testing.T) {
+	signCtx, err := NewSignOnlyContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signCtx.Destroy()
+	verifyCtx, err := NewVerifyOnlyContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer verifyCtx.Destroy()
+
+	r := rand.New(rand.NewSource(5))
+	hash := Hash{}
+	r.Read(hash[:])
+	keypair := mustSchnorrKeyPair(t, r)
+	ecdsaKey, err := DeserializeECDSAPrivateKey(keypair.SerializePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	schnorrPubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaPubkey, err := ecdsaKey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schnorrSig, err := signCtx.SchnorrSign(keypair, &hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaSig, err := signCtx.ECDSASign(ecdsaKey, &hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyCtx.SchnorrVerify(schnorrPubkey, &hash, schnorrSig) || !schnorrPubkey.SchnorrVerify(&hash, schnorrSig) {
+		t.Errorf("Failed verifying a schnorr signature made with a sign-only context")
+	}
+	if !verifyCtx.ECDSAVerify(ecdsaPubkey, &hash, ecdsaSig) || !ecdsaPubkey.ECDSAVerify(&hash, ecdsaSig) {
+		t.Errorf("Failed verifying an ECDSA signature made with a sign-only context")
+	}
+
+	if signCtx.SchnorrVerify(schnorrPubkey, &hash, schnorrSig) || signCtx.ECDSAVerify(ecdsaPubkey, &hash, ecdsaSig) {
+		t.Errorf("A sign-only context shouldn't verify")
+	}
+	_, err = verifyCtx.SchnorrSign(keypair, &hash)
+	if err == nil {
+		t.Errorf("A verify-only context shouldn't sign")
+	}
+	_, err = verifyCtx.ECDSASign(ecdsaKey, &hash)
+	if err == nil {
+		t.Errorf("A verify-only context shouldn't sign")
+	}
+
+	signCtx.Destroy()
+	_, err = signCtx.SchnorrSign(keypair, &hash)
+	if err == nil {
+		t.Errorf("A destroyed context shouldn't sign")
+	}
+	verifyCtx.Destroy()
+	if verifyCtx.SchnorrVerify(schnorrPubkey, &hash, schnorrSig) {
+		t.Errorf("A destroyed context shouldn't verify")
+	}
+}
+
+func TestContextHandle(t *testing.T) {
+	handle := ContextHandle()
+	if handle == nil {
+		t.Fatalf("Expected the context handle to not be nil")
+	}
+	if handle != unsafe.Pointer(context) || ContextHandle() != handle {
+		t.Fatalf("Expected the handle to always be the default context")
+	}
+}
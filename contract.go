@@ -0,0 +1,32 @@
+package secp256k1
+
+// payToContractTag is the tagged hash tag of a pay-to-contract tweak
+const payToContractTag = "pay-to-contract"
+
+// CommitToData commits the data into the public key for pay-to-contract: it computes the tweak
+// `TaggedHash("pay-to-contract", P || data)`, where P is the x-only public key, and returns `P + tweak*Generator` and the tweak.
+// The owner of P's keypair can spend from the committed key by adding the same tweak with SchnorrKeyPair.Add,
+// and anyone who knows P and the data can check the commitment with VerifyDataCommitment.
+// The public key itself isn't modified.
+func (key *SchnorrPublicKey) CommitToData(data []byte) (committed *SchnorrPublicKey, tweak [32]byte, err error) {
+	serialized, err := key.Serialize()
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	tweak = *TaggedHash(payToContractTag, serialized[:], data)
+	committedKey := *key
+	err = committedKey.Add(tweak)
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	return &committedKey, tweak, nil
+}
+
+// VerifyDataCommitment returns true if committed is the public key with the data committed into it by CommitToData.
+func (key *SchnorrPublicKey) VerifyDataCommitment(committed *SchnorrPublicKey, data []byte) bool {
+	expected, _, err := key.CommitToData(data)
+	if err != nil {
+		return false
+	}
+	return expected.IsEqual(committed)
+}
@@ -0,0 +1,35 @@
+package secp256k1
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// counterTag is the tagged hash tag of a counter bound signature
+const counterTag = "go-secp256k1/counter"
+
+// ErrCounterReplayed is returned by VerifyWithCounter when the counter isn't bigger than the last one seen.
+var ErrCounterReplayed = errors.New("the counter isn't bigger than the last seen counter")
+
+// CounterHash returns the hash SignWithCounter signs: `TaggedHash("go-secp256k1/counter", counter as 8 bytes big endian || payload)`.
+func CounterHash(payload []byte, counter uint64) *Hash {
+	var serializedCounter [8]byte
+	binary.BigEndian.PutUint64(serializedCounter[:], counter)
+	return TaggedHash(counterTag, serializedCounter[:], payload)
+}
+
+// SignWithCounter signs the payload bound to a monotonic counter, so the verifier can reject replays with VerifyWithCounter.
+// The signer has to use a bigger counter for every signature, e.g. by persisting the last one used.
+func (key *SchnorrKeyPair) SignWithCounter(payload []byte, counter uint64) (*SchnorrSignature, error) {
+	return key.SchnorrSign(CounterHash(payload, counter))
+}
+
+// VerifyWithCounter verifies a SignWithCounter signature, after rejecting it with ErrCounterReplayed if counter <= lastSeen.
+// On success the caller should store counter as the new lastSeen.
+func (key *SchnorrPublicKey) VerifyWithCounter(payload []byte, counter uint64, lastSeen uint64, signature *SchnorrSignature) (bool, error) {
+	if counter <= lastSeen {
+		return false, errors.Wrapf(ErrCounterReplayed, "got %d, last seen %d", counter, lastSeen)
+	}
+	return key.SchnorrVerify(CounterHash(payload, counter), signature), nil
+}
@@ -0,0 +1,176 @@
+package secp256k1
+
+import (
+	"encoding/hex"
+	"github.com/pkg/errors"
+)
+
+const (
+	// dleqTag is the tagged hash tag of a DLEQ proof's challenge
+	dleqTag = "go-secp256k1/dleq"
+	// dleqNonceTag is the tagged hash tag of a DLEQ proof's nonce
+	dleqNonceTag = "go-secp256k1/dleq/nonce"
+
+	// SerializedDLEQProofSize defines the length in bytes of SerializedDLEQProof
+	SerializedDLEQProofSize = 64
+)
+
+// DLEQProof is a proof that two pairs of points share the same discrete logarithm, i.e. that `P = x*Generator` and `P2 = x*G2`
+// for the same x, without revealing x. It's a Chaum-Pedersen proof made non-interactive with the Fiat-Shamir transform.
+// The struct itself is an opaque data type that should only be created via the supplied methods.
+type DLEQProof struct {
+	challenge [32]byte
+	response  [32]byte
+}
+
+// SerializedDLEQProof is a byte array representing the storage representation of a DLEQProof, `challenge (32 bytes) || response (32 bytes)`
+type SerializedDLEQProof [SerializedDLEQProofSize]byte
+
+// String returns the SerializedDLEQProof as the hexadecimal string
+func (serialized SerializedDLEQProof) String() string {
+	return hex.EncodeToString(serialized[:])
+}
+
+// String returns the DLEQProof as the hexadecimal string
+func (proof DLEQProof) String() string {
+	return proof.Serialize().String()
+}
+
+// Serialize returns a 64 byte serialized proof
+func (proof *DLEQProof) Serialize() *SerializedDLEQProof {
+	serialized := SerializedDLEQProof{}
+	copy(serialized[:32], proof.challenge[:])
+	copy(serialized[32:], proof.response[:])
+	return &serialized
+}
+
+// DeserializeDLEQProof deserializes a 64 byte serialized proof, verifying both scalars are smaller than the group order.
+func DeserializeDLEQProof(serialized *SerializedDLEQProof) (*DLEQProof, error) {
+	proof := DLEQProof{}
+	copy(proof.challenge[:], serialized[:32])
+	copy(proof.response[:], serialized[32:])
+	if _, overflowed := reduceScalar(&proof.challenge); overflowed {
+		return nil, errors.New("the proof's challenge is bigger than the group order")
+	}
+	if _, overflowed := reduceScalar(&proof.response); overflowed {
+		return nil, errors.New("the proof's response is bigger than the group order")
+	}
+	return &proof, nil
+}
+
+// ProveDLEQ proves that the keypair's private key x is also the discrete logarithm of P2 with respect to G2, i.e. `P2 = x*G2`,
+// the proof is verified with VerifyDLEQ against the keypair's full (not x-only) public key `P = x*Generator`.
+// It's an error if P2 isn't x*G2.
+// The nonce is derived from the private key, the points and fresh randomness, so it's safe even if the randomness is broken.
+func ProveDLEQ(key *SchnorrKeyPair, G2, P2 *ECDSAPublicKey) (*DLEQProof, error) {
+	if key == nil || !key.init || G2 == nil || !G2.init || P2 == nil || !P2.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	serializedPrivateKey := key.SerializePrivateKey()
+	defer func() { *serializedPrivateKey = SerializedPrivateKey{} }()
+	privateKey, err := DeserializeECDSAPrivateKey(serializedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { privateKey.privateKey = [32]byte{} }()
+	P, err := privateKey.ECDSAPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	expectedP2 := *G2
+	err = expectedP2.Mul(privateKey.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if !expectedP2.IsEqual(P2) {
+		return nil, errors.New("P2 isn't the private key times G2")
+	}
+	points, err := serializePoints(P, G2, P2)
+	if err != nil {
+		return nil, err
+	}
+
+	var auxiliaryRand [32]byte
+	err = readRandom(auxiliaryRand[:])
+	if err != nil {
+		return nil, err
+	}
+	// The chance of the hash not being a valid nonce is less than 2^-127, the counter only exists so this can't loop forever.
+	for counter := 0; counter < 256; counter++ {
+		nonce := TaggedHash(dleqNonceTag, serializedPrivateKey[:], auxiliaryRand[:], points, []byte{byte(counter)})
+		noncePrivateKey, err := DeserializeECDSAPrivateKey((*SerializedPrivateKey)(nonce))
+		*nonce = Hash{}
+		if err != nil {
+			continue
+		}
+		defer func() { noncePrivateKey.privateKey = [32]byte{} }()
+		R1, err := noncePrivateKey.ECDSAPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		R2 := *G2
+		err = R2.Mul(noncePrivateKey.privateKey)
+		if err != nil {
+			return nil, err
+		}
+		challenge, err := dleqChallenge(points, R1, &R2)
+		if err != nil {
+			return nil, err
+		}
+		response := ScalarAdd(noncePrivateKey.privateKey, ScalarMul(challenge, privateKey.privateKey))
+		return &DLEQProof{challenge: challenge, response: response}, nil
+	}
+	return nil, errors.New("failed generating a valid nonce. Should never happen")
+}
+
+// VerifyDLEQ returns true if the proof shows that P and P2 have the same discrete logarithm with respect to Generator and G2,
+// i.e. that `P = x*Generator` and `P2 = x*G2` for some x. see ProveDLEQ
+func VerifyDLEQ(P, G2, P2 *ECDSAPublicKey, proof *DLEQProof) bool {
+	if P == nil || !P.init || G2 == nil || !G2.init || P2 == nil || !P2.init || proof == nil {
+		return false
+	}
+	points, err := serializePoints(P, G2, P2)
+	if err != nil {
+		return false
+	}
+	// R1 = s*Generator - e*P, R2 = s*G2 - e*P2
+	negatedChallenge := ScalarNegate(proof.challenge)
+	response := proof.response
+	R1, isInfinity, err := multiScalarMultInternal(&response, [][32]byte{negatedChallenge}, []*ECDSAPublicKey{P})
+	if err != nil || isInfinity {
+		return false
+	}
+	R2, isInfinity, err := multiScalarMultInternal(nil, [][32]byte{proof.response, negatedChallenge}, []*ECDSAPublicKey{G2, P2})
+	if err != nil || isInfinity {
+		return false
+	}
+	challenge, err := dleqChallenge(points, R1, R2)
+	if err != nil {
+		return false
+	}
+	return challenge == proof.challenge
+}
+
+// dleqChallenge computes `TaggedHash("go-secp256k1/dleq", P || G2 || P2 || R1 || R2) % Group Order` with compressed points.
+func dleqChallenge(points []byte, R1, R2 *ECDSAPublicKey) ([32]byte, error) {
+	nonces, err := serializePoints(R1, R2)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	hash := [32]byte(*TaggedHash(dleqTag, points, nonces))
+	challenge, _ := reduceScalar(&hash)
+	return challenge, nil
+}
+
+// serializePoints concatenates the compressed serialization of the points.
+func serializePoints(points ...*ECDSAPublicKey) ([]byte, error) {
+	serialized := make([]byte, 0, SerializedECDSAPublicKeySize*len(points))
+	for _, point := range points {
+		serializedPoint, err := point.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		serialized = append(serialized, serializedPoint[:]...)
+	}
+	return serialized, nil
+}
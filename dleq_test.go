@@ -0,0 +1,89 @@
+package secp256k1
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDLEQ(t *testing.T) {
+	r := rand.New(rand.NewSource(179))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		privateKey, err := DeserializeECDSAPrivateKey(keypair.SerializePrivateKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		P, err := privateKey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		G2, err := TweakPublicKey(*fastGenerateTweak(t, r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		P2 := *G2
+		err = P2.Mul(*keypair.SerializePrivateKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		proof, err := ProveDLEQ(keypair, G2, &P2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyDLEQ(P, G2, &P2, proof) {
+			t.Fatalf("Expected the DLEQ proof to verify")
+		}
+		deserialized, err := DeserializeDLEQProof(proof.Serialize())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *deserialized != *proof {
+			t.Fatalf("Expected the proof to round trip: %s != %s", deserialized, proof)
+		}
+
+		// The proof doesn't verify for another pair of points.
+		otherP2 := P2
+		err = otherP2.Add(*fastGenerateTweak(t, r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if VerifyDLEQ(P, G2, &otherP2, proof) || VerifyDLEQ(&P2, G2, P, proof) || VerifyDLEQ(P, &P2, G2, proof) {
+			t.Fatalf("Expected the DLEQ proof to not verify against other points")
+		}
+		tampered := *proof
+		tampered.response[31] ^= 1
+		if VerifyDLEQ(P, G2, &P2, &tampered) {
+			t.Fatalf("Expected a tampered DLEQ proof to not verify")
+		}
+		if _, err := ProveDLEQ(keypair, G2, &otherP2); err == nil {
+			t.Fatalf("Expected an error proving a false statement")
+		}
+		if VerifyDLEQ(nil, G2, &P2, proof) || VerifyDLEQ(P, nil, &P2, proof) || VerifyDLEQ(P, G2, nil, proof) || VerifyDLEQ(P, G2, &P2, nil) {
+			t.Fatalf("Expected a DLEQ proof with a nil argument to not verify")
+		}
+		if _, err := ProveDLEQ(nil, G2, &P2); err == nil {
+			t.Fatalf("Expected an error proving with a nil keypair")
+		}
+		if _, err := ProveDLEQ(keypair, nil, &P2); err == nil {
+			t.Fatalf("Expected an error proving with a nil G2")
+		}
+		if _, err := ProveDLEQ(keypair, G2, nil); err == nil {
+			t.Fatalf("Expected an error proving with a nil P2")
+		}
+	}
+
+	invalid := SerializedDLEQProof{}
+	for i := range invalid {
+		invalid[i] = 0xff
+	}
+	if _, err := DeserializeDLEQProof(&invalid); err == nil {
+		t.Fatalf("Expected an error deserializing a proof with scalars bigger than the group order")
+	}
+	if _, err := ProveDLEQ(new(SchnorrKeyPair), &ECDSAPublicKey{}, &ECDSAPublicKey{}); err == nil {
+		t.Fatalf("Expected an error proving with uninitialized keys")
+	}
+	if VerifyDLEQ(&ECDSAPublicKey{}, &ECDSAPublicKey{}, &ECDSAPublicKey{}, &DLEQProof{}) {
+		t.Fatalf("Expected a proof over uninitialized keys to not verify")
+	}
+}
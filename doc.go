@@ -0,0 +1,10 @@
+/*
+Package secp256k1 provides Go bindings for libsecp256k1's ECDSA and BIP-340 schnorr signatures.
+
+# Byte order
+
+All the scalars (private keys, tweaks, signature components and challenges), field elements (x-only public keys)
+and hashes in this package are 32 byte big endian arrays, matching libsecp256k1 and the BIPs.
+Use ReverseScalar to convert from/to systems that use little endian scalars.
+*/
+package secp256k1
@@ -0,0 +1,52 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+// maxDerivedKeyLength is the most HKDF-SHA256 can derive, 255 blocks of 32 bytes
+const maxDerivedKeyLength = 255 * sha256.Size
+
+// ECDHXOnly computes the x-only ECDH shared secret with the peer's x-only public key:
+// the peer key is lifted to the point with an even Y, multiplied by the private key, and the x coordinate of the result is returned as is.
+// Because x(d*P) == x(-d*P) the result doesn't depend on the parity of either key, so both sides get the same secret.
+// Notice: the raw x coordinate isn't uniformly random, hash it (e.g. with TaggedHash) before using it as a symmetric key.
+func (key *SchnorrKeyPair) ECDHXOnly(peer *SchnorrPublicKey) (*Hash, error) {
+	if !key.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	point, err := peer.toECDSA()
+	if err != nil {
+		return nil, err
+	}
+	privateKey := key.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	err = point.Mul(*privateKey)
+	if err != nil {
+		return nil, err
+	}
+	serialized, err := point.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	shared := Hash{}
+	copy(shared[:], serialized[1:])
+	return &shared, nil
+}
+
+// DeriveKey derives a length bytes long symmetric key from the shared secret (e.g. from ECDHXOnly) with HKDF-SHA256 (RFC 5869).
+// No salt is used (HKDF then uses 32 zero bytes), info should bind the key to its purpose, e.g. the protocol name and both public keys.
+func (secret *Hash) DeriveKey(info []byte, length int) ([]byte, error) {
+	if length <= 0 || length > maxDerivedKeyLength {
+		return nil, errors.Errorf("the key length has to be between 1 and %d, instead got %d", maxDerivedKeyLength, length)
+	}
+	key := make([]byte, length)
+	_, err := io.ReadFull(hkdf.New(sha256.New, secret[:], nil, info), key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed deriving the key")
+	}
+	return key, nil
+}
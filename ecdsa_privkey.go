@@ -3,7 +3,6 @@ package secp256k1
 // #include "./depend/secp256k1/include/secp256k1.h"
 import "C"
 import (
-	"crypto/rand"
 	"github.com/pkg/errors"
 	"unsafe"
 )
@@ -32,14 +31,18 @@ func (key *ECDSAPrivateKey) ECDSAPublicKey() (*ECDSAPublicKey, error) {
 // Notice: the [32] byte array *MUST* be a hash of a message.
 func (key *ECDSAPrivateKey) ECDSASign(hash *Hash) (*ECDSASignature, error) {
 	var auxilaryRand [32]byte
-	n, err := rand.Read(auxilaryRand[:])
-	if err != nil || n != len(auxilaryRand) {
+	err := readRandom(auxilaryRand[:])
+	if err != nil {
 		return nil, err
 	}
 	return key.ecdsaSignInternal(hash, &auxilaryRand)
 }
 
 func (key *ECDSAPrivateKey) ecdsaSignInternal(hash *Hash, auxiliaryRand *[32]byte) (*ECDSASignature, error) {
+	return key.ecdsaSignWithContext(context, hash, auxiliaryRand)
+}
+
+func (key *ECDSAPrivateKey) ecdsaSignWithContext(ctx *C.secp256k1_context, hash *Hash, auxiliaryRand *[32]byte) (*ECDSASignature, error) {
 	if !key.init {
 		return nil, errNonInitializedKey
 	}
@@ -47,7 +50,7 @@ func (key *ECDSAPrivateKey) ecdsaSignInternal(hash *Hash, auxiliaryRand *[32]byt
 	cPtrHash := (*C.uchar)(&hash[0])
 	cPtrPrivKey := (*C.uchar)(&key.privateKey[0])
 	cPtrAux := unsafe.Pointer(auxiliaryRand)
-	ret := C.secp256k1_ecdsa_sign(context, &signature.signature, cPtrHash, cPtrPrivKey, C.secp256k1_nonce_function_rfc6979, cPtrAux)
+	ret := C.secp256k1_ecdsa_sign(ctx, &signature.signature, cPtrHash, cPtrPrivKey, C.secp256k1_nonce_function_rfc6979, cPtrAux)
 	if ret != 1 {
 		return nil, errors.New("failed Signing. You should call `DeserializeECDSAPrivateKey` before calling this")
 	}
@@ -87,21 +90,12 @@ func DeserializeECDSAPrivateKeyFromSlice(data []byte) (key *ECDSAPrivateKey, err
 
 // GenerateECDSAPrivateKey generates a random valid private key from `crypto/rand`
 func GenerateECDSAPrivateKey() (key *ECDSAPrivateKey, err error) {
-	key = &ECDSAPrivateKey{init: true}
-	cPtr := (*C.uchar)(&key.privateKey[0])
-	for {
-		n, tmpErr := rand.Read(key.privateKey[:])
-		if tmpErr != nil {
-			return nil, tmpErr
-		}
-		if n != len(key.privateKey) {
-			panic("The standard library promises that this should never happen")
-		}
-		ret := C.secp256k1_ec_seckey_verify(C.secp256k1_context_no_precomp, cPtr)
-		if ret == 1 {
-			return key, nil
-		}
+	rawKey, err := generatePrivateKey()
+	if err != nil {
+		return nil, err
 	}
+	defer func() { *rawKey = SerializedPrivateKey{} }()
+	return DeserializeECDSAPrivateKey(rawKey)
 }
 
 // Serialize a private key
@@ -138,6 +132,24 @@ func (key *ECDSAPrivateKey) Add(tweak [32]byte) error {
 	return nil
 }
 
+// Mul multiplies the private key by a tweak `key * tweak % Group Order`. this multiplies it in place, the key is unchanged if this fails.
+func (key *ECDSAPrivateKey) Mul(tweak [32]byte) error {
+	if !key.init {
+		return errors.WithStack(errNonInitializedKey)
+	}
+	// libsecp256k1 zeroes the key if the tweak is invalid, so tweak a copy to leave the key usable on failure.
+	tweaked := key.privateKey
+	defer func() { tweaked = [32]byte{} }()
+	cPtrKey := (*C.uchar)(&tweaked[0])
+	cPtrTweak := (*C.uchar)(&tweak[0])
+	ret := C.secp256k1_ec_privkey_tweak_mul(C.secp256k1_context_no_precomp, cPtrKey, cPtrTweak)
+	if ret != 1 {
+		return errors.New("failed multiplying the private key. Tweak is zero or bigger than the order")
+	}
+	key.privateKey = tweaked
+	return nil
+}
+
 // ToSchnorr converts an ECDSA private key to a schnorr keypair
 // Note: You shouldn't sign using the same key in both ECDSA and Schnorr signatures.
 // this function is for convenience when using BIP-32
@@ -1,12 +1,31 @@
 package secp256k1
 
+// #include <stdlib.h>
 // #include "./depend/secp256k1/include/secp256k1.h"
 // #include "./depend/secp256k1/include/secp256k1_extrakeys.h"
+//
+// static int pubkey_combine(const secp256k1_context* ctx, secp256k1_pubkey *out, const secp256k1_pubkey *pubkeys, size_t n) {
+//     size_t i;
+//     int ret;
+//     const secp256k1_pubkey **pointers = malloc(n * sizeof(secp256k1_pubkey*));
+//     if (pointers == NULL) {
+//         return -1;
+//     }
+//     for (i = 0; i < n; i++) {
+//         pointers[i] = &pubkeys[i];
+//     }
+//     ret = secp256k1_ec_pubkey_combine(ctx, out, pointers, n);
+//     free(pointers);
+//     return ret;
+// }
 import "C"
 import (
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"github.com/pkg/errors"
+	"math/big"
+	"time"
 )
 
 // SerializedECDSAPublicKeySize defines the length in bytes of a SerializedECDSAPublicKey
@@ -19,6 +38,16 @@ type ECDSAPublicKey struct {
 	init   bool
 }
 
+var (
+	// ErrSignatureZeroComponent is returned by ECDSAVerifyDetailed when the signature has R or S equal to zero.
+	ErrSignatureZeroComponent = errors.New("malformed signature, R or S is zero")
+	// ErrSignatureHighS is returned by ECDSAVerifyDetailed when the signature's S is in the upper half of the group order.
+	// libsecp256k1 only accepts lower-S signatures to prevent malleability, see ECDSASignature.NormalizeS
+	ErrSignatureHighS = errors.New("malformed signature, S isn't in the lower half of the group order")
+	// ErrSignatureMismatch is returned by ECDSAVerifyDetailed when a well formed signature doesn't match the public key and hash.
+	ErrSignatureMismatch = errors.New("the signature doesn't match the public key and hash")
+)
+
 // SerializedECDSAPublicKey is a is a byte array representing the storage representation of a compressed ECDSAPublicKey
 type SerializedECDSAPublicKey [SerializedECDSAPublicKeySize]byte
 
@@ -64,9 +93,40 @@ func (key ECDSAPublicKey) String() string {
 
 // ECDSAVerify verifies a ECDSA signature using the public key and the input hashed message.
 // Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+// Signatures with R or S equal to zero are rejected before reaching libsecp256k1.
 func (key *ECDSAPublicKey) ECDSAVerify(hash *Hash, signature *ECDSASignature) bool {
+	return key.ecdsaVerifyWithContext(context, hash, signature)
+}
+
+// ECDSAVerifyDetailed is like ECDSAVerify, but returns an error describing why the verification failed.
+// The errors can be compared with errors.Is against ErrSignatureZeroComponent, ErrSignatureHighS and ErrSignatureMismatch.
+// It returns nil if the signature is valid.
+func (key *ECDSAPublicKey) ECDSAVerifyDetailed(hash *Hash, signature *ECDSASignature) error {
+	if !key.init {
+		return errors.WithStack(errNonInitializedKey)
+	}
+	if signature.hasZeroComponent() {
+		return errors.WithStack(ErrSignatureZeroComponent)
+	}
+	if signature.IsHighS() {
+		return errors.WithStack(ErrSignatureHighS)
+	}
+	if !key.ecdsaVerifyWithContext(context, hash, signature) {
+		return errors.WithStack(ErrSignatureMismatch)
+	}
+	return nil
+}
+
+func (key *ECDSAPublicKey) ecdsaVerifyWithContext(ctx *C.secp256k1_context, hash *Hash, signature *ECDSASignature) (valid bool) {
+	if observer := loadVerifyObserver(); observer != nil {
+		start := time.Now()
+		defer func() { observer(valid, time.Since(start)) }()
+	}
+	if signature.hasZeroComponent() {
+		return false
+	}
 	cPtrHash := (*C.uchar)(&hash[0])
-	return C.secp256k1_ecdsa_verify(context, &signature.signature, cPtrHash, &key.pubkey) == 1
+	return C.secp256k1_ecdsa_verify(ctx, &signature.signature, cPtrHash, &key.pubkey) == 1
 }
 
 // DeserializeECDSAPubKey deserializes a serialized ECDSA public key, verifying it's valid.
@@ -85,6 +145,71 @@ func DeserializeECDSAPubKey(serializedPubKey []byte) (*ECDSAPublicKey, error) {
 	return &key, nil
 }
 
+// DeserializeECDSAPubKeyCompressedOnly deserializes a compressed ECDSA public key, it's an error if the data isn't
+// exactly 33 bytes with a 0x02 or 0x03 prefix, even if it's a valid encoding of a point in another form.
+// DeserializeECDSAPubKey currently accepts the same keys, this pins that down for protocols whose rules depend on it.
+func DeserializeECDSAPubKeyCompressedOnly(data []byte) (*ECDSAPublicKey, error) {
+	if len(data) != SerializedECDSAPublicKeySize {
+		return nil, errors.Errorf("a compressed public key has to be %d bytes, instead got %d", SerializedECDSAPublicKeySize, len(data))
+	}
+	if data[0] != 0x02 && data[0] != 0x03 {
+		return nil, errors.Errorf("a compressed public key has to start with 0x02 or 0x03, instead got 0x%02x", data[0])
+	}
+	return DeserializeECDSAPubKey(data)
+}
+
+// NewECDSAPublicKey creates a ECDSA public key from its affine coordinates, verifying the point is on the curve.
+func NewECDSAPublicKey(x, y *big.Int) (*ECDSAPublicKey, error) {
+	if x == nil || y == nil {
+		return nil, errors.New("the coordinates can't be nil")
+	}
+	if x.Sign() < 0 || y.Sign() < 0 || x.BitLen() > 256 || y.BitLen() > 256 {
+		return nil, errors.New("the coordinates have to be between 0 and the field size")
+	}
+	serialized := [65]byte{0x04}
+	xBytes, yBytes := x.Bytes(), y.Bytes()
+	copy(serialized[33-len(xBytes):33], xBytes)
+	copy(serialized[65-len(yBytes):], yBytes)
+
+	key := ECDSAPublicKey{init: true}
+	cPtr := (*C.uchar)(&serialized[0])
+	ret := C.secp256k1_ec_pubkey_parse(C.secp256k1_context_no_precomp, &key.pubkey, cPtr, C.size_t(len(serialized)))
+	if ret != 1 {
+		return nil, errors.New("the point isn't on the curve")
+	}
+	return &key, nil
+}
+
+// DecompressPoint creates a ECDSA public key from its x coordinate and the parity of its y coordinate,
+// it's the same as deserializing the compressed `0x02 || x` (even y) or `0x03 || x` (odd y) public key.
+// It's an error if x isn't smaller than the field size or isn't the x coordinate of a point on the curve.
+func DecompressPoint(x [32]byte, oddY bool) (*ECDSAPublicKey, error) {
+	serialized := [SerializedECDSAPublicKeySize]byte{0x02}
+	if oddY {
+		serialized[0] = 0x03
+	}
+	copy(serialized[1:], x[:])
+
+	key := ECDSAPublicKey{init: true}
+	cPtr := (*C.uchar)(&serialized[0])
+	ret := C.secp256k1_ec_pubkey_parse(C.secp256k1_context_no_precomp, &key.pubkey, cPtr, C.size_t(len(serialized)))
+	if ret != 1 {
+		return nil, errors.New("x isn't the x coordinate of a point on the curve")
+	}
+	return &key, nil
+}
+
+// LeadingZeroBits returns the number of leading zero bits of the public key's x coordinate, e.g. for scoring vanity keys.
+// The 0x02/0x03 prefix byte of the compressed serialization isn't counted, so this matches SchnorrPublicKey.LeadingZeroBits.
+// It returns 0 if the key isn't initialized.
+func (key *ECDSAPublicKey) LeadingZeroBits() int {
+	serialized, err := key.Serialize()
+	if err != nil {
+		return 0
+	}
+	return leadingZeroBits(serialized[1:])
+}
+
 // Serialize serializes a ECDSA public key
 func (key *ECDSAPublicKey) Serialize() (*SerializedECDSAPublicKey, error) {
 	if !key.init {
@@ -104,6 +229,71 @@ func (key *ECDSAPublicKey) Serialize() (*SerializedECDSAPublicKey, error) {
 	return &serialized, nil
 }
 
+// Coordinates returns the affine coordinates of the public key, it's the inverse of NewECDSAPublicKey.
+// It returns nils if the key isn't initialized.
+func (key *ECDSAPublicKey) Coordinates() (x, y *big.Int) {
+	serialized, err := key.serializeUncompressed()
+	if err != nil {
+		return nil, nil
+	}
+	return new(big.Int).SetBytes(serialized[1:33]), new(big.Int).SetBytes(serialized[33:])
+}
+
+// combineECDSAPublicKeys returns the sum of the public keys, it fails if the sum is the point at infinity.
+func combineECDSAPublicKeys(keys []*ECDSAPublicKey) (*ECDSAPublicKey, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one public key is needed")
+	}
+	cPubkeys := make([]C.secp256k1_pubkey, len(keys))
+	for i, key := range keys {
+		if key == nil || !key.init {
+			return nil, errors.WithStack(errNonInitializedKey)
+		}
+		cPubkeys[i] = key.pubkey
+	}
+	result := ECDSAPublicKey{init: true}
+	ret := C.pubkey_combine(C.secp256k1_context_no_precomp, &result.pubkey, &cPubkeys[0], C.size_t(len(cPubkeys)))
+	switch ret {
+	case 1:
+		return &result, nil
+	case 0:
+		return nil, errors.New("the sum of the public keys is the point at infinity")
+	default:
+		panic("failed allocating memory")
+	}
+}
+
+// serializeUncompressed serializes the public key as an uncompressed 65 byte point `0x04 || X || Y`
+func (key *ECDSAPublicKey) serializeUncompressed() (*[65]byte, error) {
+	if !key.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	serialized := [65]byte{}
+	cPtr := (*C.uchar)(&serialized[0])
+	cLen := C.size_t(len(serialized))
+
+	ret := C.secp256k1_ec_pubkey_serialize(C.secp256k1_context_no_precomp, cPtr, &cLen, &key.pubkey, C.SECP256K1_EC_UNCOMPRESSED)
+	if ret != 1 {
+		panic("failed serializing a pubkey. Should never happen (upstream promise to return 1)")
+	}
+	if cLen != C.size_t(len(serialized)) {
+		panic("Returned length should be 65 because we passed SECP256K1_EC_UNCOMPRESSED")
+	}
+	return &serialized, nil
+}
+
+// TweakPublicKey returns the point `tweak*Generator`, e.g. for checking a pay-to-contract commitment `P' == P + tweak*Generator`
+// without tweaking P in place. It fails if the tweak is zero or not smaller than the group order.
+func TweakPublicKey(tweak [32]byte) (*ECDSAPublicKey, error) {
+	pubkey := ECDSAPublicKey{init: true}
+	cPtrTweak := (*C.uchar)(&tweak[0])
+	ret := C.secp256k1_ec_pubkey_create(context, &pubkey.pubkey, cPtrTweak)
+	if ret != 1 {
+		return nil, errors.New("failed computing the tweak's public key. Tweak is zero or bigger than the order")
+	}
+	return &pubkey, nil
+}
+
 // Add a tweak to the public key by doing `key + tweak*Generator`. this adds it in place.
 // This is meant for creating BIP-32(HD) wallets
 func (key *ECDSAPublicKey) Add(tweak [32]byte) error {
@@ -118,6 +308,23 @@ func (key *ECDSAPublicKey) Add(tweak [32]byte) error {
 	return nil
 }
 
+// Mul multiplies the public key by a tweak `key * tweak`. this multiplies it in place, the key is unchanged if this fails.
+// Equivalent to multiplying the private key and then generating the public key.
+func (key *ECDSAPublicKey) Mul(tweak [32]byte) error {
+	if !key.init {
+		return errors.WithStack(errNonInitializedKey)
+	}
+	// libsecp256k1 zeroes the key if the tweak is invalid, so tweak a copy to leave the key usable on failure.
+	tweaked := key.pubkey
+	cPtrTweak := (*C.uchar)(&tweak[0])
+	ret := C.secp256k1_ec_pubkey_tweak_mul(context, &tweaked, cPtrTweak)
+	if ret != 1 {
+		return errors.New("failed multiplying the public key. Tweak is zero or bigger than the order")
+	}
+	key.pubkey = tweaked
+	return nil
+}
+
 // Negate a public key in place.
 // Equivalent to negating the private key and then generating the public key.
 func (key *ECDSAPublicKey) Negate() error {
@@ -145,3 +352,25 @@ func (key *ECDSAPublicKey) ToSchnorr() (*SchnorrPublicKey, error) {
 	}
 	return &schnorrPubKey, nil
 }
+
+// SelectPublicKey returns a copy of keys[index], reading every key in the same way regardless of the index,
+// so which of the keys was selected doesn't leak through timing or cache access patterns.
+// It returns nil if the index is out of range or any of the keys is nil or uninitialized, these checks don't depend on the index.
+func SelectPublicKey(keys []*ECDSAPublicKey, index int) *ECDSAPublicKey {
+	if index < 0 || index >= len(keys) {
+		return nil
+	}
+	for _, key := range keys {
+		if key == nil || !key.init {
+			return nil
+		}
+	}
+	selected := ECDSAPublicKey{init: true}
+	for i, key := range keys {
+		mask := C.uchar(-subtle.ConstantTimeEq(int32(i), int32(index)))
+		for j := range selected.pubkey.data {
+			selected.pubkey.data[j] |= mask & key.pubkey.data[j]
+		}
+	}
+	return &selected
+}
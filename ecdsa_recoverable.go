@@ -0,0 +1,183 @@
+package secp256k1
+
+// #include "./depend/secp256k1/include/secp256k1_recovery.h"
+import "C"
+import (
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"unsafe"
+)
+
+// SerializedECDSARecoverableSignatureSize defines the length in bytes of SerializedECDSARecoverableSignature
+const SerializedECDSARecoverableSignatureSize = 65
+
+// ECDSARecoverableSignature is a type representing a ECDSA Signature with a recovery id, which allows recovering the public key.
+// The struct itself is an opaque data type that should only be created via the supplied methods.
+type ECDSARecoverableSignature struct {
+	signature C.secp256k1_ecdsa_recoverable_signature
+}
+
+// SerializedECDSARecoverableSignature is a byte array representing the storage representation of a ECDSARecoverableSignature.
+// It's the 64 byte compact signature `R || S` followed by the recovery id (0-3).
+type SerializedECDSARecoverableSignature [SerializedECDSARecoverableSignatureSize]byte
+
+// String returns the SerializedECDSARecoverableSignature as the hexadecimal string
+func (serialized SerializedECDSARecoverableSignature) String() string {
+	return hex.EncodeToString(serialized[:])
+}
+
+// String returns the ECDSARecoverableSignature as the hexadecimal string
+func (signature ECDSARecoverableSignature) String() string {
+	return signature.Serialize().String()
+}
+
+// IsEqual returns true if target is the same as signature.
+func (signature *ECDSARecoverableSignature) IsEqual(target *ECDSARecoverableSignature) bool {
+	if signature == nil && target == nil {
+		return true
+	}
+	if signature == nil || target == nil {
+		return false
+	}
+	return *signature.Serialize() == *target.Serialize()
+}
+
+// Serialize returns a 65 byte serialized signature
+func (signature *ECDSARecoverableSignature) Serialize() *SerializedECDSARecoverableSignature {
+	serialized := SerializedECDSARecoverableSignature{}
+	cPtr := (*C.uchar)(&serialized[0])
+	cRecid := C.int(0)
+	ret := C.secp256k1_ecdsa_recoverable_signature_serialize_compact(C.secp256k1_context_no_precomp, cPtr, &cRecid, &signature.signature)
+	if ret != 1 {
+		panic("failed serializing a signature. Should never happen (upstream promise to return 1)")
+	}
+	serialized[SerializedECDSARecoverableSignatureSize-1] = byte(cRecid)
+	return &serialized
+}
+
+// DeserializeECDSARecoverableSignature deserializes a 65 byte serialized recoverable signature into a ECDSARecoverableSignature type.
+func DeserializeECDSARecoverableSignature(serializedSignature *SerializedECDSARecoverableSignature) (*ECDSARecoverableSignature, error) {
+	recid := serializedSignature[SerializedECDSARecoverableSignatureSize-1]
+	if recid > 3 {
+		return nil, errors.Errorf("invalid recovery id %d, expected 0-3", recid)
+	}
+	signature := ECDSARecoverableSignature{}
+	cPtr := (*C.uchar)(&serializedSignature[0])
+	ret := C.secp256k1_ecdsa_recoverable_signature_parse_compact(C.secp256k1_context_no_precomp, &signature.signature, cPtr, C.int(recid))
+	if ret != 1 {
+		return nil, errors.New("failed parsing the ECDSA recoverable signature")
+	}
+	return &signature, nil
+}
+
+// DeserializeECDSARecoverableSignatureFromSlice returns a ECDSARecoverableSignature type from a serialized signature slice.
+// will verify that it's SerializedECDSARecoverableSignatureSize bytes long
+func DeserializeECDSARecoverableSignatureFromSlice(data []byte) (*ECDSARecoverableSignature, error) {
+	if len(data) != SerializedECDSARecoverableSignatureSize {
+		return nil, errors.Errorf("invalid ECDSA recoverable signature length got %d, expected %d", len(data),
+			SerializedECDSARecoverableSignatureSize)
+	}
+	serialized := SerializedECDSARecoverableSignature{}
+	copy(serialized[:], data)
+	return DeserializeECDSARecoverableSignature(&serialized)
+}
+
+// ECDSASignRecoverable creates a recoverable ECDSA signature using the private key and the input hashed message.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func (key *ECDSAPrivateKey) ECDSASignRecoverable(hash *Hash) (*ECDSARecoverableSignature, error) {
+	if !key.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	var auxilaryRand [32]byte
+	err := readRandom(auxilaryRand[:])
+	if err != nil {
+		return nil, err
+	}
+	signature := ECDSARecoverableSignature{}
+	cPtrHash := (*C.uchar)(&hash[0])
+	cPtrPrivKey := (*C.uchar)(&key.privateKey[0])
+	cPtrAux := unsafe.Pointer(&auxilaryRand)
+	ret := C.secp256k1_ecdsa_sign_recoverable(context, &signature.signature, cPtrHash, cPtrPrivKey, C.secp256k1_nonce_function_rfc6979, cPtrAux)
+	if ret != 1 {
+		return nil, errors.New("failed Signing. You should call `DeserializeECDSAPrivateKey` before calling this")
+	}
+	return &signature, nil
+}
+
+// ToECDSASignature converts the signature into a regular ECDSASignature, dropping the recovery id.
+func (signature *ECDSARecoverableSignature) ToECDSASignature() *ECDSASignature {
+	converted := ECDSASignature{}
+	ret := C.secp256k1_ecdsa_recoverable_signature_convert(C.secp256k1_context_no_precomp, &converted.signature, &signature.signature)
+	if ret != 1 {
+		panic("failed converting a signature. Should never happen (upstream promise to return 1)")
+	}
+	return &converted
+}
+
+// RecoverPublicKey recovers the public key that created the signature over the hashed message.
+// Notice: any signature recovers to *some* public key, so the result must be checked against a known key (or address).
+func (signature *ECDSARecoverableSignature) RecoverPublicKey(hash *Hash) (*ECDSAPublicKey, error) {
+	if signature.ToECDSASignature().hasZeroComponent() {
+		return nil, errors.WithStack(ErrSignatureZeroComponent)
+	}
+	pubkey := ECDSAPublicKey{init: true}
+	cPtrHash := (*C.uchar)(&hash[0])
+	ret := C.secp256k1_ecdsa_recover(context, &pubkey.pubkey, &signature.signature, cPtrHash)
+	if ret != 1 {
+		return nil, errors.New("failed recovering the public key from the signature")
+	}
+	return &pubkey, nil
+}
+
+// VerifyRecoverable returns true if the signature over the hashed message recovers to expectedPubKey.
+// Like ECDSAVerify this rejects high-S signatures.
+func VerifyRecoverable(signature *ECDSARecoverableSignature, hash *Hash, expectedPubKey *ECDSAPublicKey) bool {
+	_, valid := VerifyRecoverableWithKey(signature, hash, expectedPubKey)
+	return valid
+}
+
+// VerifyRecoverableWithKey is like VerifyRecoverable, but also returns the recovered public key (nil if recovery failed).
+// The recovered key is returned even if it doesn't match expectedPubKey.
+func VerifyRecoverableWithKey(signature *ECDSARecoverableSignature, hash *Hash, expectedPubKey *ECDSAPublicKey) (*ECDSAPublicKey, bool) {
+	if signature.ToECDSASignature().IsHighS() {
+		return nil, false
+	}
+	recovered, err := signature.RecoverPublicKey(hash)
+	if err != nil {
+		return nil, false
+	}
+	return recovered, recovered.IsEqual(expectedPubKey)
+}
+
+// RecoverBothPublicKeys recovers every public key that the signature over the hashed message is valid for,
+// by trying all the recovery ids, for when the recovery id isn't known.
+// Recovery ids 0 and 1 are the two possible Y coordinates of R, 2 and 3 only recover in the very rare case where R's
+// X coordinate is bigger than the group order. The keys are returned in the order of their recovery ids.
+// Like RecoverPublicKey, the results must be checked against a known key (or address).
+func RecoverBothPublicKeys(signature *ECDSASignature, hash *Hash) ([]*ECDSAPublicKey, error) {
+	if signature == nil {
+		return nil, errors.New("the signature is nil")
+	}
+	if signature.hasZeroComponent() {
+		return nil, errors.WithStack(ErrSignatureZeroComponent)
+	}
+	serialized := SerializedECDSARecoverableSignature{}
+	copy(serialized[:], signature.Serialize()[:])
+	var recovered []*ECDSAPublicKey
+	for recid := byte(0); recid <= 3; recid++ {
+		serialized[SerializedECDSARecoverableSignatureSize-1] = recid
+		recoverable, err := DeserializeECDSARecoverableSignature(&serialized)
+		if err != nil {
+			continue
+		}
+		pubkey, err := recoverable.RecoverPublicKey(hash)
+		if err != nil {
+			continue
+		}
+		recovered = append(recovered, pubkey)
+	}
+	if len(recovered) == 0 {
+		return nil, errors.New("the signature doesn't recover to any public key")
+	}
+	return recovered, nil
+}
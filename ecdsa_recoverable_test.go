@@ -0,0 +1,144 @@
+package secp256k1
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestECDSARecoverableSignature(t *testing.T) {
+	r := rand.New(rand.NewSource(18))
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		pubkey, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherPrivkey := mustECDSAPrivateKey(t, r)
+		otherPubkey, err := otherPrivkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := Hash{}
+		r.Read(hash[:])
+
+		signature, err := privkey.ECDSASignRecoverable(&hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pubkey.ECDSAVerify(&hash, signature.ToECDSASignature()) {
+			t.Fatalf("Expected the converted signature to verify")
+		}
+		recovered, err := signature.RecoverPublicKey(&hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !recovered.IsEqual(pubkey) {
+			t.Fatalf("Expected %s == %s", recovered, pubkey)
+		}
+		if !VerifyRecoverable(signature, &hash, pubkey) {
+			t.Fatalf("Expected the signature to verify against the signer")
+		}
+		recovered, valid := VerifyRecoverableWithKey(signature, &hash, otherPubkey)
+		if valid || !recovered.IsEqual(pubkey) {
+			t.Fatalf("Expected the signature not to verify against another key, but still recover the signer")
+		}
+		otherHash := hash
+		otherHash[0] ^= 1
+		if VerifyRecoverable(signature, &otherHash, pubkey) {
+			t.Fatalf("Expected the signature not to verify over another hash")
+		}
+
+		serialized := signature.Serialize()
+		deserialized, err := DeserializeECDSARecoverableSignatureFromSlice(serialized[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !deserialized.IsEqual(signature) {
+			t.Fatalf("Expected %s == %s", deserialized, signature)
+		}
+
+		// The high-S twin recovers the same key with the flipped recovery id, but is rejected like in ECDSAVerify.
+		highS := intTo32Bytes(new(big.Int).Sub(Secp256k1Order, new(big.Int).SetBytes(serialized[32:64])))
+		copy(serialized[32:64], highS[:])
+		serialized[64] ^= 1
+		highSSignature, err := DeserializeECDSARecoverableSignature(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recovered, err = highSSignature.RecoverPublicKey(&hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !recovered.IsEqual(pubkey) {
+			t.Fatalf("Expected the high-S twin to recover the same key")
+		}
+		if VerifyRecoverable(highSSignature, &hash, pubkey) {
+			t.Fatalf("Expected a high-S signature to be rejected")
+		}
+	}
+
+	for _, recid := range []byte{4, 255} {
+		_, err := DeserializeECDSARecoverableSignature(&SerializedECDSARecoverableSignature{0: 1, 32: 1, 64: recid})
+		if err == nil {
+			t.Errorf("Expected an error on recovery id %d", recid)
+		}
+	}
+	zero, err := DeserializeECDSARecoverableSignature(&SerializedECDSARecoverableSignature{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = zero.RecoverPublicKey(&Hash{})
+	if err == nil {
+		t.Errorf("Expected an error recovering from a zero signature")
+	}
+	_, err = DeserializeECDSARecoverableSignatureFromSlice(make([]byte, 64))
+	if err == nil {
+		t.Errorf("Expected an error on a 64 byte signature")
+	}
+}
+
+func TestRecoverBothPublicKeys(t *testing.T) {
+	r := rand.New(rand.NewSource(226))
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		pubkey, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := Hash(*fastGenerateTweak(t, r))
+		signature, err := privkey.ECDSASign(&hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		candidates, err := RecoverBothPublicKeys(signature, &hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// R's X coordinate is practically never above the group order, so there are exactly 2 candidates.
+		if len(candidates) != 2 {
+			t.Fatalf("Expected 2 candidates, got %d", len(candidates))
+		}
+		if candidates[0].IsEqual(candidates[1]) {
+			t.Fatalf("Expected the candidates to be different")
+		}
+		found := false
+		for _, candidate := range candidates {
+			if candidate.IsEqual(pubkey) {
+				found = true
+			}
+			if !candidate.ECDSAVerify(&hash, signature) {
+				t.Fatalf("Expected the signature to verify with every candidate")
+			}
+		}
+		if !found {
+			t.Fatalf("Expected the signer's public key to be one of the candidates")
+		}
+	}
+	if _, err := RecoverBothPublicKeys(nil, &Hash{}); err == nil {
+		t.Fatalf("Expected an error for a nil signature")
+	}
+	if _, err := RecoverBothPublicKeys(&ECDSASignature{}, &Hash{}); err == nil {
+		t.Fatalf("Expected an error for a zero signature")
+	}
+}
@@ -1,8 +1,10 @@
 package secp256k1
 
 // #include "./depend/secp256k1/include/secp256k1.h"
+// #include "./depend/secp256k1/contrib/lax_der_parsing.h"
 import "C"
 import (
+	"bytes"
 	"encoding/hex"
 	"github.com/pkg/errors"
 )
@@ -32,6 +34,13 @@ func (signature *ECDSASignature) IsEqual(target *ECDSASignature) bool {
 	return *signature.Serialize() == *target.Serialize()
 }
 
+// hasZeroComponent returns true if either R or S is zero, such a signature is malformed and can never be valid.
+func (signature *ECDSASignature) hasZeroComponent() bool {
+	var zero [32]byte
+	serialized := signature.Serialize()
+	return bytes.Equal(serialized[:32], zero[:]) || bytes.Equal(serialized[32:], zero[:])
+}
+
 // String returns the SerializedECDSASignature as the hexadecimal string
 func (serialized SerializedECDSASignature) String() string {
 	return hex.EncodeToString(serialized[:])
@@ -76,3 +85,126 @@ func DeserializeECDSASignatureFromSlice(data []byte) (signature *ECDSASignature,
 	copy(serialized[:], data)
 	return DeserializeECDSASignature(&serialized)
 }
+
+// maxDERSignatureSize is the maximum size of a DER encoded ECDSA signature.
+const maxDERSignatureSize = 72
+
+// DeserializeECDSASignatureDER deserializes a DER encoded ECDSA signature into a ECDSASignature type.
+func DeserializeECDSASignatureDER(data []byte) (*ECDSASignature, error) {
+	if len(data) == 0 {
+		return nil, errors.New("failed parsing the DER ECDSA signature, it's empty")
+	}
+	signature := ECDSASignature{}
+	cPtr := (*C.uchar)(&data[0])
+	ret := C.secp256k1_ecdsa_signature_parse_der(C.secp256k1_context_no_precomp, &signature.signature, cPtr, C.size_t(len(data)))
+	if ret != 1 {
+		return nil, errors.New("failed parsing the DER ECDSA signature")
+	}
+	return &signature, nil
+}
+
+// minDERSignatureSize is the minimum size of a DER encoded ECDSA signature, with one byte R and S.
+const minDERSignatureSize = 8
+
+// ParseECDSASignature deserializes either a 64 byte compact `R || S` signature (as serialized by Serialize) or a DER encoded one.
+// Exactly 64 bytes are always parsed as compact, even though a DER signature can be 64 bytes long,
+// so use DeserializeECDSASignatureDER if the encoding is known to be DER.
+// It returns an error describing the expected lengths if the data is neither.
+func ParseECDSASignature(data []byte) (*ECDSASignature, error) {
+	if len(data) == SerializedECDSASignatureSize {
+		return DeserializeECDSASignatureFromSlice(data)
+	}
+	if len(data) < minDERSignatureSize || len(data) > maxDERSignatureSize {
+		return nil, errors.Errorf("invalid ECDSA signature length got %d, expected %d (compact) or %d to %d (DER)",
+			len(data), SerializedECDSASignatureSize, minDERSignatureSize, maxDERSignatureSize)
+	}
+	return DeserializeECDSASignatureDER(data)
+}
+
+// SerializeDER returns the DER encoding of the signature
+func (signature *ECDSASignature) SerializeDER() []byte {
+	serialized := [maxDERSignatureSize]byte{}
+	cPtr := (*C.uchar)(&serialized[0])
+	cLen := C.size_t(len(serialized))
+	ret := C.secp256k1_ecdsa_signature_serialize_der(C.secp256k1_context_no_precomp, cPtr, &cLen, &signature.signature)
+	if ret != 1 {
+		panic("failed serializing a signature. Should never happen (the buffer is big enough)")
+	}
+	return serialized[:cLen]
+}
+
+// IsHighS returns true if the signature's S is bigger than HalfOrder.
+// libsecp256k1 rejects such signatures, they should be normalized with NormalizeS.
+func (signature *ECDSASignature) IsHighS() bool {
+	return C.secp256k1_ecdsa_signature_normalize(C.secp256k1_context_no_precomp, nil, &signature.signature) == 1
+}
+
+// NormalizeS converts the signature into its lower-S form (S' = Group Order - S) in place, if it was high-S.
+// It returns true if the signature was changed.
+func (signature *ECDSASignature) NormalizeS() bool {
+	return C.secp256k1_ecdsa_signature_normalize(C.secp256k1_context_no_precomp, &signature.signature, &signature.signature) == 1
+}
+
+// CanonicalizeECDSASignature parses a DER or 64 byte compact ECDSA signature, normalizes it to lower-S
+// and returns it in the 64 byte compact form.
+// Inputs of exactly 64 bytes are treated as compact, anything else as DER.
+// Signatures with R or S equal to zero are rejected.
+func CanonicalizeECDSASignature(data []byte) ([]byte, error) {
+	var signature *ECDSASignature
+	var err error
+	if len(data) == SerializedECDSASignatureSize {
+		signature, err = DeserializeECDSASignatureFromSlice(data)
+	} else {
+		signature, err = DeserializeECDSASignatureDER(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if signature.hasZeroComponent() {
+		return nil, errors.WithStack(ErrSignatureZeroComponent)
+	}
+	signature.NormalizeS()
+	return signature.Serialize()[:], nil
+}
+
+// ParseECDSASignatureLenient parses a DER or 64 byte compact ECDSA signature like CanonicalizeECDSASignature,
+// but also accepts DER encodings that violate the DER rules (e.g. excess padding or a wrong sequence length),
+// as long as they can be parsed by libsecp256k1's lax DER parser.
+// The signature is normalized to lower-S, and the returned list describes each repair that was applied, it's empty for a strict signature.
+// Notice: repairing the encoding changes the signature's bytes, so the result must not be used where the original bytes are committed to.
+func ParseECDSASignatureLenient(data []byte) (*ECDSASignature, []string, error) {
+	var repairs []string
+	var signature *ECDSASignature
+	var err error
+	if len(data) == SerializedECDSASignatureSize {
+		signature, err = DeserializeECDSASignatureFromSlice(data)
+	} else {
+		signature, err = DeserializeECDSASignatureDER(data)
+		if err != nil && len(data) != 0 {
+			signature, err = parseECDSASignatureLaxDER(data)
+			if err == nil {
+				repairs = append(repairs, "re-encoded a non-canonical DER signature")
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if signature.hasZeroComponent() {
+		return nil, nil, errors.WithStack(ErrSignatureZeroComponent)
+	}
+	if signature.NormalizeS() {
+		repairs = append(repairs, "normalized a high-S signature to lower-S")
+	}
+	return signature, repairs, nil
+}
+
+func parseECDSASignatureLaxDER(data []byte) (*ECDSASignature, error) {
+	signature := ECDSASignature{}
+	cPtr := (*C.uchar)(&data[0])
+	ret := C.ecdsa_signature_parse_der_lax(C.secp256k1_context_no_precomp, &signature.signature, cPtr, C.size_t(len(data)))
+	if ret != 1 {
+		return nil, errors.New("failed parsing the DER ECDSA signature, even leniently")
+	}
+	return &signature, nil
+}
@@ -0,0 +1,105 @@
+package secp256k1
+
+import (
+	"crypto/cipher"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// eciesInfo is the HKDF info prefix used for deriving the ECIES encryption key
+const eciesInfo = "go-secp256k1/ecies"
+
+// eciesOverhead is how much longer an ECIES ciphertext is than its plaintext: the ephemeral public key and the 16 byte Poly1305 tag
+const eciesOverhead = SerializedECDSAPublicKeySize + 16
+
+// Encrypt encrypts the plaintext to the public key with ECIES, the result can be decrypted with the matching ECDSAPrivateKey.Decrypt.
+// A fresh ephemeral key is generated, the x coordinate of the ECDH point is turned into a ChaCha20-Poly1305 key with
+// HKDF-SHA256 (info: "go-secp256k1/ecies" || ephemeral public key || recipient public key), and the ciphertext is
+// `compressed ephemeral public key (33 bytes) || ChaCha20-Poly1305 ciphertext and tag`.
+// The AEAD key is never reused so the nonce is all zeros.
+func (key *ECDSAPublicKey) Encrypt(plaintext []byte) ([]byte, error) {
+	recipient, err := key.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { *ephemeral = ECDSAPrivateKey{} }()
+	ephemeralPubkey, err := ephemeral.ECDSAPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	serializedEphemeral, err := ephemeralPubkey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := eciesAEAD(ephemeral, key, serializedEphemeral, recipient)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	ciphertext := make([]byte, 0, len(plaintext)+eciesOverhead)
+	ciphertext = append(ciphertext, serializedEphemeral[:]...)
+	return aead.Seal(ciphertext, nonce, plaintext, nil), nil
+}
+
+// Decrypt decrypts a ciphertext created by ECDSAPublicKey.Encrypt, it fails if the ciphertext was modified or isn't for this key.
+func (key *ECDSAPrivateKey) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < eciesOverhead {
+		return nil, errors.Errorf("an ECIES ciphertext has to be at least %d bytes, instead got %d", eciesOverhead, len(ciphertext))
+	}
+	pubkey, err := key.ECDSAPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := pubkey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPubkey, err := DeserializeECDSAPubKey(ciphertext[:SerializedECDSAPublicKeySize])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ephemeral public key in the ciphertext")
+	}
+	serializedEphemeral := SerializedECDSAPublicKey{}
+	copy(serializedEphemeral[:], ciphertext[:SerializedECDSAPublicKeySize])
+	aead, err := eciesAEAD(key, ephemeralPubkey, &serializedEphemeral, recipient)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	plaintext, err := aead.Open(nil, nonce, ciphertext[SerializedECDSAPublicKeySize:], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decrypting the ciphertext")
+	}
+	return plaintext, nil
+}
+
+// eciesAEAD does the ECDH between privateKey and pubkey, and derives the ChaCha20-Poly1305 key from the shared x coordinate.
+func eciesAEAD(privateKey *ECDSAPrivateKey, pubkey *ECDSAPublicKey, ephemeral, recipient *SerializedECDSAPublicKey) (cipher.AEAD, error) {
+	point := *pubkey
+	serializedPrivateKey := privateKey.Serialize()
+	defer func() { *serializedPrivateKey = SerializedPrivateKey{} }()
+	err := point.Mul(*serializedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	serializedPoint, err := point.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	shared := Hash{}
+	copy(shared[:], serializedPoint[1:])
+	defer func() { shared = Hash{} }()
+
+	info := make([]byte, 0, len(eciesInfo)+2*SerializedECDSAPublicKeySize)
+	info = append(info, eciesInfo...)
+	info = append(info, ephemeral[:]...)
+	info = append(info, recipient[:]...)
+	aeadKey, err := shared.DeriveKey(info, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(aeadKey)
+}
@@ -0,0 +1,64 @@
+package secp256k1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestECIES(t *testing.T) {
+	r := rand.New(rand.NewSource(61))
+	privkey := mustECDSAPrivateKey(t, r)
+	pubkey, err := privkey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := mustECDSAPrivateKey(t, r)
+
+	for _, length := range []int{0, 1, 100, 1000} {
+		plaintext := make([]byte, length)
+		r.Read(plaintext)
+		ciphertext, err := pubkey.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ciphertext) != length+eciesOverhead {
+			t.Fatalf("Expected a %d byte ciphertext, got %d", length+eciesOverhead, len(ciphertext))
+		}
+		decrypted, err := privkey.Decrypt(ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("Expected the decrypted plaintext to be '%x', got '%x'", plaintext, decrypted)
+		}
+		again, err := pubkey.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(again, ciphertext) {
+			t.Fatalf("Expected every encryption to use a fresh ephemeral key")
+		}
+
+		_, err = other.Decrypt(ciphertext)
+		if err == nil {
+			t.Fatalf("Expected decrypting with the wrong key to fail")
+		}
+		for _, i := range []int{0, SerializedECDSAPublicKeySize, len(ciphertext) - 1} {
+			modified := append([]byte{}, ciphertext...)
+			modified[i] ^= 1
+			_, err = privkey.Decrypt(modified)
+			if err == nil {
+				t.Fatalf("Expected decrypting a ciphertext modified at byte %d to fail", i)
+			}
+		}
+	}
+	_, err = privkey.Decrypt(make([]byte, eciesOverhead-1))
+	if err == nil {
+		t.Fatalf("Expected an error for a too short ciphertext")
+	}
+	_, err = new(ECDSAPublicKey).Encrypt([]byte("plaintext"))
+	if err == nil {
+		t.Fatalf("Expected an error encrypting to an uninitialized public key")
+	}
+}
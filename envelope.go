@@ -0,0 +1,30 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// EnvelopeSigHash computes the signature hash of a signed envelope, so the signer and the verifier serialize it the same way:
+// `SHA256(SHA256(version || timestamp || len(payload) || payload))`, where the timestamp and len(payload) are 64 bit big endian integers.
+// Notice: unlike the tagged hashes in this package this isn't domain separated, so make sure the version identifies the protocol.
+func EnvelopeSigHash(version byte, timestamp int64, payload []byte) *Hash {
+	header := [1 + 8 + 8]byte{version}
+	binary.BigEndian.PutUint64(header[1:], uint64(timestamp))
+	binary.BigEndian.PutUint64(header[9:], uint64(len(payload)))
+	hash := Hash(doubleSHA256(header[:], payload))
+	return &hash
+}
+
+// StructuredDataHash computes the hash of structured data like EIP-712, but with SHA256 instead of Keccak256:
+// `SHA256(0x19 || 0x01 || domainSeparator || structHash)`. Computing domainSeparator and structHash is up to the caller.
+// Notice: this isn't compatible with EIP-712 signatures made for Ethereum, which hash with Keccak256.
+func StructuredDataHash(domainSeparator [32]byte, structHash [32]byte) *Hash {
+	hasher := sha256.New()
+	hasher.Write([]byte{0x19, 0x01})
+	hasher.Write(domainSeparator[:])
+	hasher.Write(structHash[:])
+	hash := Hash{}
+	hasher.Sum(hash[:0])
+	return &hash
+}
@@ -0,0 +1,49 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// FingerprintSize is the length in bytes of a public key fingerprint
+const FingerprintSize = 4
+
+// hash160 computes `RIPEMD160(SHA256(data))`
+func hash160(data []byte) [ripemd160.Size]byte {
+	sha := sha256.Sum256(data)
+	hasher := ripemd160.New()
+	hasher.Write(sha[:])
+	hash := [ripemd160.Size]byte{}
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+// Fingerprint returns the first 4 bytes of `RIPEMD160(SHA256(compressed public key))`, the BIP-32 key fingerprint.
+// It's meant for display and for referencing parent keys, it's too short to identify a key securely.
+// An uninitialized key gives the zero fingerprint.
+func (key *ECDSAPublicKey) Fingerprint() [FingerprintSize]byte {
+	fingerprint := [FingerprintSize]byte{}
+	serialized, err := key.Serialize()
+	if err != nil {
+		return fingerprint
+	}
+	hash := hash160(serialized[:])
+	copy(fingerprint[:], hash[:])
+	return fingerprint
+}
+
+// Fingerprint returns the first 4 bytes of `RIPEMD160(SHA256(x-only public key))`.
+// Notice: this is over the 32 byte x-only serialization, so it isn't the BIP-32 fingerprint of the key,
+// which is over the 33 byte compressed serialization (see ECDSAPublicKey.Fingerprint).
+// An uninitialized key gives the zero fingerprint.
+func (key *SchnorrPublicKey) Fingerprint() [FingerprintSize]byte {
+	fingerprint := [FingerprintSize]byte{}
+	serialized, err := key.Serialize()
+	if err != nil {
+		return fingerprint
+	}
+	hash := hash160(serialized[:])
+	copy(fingerprint[:], hash[:])
+	return fingerprint
+}
@@ -0,0 +1,66 @@
+package secp256k1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// framedSignatureMagic is the first byte of every frame written by WriteFramed
+	framedSignatureMagic = 0xa5
+	// FramedSchnorrSignatureSize is the length in bytes of a frame written by WriteFramed:
+	// the magic byte, the length byte, the signature and a 4 byte CRC32.
+	FramedSchnorrSignatureSize = 1 + 1 + SerializedSchnorrSignatureSize + crc32.Size
+)
+
+// WriteFramed writes the signature to w as a self delimiting frame, for append-only logs that need to recover from corruption:
+// `0xa5 || 64 || signature || CRC32(0xa5 || 64 || signature)`, with the CRC32 (IEEE) in big endian.
+func (signature *SchnorrSignature) WriteFramed(w io.Writer) error {
+	frame := [FramedSchnorrSignatureSize]byte{}
+	frame[0] = framedSignatureMagic
+	frame[1] = SerializedSchnorrSignatureSize
+	copy(frame[2:], signature.signature[:])
+	binary.BigEndian.PutUint32(frame[FramedSchnorrSignatureSize-crc32.Size:], crc32.ChecksumIEEE(frame[:FramedSchnorrSignatureSize-crc32.Size]))
+	_, err := w.Write(frame[:])
+	return err
+}
+
+// ReadFramed reads the next frame written by WriteFramed from r.
+// If the bytes don't start a valid frame (wrong magic, length or CRC) it skips ahead to the next magic byte until it finds one,
+// so a reader resyncs after a corrupted record. It never reads past the end of the returned frame.
+// If r ends before a valid frame the error is io.EOF (nothing was read) or io.ErrUnexpectedEOF.
+func ReadFramed(r io.Reader) (*SchnorrSignature, error) {
+	frame := [FramedSchnorrSignatureSize]byte{}
+	_, err := io.ReadFull(r, frame[:])
+	if err != nil {
+		return nil, err
+	}
+	for !isValidSignatureFrame(&frame) {
+		// Skip up to the next magic byte in the window, or the whole window if there's none, and refill it.
+		skip := bytes.IndexByte(frame[1:], framedSignatureMagic) + 1
+		if skip == 0 {
+			skip = len(frame)
+		}
+		copy(frame[:], frame[skip:])
+		_, err = io.ReadFull(r, frame[len(frame)-skip:])
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	signature := &SchnorrSignature{}
+	copy(signature.signature[:], frame[2:])
+	return signature, nil
+}
+
+func isValidSignatureFrame(frame *[FramedSchnorrSignatureSize]byte) bool {
+	if frame[0] != framedSignatureMagic || frame[1] != SerializedSchnorrSignatureSize {
+		return false
+	}
+	checksum := binary.BigEndian.Uint32(frame[FramedSchnorrSignatureSize-crc32.Size:])
+	return checksum == crc32.ChecksumIEEE(frame[:FramedSchnorrSignatureSize-crc32.Size])
+}
@@ -4,4 +4,5 @@ go 1.13
 
 require (
 	github.com/pkg/errors v0.9.1
+	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
 )
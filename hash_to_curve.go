@@ -0,0 +1,199 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// hashToFieldSize is the L parameter of the secp256k1 RFC 9380 suites: ceil((ceil(log2(p)) + 128) / 8)
+const hashToFieldSize = 48
+
+func hexToBigInt(s string) *big.Int {
+	result, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex constant. Should never happen")
+	}
+	return result
+}
+
+// The parameters of the simplified SWU map to the curve E': y^2 = x^3 + A'*x + B', which is 3-isogenous to secp256k1,
+// and the coefficients of the isogeny map from E' to secp256k1, see RFC 9380 section 8.7 and appendix E.1.
+var (
+	sswuA = hexToBigInt("3f8731abdd661adca08a5558f0f5d272e953d363cb6f0e5d405447c01a444533")
+	sswuB = big.NewInt(1771)
+	sswuZ = new(big.Int).Sub(secp256k1FieldPrime, big.NewInt(11))
+
+	isoXNum = []*big.Int{
+		hexToBigInt("8e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38daaaaa8c7"),
+		hexToBigInt("7d3d4c80bc321d5b9f315cea7fd44c5d595d2fc0bf63b92dfff1044f17c6581"),
+		hexToBigInt("534c328d23f234e6e2a413deca25caece4506144037c40314ecbd0b53d9dd262"),
+		hexToBigInt("8e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38daaaaa88c"),
+	}
+	isoXDen = []*big.Int{
+		hexToBigInt("d35771193d94918a9ca34ccbb7b640dd86cd409542f8487d9fe6b745781eb49b"),
+		hexToBigInt("edadc6f64383dc1df7c4b2d51b54225406d36b641f5e41bbc52a56612a8c6d14"),
+		big.NewInt(1),
+	}
+	isoYNum = []*big.Int{
+		hexToBigInt("4bda12f684bda12f684bda12f684bda12f684bda12f684bda12f684b8e38e23c"),
+		hexToBigInt("c75e0c32d5cb7c0fa9d0a54b12a0a6d5647ab046d686da6fdffc90fc201d71a3"),
+		hexToBigInt("29a6194691f91a73715209ef6512e576722830a201be2018a765e85a9ecee931"),
+		hexToBigInt("2f684bda12f684bda12f684bda12f684bda12f684bda12f684bda12f38e38d84"),
+	}
+	isoYDen = []*big.Int{
+		hexToBigInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffff93b"),
+		hexToBigInt("7a06534bb8bdb49fd5e9e6632722c2989467c1bfc8e8d978dfb425d2685c2573"),
+		hexToBigInt("6484aa716545ca2cf3a70c3fa8fe337e0a3d21162f0d6299a7bf8192bfd2a76f"),
+		big.NewInt(1),
+	}
+
+	// fieldSqrtExponent is (p + 1) / 4, since p = 3 mod 4 a square root of a square a is a^((p + 1) / 4)
+	fieldSqrtExponent = new(big.Int).Rsh(new(big.Int).Add(secp256k1FieldPrime, big.NewInt(1)), 2)
+)
+
+// HashToCurve hashes msg to a point on the curve with the RFC 9380 suite secp256k1_XMD:SHA-256_SSWU_RO_ (a random oracle encoding),
+// under the domain separation tag, which should be unique to the protocol and its use of the hash, see RFC 9380 section 3.1.
+// Nobody knows the discrete logarithm of the result, so it can be used as an independent generator.
+// It's an error if the tag is empty, tags longer than 255 bytes are hashed as specified by the RFC.
+// Notice: this isn't constant time, only hash public data with it.
+func HashToCurve(msg, domainSeparationTag []byte) (*ECDSAPublicKey, error) {
+	if len(domainSeparationTag) == 0 {
+		return nil, errors.New("the hash to curve domain separation tag can't be empty")
+	}
+	uniform, err := expandMessageXMD(msg, domainSeparationTag, 2*hashToFieldSize)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]*ECDSAPublicKey, 2)
+	for i := range points {
+		u := new(big.Int).SetBytes(uniform[i*hashToFieldSize : (i+1)*hashToFieldSize])
+		u.Mod(u, secp256k1FieldPrime)
+		x, y := isoMap(mapToCurveSimpleSWU(u))
+		points[i], err = NewECDSAPublicKey(x, y)
+		if err != nil {
+			return nil, errors.Wrap(err, "the SSWU map returned a point that isn't on the curve. Should never happen")
+		}
+	}
+	// secp256k1's cofactor is 1, so there's no cofactor to clear.
+	return combineECDSAPublicKeys(points)
+}
+
+// expandMessageXMD is expand_message_xmd from RFC 9380 section 5.3.1 with SHA256.
+func expandMessageXMD(msg, dst []byte, length int) ([]byte, error) {
+	if len(dst) > 255 {
+		oversizeHash := sha256.Sum256(append([]byte("H2C-OVERSIZE-DST-"), dst...))
+		dst = oversizeHash[:]
+	}
+	ell := (length + sha256.Size - 1) / sha256.Size
+	if ell > 255 || length > 0xffff {
+		return nil, errors.Errorf("expand_message_xmd can't expand to %d bytes", length)
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	hasher := sha256.New()
+	hasher.Write(make([]byte, sha256.BlockSize))
+	hasher.Write(msg)
+	hasher.Write([]byte{byte(length >> 8), byte(length), 0})
+	hasher.Write(dstPrime)
+	b0 := hasher.Sum(nil)
+
+	uniform := make([]byte, 0, ell*sha256.Size)
+	bi := make([]byte, sha256.Size)
+	for i := 1; i <= ell; i++ {
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		hasher.Reset()
+		hasher.Write(bi)
+		hasher.Write([]byte{byte(i)})
+		hasher.Write(dstPrime)
+		bi = hasher.Sum(nil)
+		uniform = append(uniform, bi...)
+	}
+	return uniform[:length], nil
+}
+
+// mapToCurveSimpleSWU maps a field element to a point on E', it's the straight-line map of RFC 9380 section 6.6.2.
+func mapToCurveSimpleSWU(u *big.Int) (x, y *big.Int) {
+	p := secp256k1FieldPrime
+	zu2 := new(big.Int).Mul(u, u)
+	zu2.Mul(zu2, sswuZ).Mod(zu2, p)
+	// tv1 = 1 / (Z^2*u^4 + Z*u^2), where the inverse of 0 is 0
+	tv1 := new(big.Int).Mul(zu2, zu2)
+	tv1.Add(tv1, zu2).Mod(tv1, p)
+	if tv1.Sign() != 0 {
+		tv1.ModInverse(tv1, p)
+	}
+	x1 := new(big.Int)
+	if tv1.Sign() == 0 {
+		// x1 = B / (Z*A)
+		x1.Mul(sswuZ, sswuA).Mod(x1, p)
+		x1.ModInverse(x1, p)
+		x1.Mul(x1, sswuB)
+	} else {
+		// x1 = (-B / A) * (1 + tv1)
+		x1.ModInverse(sswuA, p)
+		x1.Mul(x1, sswuB).Neg(x1)
+		x1.Mul(x1, new(big.Int).Add(tv1, big.NewInt(1)))
+	}
+	x1.Mod(x1, p)
+	x, y = x1, fieldSqrt(curveIsoEquation(x1))
+	if y == nil {
+		x2 := new(big.Int).Mul(zu2, x1)
+		x2.Mod(x2, p)
+		x, y = x2, fieldSqrt(curveIsoEquation(x2))
+		if y == nil {
+			panic("neither SSWU candidate is on the curve. Should never happen")
+		}
+	}
+	if u.Bit(0) != y.Bit(0) {
+		y.Sub(p, y)
+	}
+	return x, y
+}
+
+// curveIsoEquation returns x^3 + A'*x + B' % p
+func curveIsoEquation(x *big.Int) *big.Int {
+	result := new(big.Int).Mul(x, x)
+	result.Add(result, sswuA)
+	result.Mul(result, x)
+	result.Add(result, sswuB)
+	return result.Mod(result, secp256k1FieldPrime)
+}
+
+// fieldSqrt returns the square root of a modulo the field prime, or nil if a isn't a square.
+func fieldSqrt(a *big.Int) *big.Int {
+	root := new(big.Int).Exp(a, fieldSqrtExponent, secp256k1FieldPrime)
+	square := new(big.Int).Mul(root, root)
+	if square.Mod(square, secp256k1FieldPrime).Cmp(a) != 0 {
+		return nil
+	}
+	return root
+}
+
+// isoMap maps a point on E' to secp256k1 with the 3-isogeny of RFC 9380 appendix E.1.
+func isoMap(x, y *big.Int) (*big.Int, *big.Int) {
+	p := secp256k1FieldPrime
+	xNum := evaluatePolynomial(isoXNum, x)
+	xDen := evaluatePolynomial(isoXDen, x)
+	yNum := evaluatePolynomial(isoYNum, x)
+	yDen := evaluatePolynomial(isoYDen, x)
+	mappedX := new(big.Int).ModInverse(xDen, p)
+	mappedX.Mul(mappedX, xNum).Mod(mappedX, p)
+	mappedY := new(big.Int).ModInverse(yDen, p)
+	mappedY.Mul(mappedY, yNum).Mul(mappedY, y).Mod(mappedY, p)
+	return mappedX, mappedY
+}
+
+// evaluatePolynomial evaluates the polynomial with the coefficients (lowest degree first) at x modulo the field prime.
+func evaluatePolynomial(coefficients []*big.Int, x *big.Int) *big.Int {
+	result := new(big.Int)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, coefficients[i])
+		result.Mod(result, secp256k1FieldPrime)
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	// RFC 9380 appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	tests := []struct {
+		msg      string
+		expected string
+	}{
+		{"", "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	}
+	for _, test := range tests {
+		uniform, err := expandMessageXMD([]byte(test.msg), dst, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprintf("%x", uniform) != test.expected {
+			t.Errorf("msg '%s': expected %s, got %x", test.msg, test.expected, uniform)
+		}
+	}
+}
+
+func TestHashToCurveVectors(t *testing.T) {
+	// RFC 9380 appendix J.8.1, secp256k1_XMD:SHA-256_SSWU_RO_
+	dst := []byte("QUUX-V01-CS02-with-secp256k1_XMD:SHA-256_SSWU_RO_")
+	tests := []struct {
+		msg  string
+		x, y string
+	}{
+		{"", "c1cae290e291aee617ebaef1be6d73861479c48b841eaba9b7b5852ddfeb1346", "64fa678e07ae116126f08b022a94af6de15985c996c3a91b64c406a960e51067"},
+		{"abc", "3377e01eab42db296b512293120c6cee72b6ecf9f9205760bd9ff11fb3cb2c4b", "7f95890f33efebd1044d382a01b1bee0900fb6116f94688d487c6c7b9c8371f6"},
+	}
+	for _, test := range tests {
+		point, err := HashToCurve([]byte(test.msg), dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y := point.Coordinates()
+		if fmt.Sprintf("%064x", x) != test.x || fmt.Sprintf("%064x", y) != test.y {
+			t.Errorf("msg '%s': expected (%s, %s), got (%064x, %064x)", test.msg, test.x, test.y, x, y)
+		}
+	}
+
+	a, err := HashToCurve([]byte("a"), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherTag, err := HashToCurve([]byte("a"), []byte("another tag"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.IsEqual(otherTag) {
+		t.Errorf("Expected different tags to hash to different points")
+	}
+	if _, err := HashToCurve([]byte("a"), nil); err == nil {
+		t.Errorf("Expected an error for an empty domain separation tag")
+	}
+
+	// A tag longer than 255 bytes is replaced by SHA256("H2C-OVERSIZE-DST-" || tag).
+	longTag := make([]byte, 256)
+	hashedTag := sha256.Sum256(append([]byte("H2C-OVERSIZE-DST-"), longTag...))
+	long, err := HashToCurve([]byte("a"), longTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed, err := HashToCurve([]byte("a"), hashedTag[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !long.IsEqual(hashed) {
+		t.Errorf("Expected a long tag to be hashed")
+	}
+}
+
+func TestKeyImage(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, isOdd, err := key.schnorrPublicKeyInternal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, err := key.KeyImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The keypair with the negated private key has the same x-only public key, so it must have the same image.
+	negatedPrivateKey := SerializedPrivateKey(ScalarNegate(*key.SerializePrivateKey()))
+	negated, err := DeserializeSchnorrPrivateKey(&negatedPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	negatedImage, err := negated.KeyImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !image.IsEqual(negatedImage) {
+		t.Fatalf("Expected x and -x to have the same key image, got %s and %s", image, negatedImage)
+	}
+
+	basePoint, err := KeyImageBasePoint(pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scalar := [32]byte(*key.SerializePrivateKey())
+	if isOdd {
+		scalar = ScalarNegate(scalar)
+	}
+	err = basePoint.Mul(scalar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !image.IsEqual(basePoint) {
+		t.Fatalf("Expected the key image to be x*Hp(P), got %s instead of %s", image, basePoint)
+	}
+
+	other, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherImage, err := other.KeyImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.IsEqual(otherImage) {
+		t.Fatalf("Expected different keys to have different key images")
+	}
+	if _, err := (&SchnorrKeyPair{}).KeyImage(); err == nil {
+		t.Fatalf("Expected an error for an uninitialized keypair")
+	}
+}
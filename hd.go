@@ -0,0 +1,499 @@
+package secp256k1
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// HardenedKeyStart is the first hardened BIP-32 child index, hardened children can't be derived from an extended public key.
+	HardenedKeyStart = 0x80000000
+
+	// serializedExtendedKeySize is the length in bytes of a BIP-32 serialized extended key, before the Base58Check checksum:
+	// version (4) || depth (1) || parent fingerprint (4) || child number (4) || chain code (32) || key (33)
+	serializedExtendedKeySize = 4 + 1 + FingerprintSize + 4 + 32 + 33
+
+	// maxHDDepth is the maximum depth of an extended key, it's serialized as a single byte.
+	maxHDDepth = 255
+)
+
+var (
+	// xpubVersion and tpubVersion are the BIP-32 version bytes of mainnet and testnet extended public keys
+	xpubVersion = [4]byte{0x04, 0x88, 0xb2, 0x1e}
+	tpubVersion = [4]byte{0x04, 0x35, 0x87, 0xcf}
+	// xprvVersion and tprvVersion are the BIP-32 version bytes of mainnet and testnet extended private keys
+	xprvVersion = [4]byte{0x04, 0x88, 0xad, 0xe4}
+	tprvVersion = [4]byte{0x04, 0x35, 0x83, 0x94}
+)
+
+// Network is the pair of BIP-32 version bytes extended keys are serialized with on a network.
+// MainNet and TestNet are the standard ones, other networks can use their own version bytes.
+type Network struct {
+	// PublicVersion is the version of extended public keys, e.g. 0x0488b21e (xpub)
+	PublicVersion [4]byte
+	// PrivateVersion is the version of extended private keys, e.g. 0x0488ade4 (xprv)
+	PrivateVersion [4]byte
+}
+
+var (
+	// MainNet is the network of xpub and xprv extended keys
+	MainNet = Network{PublicVersion: xpubVersion, PrivateVersion: xprvVersion}
+	// TestNet is the network of tpub and tprv extended keys
+	TestNet = Network{PublicVersion: tpubVersion, PrivateVersion: tprvVersion}
+)
+
+// hdMasterKeyHMACKey is the HMAC-SHA512 key BIP-32 derives the master key from a seed with
+const hdMasterKeyHMACKey = "Bitcoin seed"
+
+// extendedKeyHeader is the part of a BIP-32 extended key that's common to extended public and private keys.
+type extendedKeyHeader struct {
+	version           [4]byte
+	depth             byte
+	parentFingerprint [FingerprintSize]byte
+	childNumber       uint32
+	chainCode         [32]byte
+}
+
+// HDPublicKey is a BIP-32 extended public key, which can derive non-hardened child public keys.
+// The struct itself is an opaque data type that should only be created via the supplied methods.
+type HDPublicKey struct {
+	extendedKeyHeader
+	publicKey ECDSAPublicKey
+}
+
+// ParseXPub parses a Base58Check encoded BIP-32 extended public key, with mainnet (xpub) or testnet (tpub) version bytes.
+// It verifies the checksum, the structure and the public key.
+func ParseXPub(xpub string) (*HDPublicKey, error) {
+	header, key, err := parseExtendedKey(xpub)
+	if err != nil {
+		return nil, err
+	}
+	if header.version == xprvVersion || header.version == tprvVersion {
+		return nil, errors.New("expected an extended public key, got an extended private key")
+	}
+	if header.version != xpubVersion && header.version != tpubVersion {
+		return nil, errors.Errorf("unknown extended public key version %x", header.version)
+	}
+	publicKey, err := DeserializeECDSAPubKeyCompressedOnly(key[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid extended public key")
+	}
+	return &HDPublicKey{extendedKeyHeader: *header, publicKey: *publicKey}, nil
+}
+
+// String returns the Base58Check encoding of the extended public key, the inverse of ParseXPub.
+func (hd *HDPublicKey) String() string {
+	serialized, err := hd.publicKey.Serialize()
+	if err != nil {
+		panic("an HDPublicKey has an uninitialized public key. Should never happen")
+	}
+	return hd.serialize((*[33]byte)(serialized))
+}
+
+// StringForNetwork returns the Base58Check encoding of the extended public key with the network's public version bytes,
+// e.g. for turning an xpub into a tpub. The extended key itself isn't modified.
+func (hd *HDPublicKey) StringForNetwork(network Network) string {
+	retargeted := *hd
+	retargeted.version = network.PublicVersion
+	return retargeted.String()
+}
+
+// PublicKey returns a copy of the extended key's public key.
+func (hd *HDPublicKey) PublicKey() *ECDSAPublicKey {
+	publicKey := hd.publicKey
+	return &publicKey
+}
+
+// ChainCode returns the extended key's chain code.
+func (hd *HDPublicKey) ChainCode() [32]byte {
+	return hd.chainCode
+}
+
+// Depth returns the amount of derivations from the master key, which has a depth of 0.
+func (hd *HDPublicKey) Depth() byte {
+	return hd.depth
+}
+
+// ParentFingerprint returns the fingerprint of the parent's public key, it's zero for the master key.
+func (hd *HDPublicKey) ParentFingerprint() [FingerprintSize]byte {
+	return hd.parentFingerprint
+}
+
+// ChildNumber returns the index this key was derived with from its parent, it's zero for the master key.
+func (hd *HDPublicKey) ChildNumber() uint32 {
+	return hd.childNumber
+}
+
+// Derive derives the non-hardened child number index of the extended public key (BIP-32's CKDpub).
+// It's an error if index is hardened (at least HardenedKeyStart), or, with a negligible probability,
+// if the child is invalid, in which case BIP-32 says to proceed with the next index.
+func (hd *HDPublicKey) Derive(index uint32) (*HDPublicKey, error) {
+	if index >= HardenedKeyStart {
+		return nil, errors.Errorf("can't derive the hardened child %d from an extended public key", index)
+	}
+	if hd.depth == maxHDDepth {
+		return nil, errors.Errorf("can't derive past the maximum depth of %d", maxHDDepth)
+	}
+	serialized, err := hd.publicKey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	tweak, chainCode := hdChildTweak(&hd.chainCode, serialized[:], index)
+	child := &HDPublicKey{
+		extendedKeyHeader: hd.childHeader(index, chainCode),
+		publicKey:         hd.publicKey,
+	}
+	err = child.publicKey.Add(tweak)
+	if err != nil {
+		return nil, errors.Wrapf(err, "the child %d is invalid, use the next index", index)
+	}
+	return child, nil
+}
+
+// childHeader returns the header of child number index of this key.
+func (hd *HDPublicKey) childHeader(index uint32, chainCode [32]byte) extendedKeyHeader {
+	return extendedKeyHeader{
+		version:           hd.version,
+		depth:             hd.depth + 1,
+		parentFingerprint: hd.publicKey.Fingerprint(),
+		childNumber:       index,
+		chainCode:         chainCode,
+	}
+}
+
+// HDKey is a BIP-32 extended private key, which can derive both hardened and non-hardened child keys.
+// The struct itself is an opaque data type that should only be created via the supplied methods.
+type HDKey struct {
+	extendedKeyHeader
+	privateKey ECDSAPrivateKey
+}
+
+// NewMasterHDKey derives the BIP-32 master extended private key (with mainnet version bytes) from a seed,
+// e.g. from MnemonicToSeed. BIP-32 requires the seed to be between 16 and 64 bytes.
+func NewMasterHDKey(seed []byte) (*HDKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, errors.Errorf("the seed has to be between 16 and 64 bytes, instead got %d", len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte(hdMasterKeyHMACKey))
+	mac.Write(seed)
+	I := mac.Sum(nil)
+	defer func() {
+		for i := range I {
+			I[i] = 0
+		}
+	}()
+	privateKey, err := DeserializeECDSAPrivateKeyFromSlice(I[:32])
+	if err != nil {
+		return nil, errors.Wrap(err, "the seed gives an invalid master key")
+	}
+	hd := &HDKey{extendedKeyHeader: extendedKeyHeader{version: xprvVersion}, privateKey: *privateKey}
+	privateKey.privateKey = [32]byte{}
+	copy(hd.chainCode[:], I[32:])
+	return hd, nil
+}
+
+// ParseXPrv parses a Base58Check encoded BIP-32 extended private key, with mainnet (xprv) or testnet (tprv) version bytes.
+// It verifies the checksum, the structure and the private key, and rejects extended public keys.
+func ParseXPrv(xprv string) (*HDKey, error) {
+	header, key, err := parseExtendedKey(xprv)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { *key = [33]byte{} }()
+	if header.version == xpubVersion || header.version == tpubVersion {
+		return nil, errors.New("expected an extended private key, got an extended public key")
+	}
+	if header.version != xprvVersion && header.version != tprvVersion {
+		return nil, errors.Errorf("unknown extended private key version %x", header.version)
+	}
+	if key[0] != 0x00 {
+		return nil, errors.Errorf("an extended private key's key data has to start with 0x00, instead got 0x%02x", key[0])
+	}
+	privateKey, err := DeserializeECDSAPrivateKeyFromSlice(key[1:])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid extended private key")
+	}
+	hd := &HDKey{extendedKeyHeader: *header, privateKey: *privateKey}
+	privateKey.privateKey = [32]byte{}
+	return hd, nil
+}
+
+// String returns the Base58Check encoding of the extended private key, the inverse of ParseXPrv.
+// Notice: this reveals the private key and every key derived from it.
+func (hd *HDKey) String() string {
+	key := [33]byte{}
+	copy(key[1:], hd.privateKey.privateKey[:])
+	defer func() { key = [33]byte{} }()
+	return hd.serialize(&key)
+}
+
+// StringForNetwork returns the Base58Check encoding of the extended private key with the network's private version bytes,
+// e.g. for turning an xprv into a tprv. The extended key itself isn't modified.
+// Notice: this reveals the private key and every key derived from it.
+func (hd *HDKey) StringForNetwork(network Network) string {
+	retargeted := *hd
+	defer func() { retargeted.privateKey = ECDSAPrivateKey{} }()
+	retargeted.version = network.PrivateVersion
+	return retargeted.String()
+}
+
+// PrivateKey returns a copy of the extended key's private key.
+func (hd *HDKey) PrivateKey() *ECDSAPrivateKey {
+	privateKey := hd.privateKey
+	return &privateKey
+}
+
+// ChainCode returns the extended key's chain code.
+func (hd *HDKey) ChainCode() [32]byte {
+	return hd.chainCode
+}
+
+// Depth returns the amount of derivations from the master key, which has a depth of 0.
+func (hd *HDKey) Depth() byte {
+	return hd.depth
+}
+
+// ParentFingerprint returns the fingerprint of the parent's public key, it's zero for the master key.
+func (hd *HDKey) ParentFingerprint() [FingerprintSize]byte {
+	return hd.parentFingerprint
+}
+
+// ChildNumber returns the index this key was derived with from its parent, it's zero for the master key.
+func (hd *HDKey) ChildNumber() uint32 {
+	return hd.childNumber
+}
+
+// HDPublicKey returns the extended public key of the extended private key (BIP-32's N), with the matching public version bytes.
+func (hd *HDKey) HDPublicKey() (*HDPublicKey, error) {
+	publicKey, err := hd.privateKey.ECDSAPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	public := &HDPublicKey{extendedKeyHeader: hd.extendedKeyHeader, publicKey: *publicKey}
+	if hd.version == tprvVersion {
+		public.version = tpubVersion
+	} else {
+		public.version = xpubVersion
+	}
+	return public, nil
+}
+
+// Derive derives the child number index of the extended private key (BIP-32's CKDpriv),
+// indexes starting at HardenedKeyStart are hardened. It's an error, with a negligible probability,
+// if the child is invalid, in which case BIP-32 says to proceed with the next index.
+func (hd *HDKey) Derive(index uint32) (*HDKey, error) {
+	if hd.depth == maxHDDepth {
+		return nil, errors.Errorf("can't derive past the maximum depth of %d", maxHDDepth)
+	}
+	publicKey, err := hd.privateKey.ECDSAPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	var tweak, chainCode [32]byte
+	if index >= HardenedKeyStart {
+		data := [33]byte{}
+		copy(data[1:], hd.privateKey.privateKey[:])
+		tweak, chainCode = hdChildTweak(&hd.chainCode, data[:], index)
+		data = [33]byte{}
+	} else {
+		tweak, chainCode = hdChildTweak(&hd.chainCode, serializedPublicKey[:], index)
+	}
+	defer func() { tweak = [32]byte{} }()
+	child := &HDKey{
+		extendedKeyHeader: extendedKeyHeader{
+			version:           hd.version,
+			depth:             hd.depth + 1,
+			parentFingerprint: publicKey.Fingerprint(),
+			childNumber:       index,
+			chainCode:         chainCode,
+		},
+		privateKey: hd.privateKey,
+	}
+	err = child.privateKey.Add(tweak)
+	if err != nil {
+		return nil, errors.Wrapf(err, "the child %d is invalid, use the next index", index)
+	}
+	return child, nil
+}
+
+// DeriveChildren derives the children start, start+1, ..., start+count-1 of the extended private key, see Derive.
+// Every child only depends on this key, so the non-hardened children are derived on GOMAXPROCS goroutines,
+// and the hardened children sequentially. It's an error if the range goes past the last index (2^32-1),
+// or if any child is invalid, in which case the error says which index to skip.
+func (hd *HDKey) DeriveChildren(start, count uint32) ([]*HDKey, error) {
+	end := uint64(start) + uint64(count)
+	if end > math.MaxUint32+1 {
+		return nil, errors.Errorf("the range of %d children starting at %d goes past the last index", count, start)
+	}
+	children := make([]*HDKey, count)
+	nonHardenedEnd := end
+	if nonHardenedEnd > HardenedKeyStart {
+		nonHardenedEnd = HardenedKeyStart
+	}
+	if uint64(start) < nonHardenedEnd {
+		errs := make([]error, nonHardenedEnd-uint64(start))
+		next := uint64(start)
+		var wg sync.WaitGroup
+		for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					index := atomic.AddUint64(&next, 1) - 1
+					if index >= nonHardenedEnd {
+						return
+					}
+					children[index-uint64(start)], errs[index-uint64(start)] = hd.Derive(uint32(index))
+				}
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	hardenedStart := nonHardenedEnd
+	if hardenedStart < uint64(start) {
+		hardenedStart = uint64(start)
+	}
+	for index := hardenedStart; index < end; index++ {
+		child, err := hd.Derive(uint32(index))
+		if err != nil {
+			return nil, err
+		}
+		children[index-uint64(start)] = child
+	}
+	return children, nil
+}
+
+// DerivePath derives the descendant at the derivation path, e.g. "m/44'/0'/0'", from the extended private key.
+// The path is relative to this key, "m" is the key itself, see ParseDerivationPath.
+func (hd *HDKey) DerivePath(path string) (*HDKey, error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	derived := hd
+	for _, index := range indexes {
+		derived, err = derived.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return derived, nil
+}
+
+// DerivePath derives the descendant at the derivation path, e.g. "m/0/5", from the extended public key.
+// The path is relative to this key, "m" is the key itself, see ParseDerivationPath. It's an error if the path has a hardened index.
+func (hd *HDPublicKey) DerivePath(path string) (*HDPublicKey, error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	derived := hd
+	for _, index := range indexes {
+		derived, err = derived.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return derived, nil
+}
+
+// ParseDerivationPath parses a BIP-32 derivation path like "m/44'/0'/0'/0/5" into its child indexes.
+// The path starts with "m" followed by "/"-separated decimal indexes below 2^31, which are hardened
+// (HardenedKeyStart is added) if they're followed by "'", "h" or "H". "m" alone is the empty path.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	segments := strings.Split(path, "/")
+	if segments[0] != "m" {
+		return nil, errors.Errorf("the derivation path %q has to start with \"m\"", path)
+	}
+	indexes := make([]uint32, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		number := segment
+		hardened := false
+		if last := len(number) - 1; last >= 0 && (number[last] == '\'' || number[last] == 'h' || number[last] == 'H') {
+			number = number[:last]
+			hardened = true
+		}
+		if number == "" || strings.TrimLeft(number, "0123456789") != "" {
+			return nil, errors.Errorf("invalid segment %q in the derivation path %q", segment, path)
+		}
+		index, err := strconv.ParseUint(number, 10, 32)
+		if err != nil || index >= HardenedKeyStart {
+			return nil, errors.Errorf("the index %s in the derivation path %q has to be smaller than 2^31", number, path)
+		}
+		if hardened {
+			index += HardenedKeyStart
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// hdChildTweak computes `I = HMAC-SHA512(chain code, data || index)` and splits it into the tweak IL and the child chain code IR.
+func hdChildTweak(chainCode *[32]byte, data []byte, index uint32) (tweak, childChainCode [32]byte) {
+	mac := hmac.New(sha512.New, chainCode[:])
+	mac.Write(data)
+	var serializedIndex [4]byte
+	binary.BigEndian.PutUint32(serializedIndex[:], index)
+	mac.Write(serializedIndex[:])
+	I := mac.Sum(nil)
+	copy(tweak[:], I[:32])
+	copy(childChainCode[:], I[32:])
+	return tweak, childChainCode
+}
+
+// serialize returns the Base58Check encoding of the header followed by the 33 byte key data.
+func (header *extendedKeyHeader) serialize(key *[33]byte) string {
+	serialized := make([]byte, 0, serializedExtendedKeySize)
+	serialized = append(serialized, header.version[1:]...)
+	serialized = append(serialized, header.depth)
+	serialized = append(serialized, header.parentFingerprint[:]...)
+	var childNumber [4]byte
+	binary.BigEndian.PutUint32(childNumber[:], header.childNumber)
+	serialized = append(serialized, childNumber[:]...)
+	serialized = append(serialized, header.chainCode[:]...)
+	serialized = append(serialized, key[:]...)
+	return Base58CheckEncode(serialized, header.version[:1])
+}
+
+// parseExtendedKey decodes a Base58Check encoded extended key into its header and 33 byte key data,
+// verifying the checksum, the length and that a master key (depth 0) has no parent fingerprint and child number.
+func parseExtendedKey(s string) (*extendedKeyHeader, *[33]byte, error) {
+	version, payload, err := Base58CheckDecode(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := append(append(make([]byte, 0, len(version)+len(payload)), version...), payload...)
+	if len(data) != serializedExtendedKeySize {
+		return nil, nil, errors.Errorf("an extended key has to be %d bytes, instead got %d", serializedExtendedKeySize, len(data))
+	}
+	header := &extendedKeyHeader{}
+	copy(header.version[:], data[:4])
+	header.depth = data[4]
+	copy(header.parentFingerprint[:], data[5:9])
+	header.childNumber = binary.BigEndian.Uint32(data[9:13])
+	copy(header.chainCode[:], data[13:45])
+	if header.depth == 0 && (header.parentFingerprint != [FingerprintSize]byte{} || header.childNumber != 0) {
+		return nil, nil, errors.New("a master extended key can't have a parent fingerprint or a child number")
+	}
+	key := &[33]byte{}
+	copy(key[:], data[45:])
+	return header, key, nil
+}
@@ -0,0 +1,320 @@
+package secp256k1
+
+import (
+	"math"
+	"testing"
+)
+
+// BIP-32 test vector 1
+const (
+	hdTestMasterXPub = "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"
+	hdTest0HXPub     = "xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw"
+	hdTest0H1XPub    = "xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ"
+
+	hdTestSeed       = "000102030405060708090a0b0c0d0e0f"
+	hdTestMasterXPrv = "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"
+	hdTest0HXPrv     = "xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7"
+	hdTest0H1XPrv    = "xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs"
+)
+
+func TestParseXPub(t *testing.T) {
+	for _, xpub := range []string{hdTestMasterXPub, hdTest0HXPub, hdTest0H1XPub} {
+		hd, err := ParseXPub(xpub)
+		if err != nil {
+			t.Fatalf("%s: %v", xpub, err)
+		}
+		if hd.String() != xpub {
+			t.Fatalf("Expected %s to round trip, got %s", xpub, hd.String())
+		}
+	}
+
+	master, err := ParseXPub(hdTestMasterXPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPubKey, err := DeserializeECDSAPubKey(decodeHex("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !master.PublicKey().IsEqual(expectedPubKey) || master.Depth() != 0 || master.ChildNumber() != 0 ||
+		master.ParentFingerprint() != [FingerprintSize]byte{} {
+		t.Fatalf("Unexpected master key fields")
+	}
+
+	parent, err := ParseXPub(hdTest0HXPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent.ParentFingerprint() != master.PublicKey().Fingerprint() || parent.ChildNumber() != HardenedKeyStart || parent.Depth() != 1 {
+		t.Fatalf("Unexpected m/0H fields")
+	}
+	child, err := parent.Derive(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.String() != hdTest0H1XPub {
+		t.Fatalf("Expected m/0H/1 to be %s, got %s", hdTest0H1XPub, child.String())
+	}
+	if _, err := parent.Derive(HardenedKeyStart); err == nil {
+		t.Fatalf("Expected an error deriving a hardened child from an extended public key")
+	}
+
+	corrupted := []byte(hdTestMasterXPub)
+	corrupted[len(corrupted)-1] = '9'
+	invalid := []string{
+		string(corrupted),
+		hdTestMasterXPub[:len(hdTestMasterXPub)-1],
+		// BIP-32 test vector 5: a master key with a non-zero parent fingerprint, and a public key with an invalid prefix
+		"xpub661no6RGEX3uJkY4bNnPcw4URcQTrSibUZ4NqJEw5eBkv7ovTwgiT91XX27VbEXGENhYRCf7hyEbWrR3FewATdCEebj6znwMfQkhRYHRLpJ",
+		"xpub661MyMwAqRbcEYS8w7XLSVeEsBXy79zSzH1J8vCdxAZningWLdN3zgtU6Txnt3siSujt9RCVYsx4qHZGc62TG4McvMGcAUjeuwZdduYEvFn",
+	}
+	for _, xpub := range invalid {
+		if _, err := ParseXPub(xpub); err == nil {
+			t.Fatalf("Expected an error parsing %s", xpub)
+		}
+	}
+}
+
+func TestParseXPrv(t *testing.T) {
+	master, err := NewMasterHDKey(decodeHex(hdTestSeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if master.String() != hdTestMasterXPrv {
+		t.Fatalf("Expected the master key %s, got %s", hdTestMasterXPrv, master.String())
+	}
+	child0H, err := master.Derive(HardenedKeyStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child0H1, err := child0H.Derive(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		hd   *HDKey
+		xprv string
+		xpub string
+	}{
+		{master, hdTestMasterXPrv, hdTestMasterXPub},
+		{child0H, hdTest0HXPrv, hdTest0HXPub},
+		{child0H1, hdTest0H1XPrv, hdTest0H1XPub},
+	}
+	for _, test := range tests {
+		if test.hd.String() != test.xprv {
+			t.Fatalf("Expected %s, got %s", test.xprv, test.hd.String())
+		}
+		parsed, err := ParseXPrv(test.xprv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != test.xprv {
+			t.Fatalf("Expected %s to round trip, got %s", test.xprv, parsed.String())
+		}
+		public, err := parsed.HDPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if public.String() != test.xpub {
+			t.Fatalf("Expected the extended public key of %s to be %s, got %s", test.xprv, test.xpub, public.String())
+		}
+		if _, err := ParseXPrv(test.xpub); err == nil {
+			t.Fatalf("Expected an error parsing the xpub %s as an xprv", test.xpub)
+		}
+		if _, err := ParseXPub(test.xprv); err == nil {
+			t.Fatalf("Expected an error parsing the xprv %s as an xpub", test.xprv)
+		}
+	}
+
+	// BIP-32 test vector 5: a private key of zero, and key data with a 0x01 prefix
+	for _, xprv := range []string{
+		"xprv9s21ZrQH143K24Mfq5zL5MhWK9hUhhGbd45hLXo2Pq2oqzMMo63oStZzF93Y5wvzdUayhgkkFoicQZcP3y52uPPxFnfoLZB21Teqt1VvEHx",
+		"xprv9s21ZrQH143K24Mfq5zL5MhWK9hUhhGbd45hLXo2Pq2oqzMMo63oStZzFAzHGBP2UuGCqWLTAPLcMtD9y5gkZ6Eq3Rjuahrv17fEQ3Qen6J",
+	} {
+		if _, err := ParseXPrv(xprv); err == nil {
+			t.Fatalf("Expected an error parsing %s", xprv)
+		}
+	}
+	if _, err := NewMasterHDKey(make([]byte, 15)); err == nil {
+		t.Fatalf("Expected an error for a seed that's too short")
+	}
+}
+
+func TestStringForNetwork(t *testing.T) {
+	master, err := NewMasterHDKey(decodeHex(hdTestSeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := master.HDPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tprv := master.StringForNetwork(TestNet)
+	if tprv[:4] != "tprv" {
+		t.Fatalf("Expected a tprv, got %s", tprv)
+	}
+	testnetKey, err := ParseXPrv(tprv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testnetKey.StringForNetwork(MainNet) != master.String() {
+		t.Fatalf("Expected retargeting back to mainnet to give %s, got %s", master.String(), testnetKey.StringForNetwork(MainNet))
+	}
+	if master.String() != master.StringForNetwork(MainNet) {
+		t.Fatalf("Expected StringForNetwork to not modify the key")
+	}
+	testnetPublic, err := testnetKey.HDPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpub := public.StringForNetwork(TestNet); tpub[:4] != "tpub" || tpub != testnetPublic.String() {
+		t.Fatalf("Expected the tpub %s, got %s", testnetPublic.String(), tpub)
+	}
+
+	// Custom version bytes, SLIP-132's zpub and zprv
+	custom := Network{PublicVersion: [4]byte{0x04, 0xb2, 0x47, 0x46}, PrivateVersion: [4]byte{0x04, 0xb2, 0x43, 0x0c}}
+	if zpub := public.StringForNetwork(custom); zpub[:4] != "zpub" {
+		t.Fatalf("Expected a zpub, got %s", zpub)
+	}
+	if zprv := master.StringForNetwork(custom); zprv[:4] != "zprv" {
+		t.Fatalf("Expected a zprv, got %s", zprv)
+	}
+}
+
+func TestDeriveChildren(t *testing.T) {
+	master, err := NewMasterHDKey(decodeHex(hdTestSeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	child0H, err := master.Derive(HardenedKeyStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		hd           *HDKey
+		start, count uint32
+	}{
+		{child0H, 0, 50},
+		{child0H, 7, 1},
+		{child0H, 3, 0},
+		// Across the hardened boundary.
+		{master, HardenedKeyStart - 3, 6},
+		{master, HardenedKeyStart, 4},
+		{master, math.MaxUint32 - 1, 2},
+	}
+	for _, test := range tests {
+		children, err := test.hd.DeriveChildren(test.start, test.count)
+		if err != nil {
+			t.Fatalf("DeriveChildren(%d, %d): %s", test.start, test.count, err)
+		}
+		if len(children) != int(test.count) {
+			t.Fatalf("Expected %d children, got %d", test.count, len(children))
+		}
+		for i, child := range children {
+			expected, err := test.hd.Derive(test.start + uint32(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if child.String() != expected.String() || child.ChildNumber() != test.start+uint32(i) {
+				t.Fatalf("Expected child %d to be %s, got %s", test.start+uint32(i), expected.String(), child.String())
+			}
+		}
+	}
+
+	// BIP-32 test vector 1: m/0H/1 and m/0H
+	children, err := child0H.DeriveChildren(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if children[1].String() != hdTest0H1XPrv {
+		t.Fatalf("Expected m/0H/1 to be %s, got %s", hdTest0H1XPrv, children[1].String())
+	}
+	children, err = master.DeriveChildren(HardenedKeyStart-1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if children[1].String() != hdTest0HXPrv {
+		t.Fatalf("Expected m/0H to be %s, got %s", hdTest0HXPrv, children[1].String())
+	}
+
+	for _, test := range []struct{ start, count uint32 }{{math.MaxUint32, 2}, {2, math.MaxUint32}} {
+		if _, err := master.DeriveChildren(test.start, test.count); err == nil {
+			t.Fatalf("Expected an error deriving %d children starting at %d", test.count, test.start)
+		}
+	}
+}
+
+func TestDerivePath(t *testing.T) {
+	// BIP-32 test vector 1
+	const (
+		xpub0H12H            = "xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5"
+		xpub0H12H2           = "xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV"
+		xprv0H12H21000000000 = "xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76"
+		xpub0H12H21000000000 = "xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy"
+	)
+	master, err := NewMasterHDKey(decodeHex(hdTestSeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct{ path, xprv string }{
+		{"m", hdTestMasterXPrv},
+		{"m/0'", hdTest0HXPrv},
+		{"m/0h/1", hdTest0H1XPrv},
+		{"m/0H/1/2'/2/1000000000", xprv0H12H21000000000},
+	} {
+		derived, err := master.DerivePath(test.path)
+		if err != nil {
+			t.Fatalf("%s: %s", test.path, err)
+		}
+		if derived.String() != test.xprv {
+			t.Fatalf("Expected %s to be %s, got %s", test.path, test.xprv, derived.String())
+		}
+	}
+
+	parent, err := ParseXPub(xpub0H12H)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct{ path, xpub string }{
+		{"m", xpub0H12H},
+		{"m/2", xpub0H12H2},
+		{"m/2/1000000000", xpub0H12H21000000000},
+	} {
+		derived, err := parent.DerivePath(test.path)
+		if err != nil {
+			t.Fatalf("%s: %s", test.path, err)
+		}
+		if derived.String() != test.xpub {
+			t.Fatalf("Expected %s to be %s, got %s", test.path, test.xpub, derived.String())
+		}
+	}
+	if _, err := parent.DerivePath("m/2'"); err == nil {
+		t.Fatalf("Expected an error deriving a hardened path from an extended public key")
+	}
+
+	indexes, err := ParseDerivationPath("m/44'/0h/0H/0/2147483647")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint32{HardenedKeyStart + 44, HardenedKeyStart, HardenedKeyStart, 0, HardenedKeyStart - 1}
+	if len(indexes) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, indexes)
+	}
+	for i := range expected {
+		if indexes[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, indexes)
+		}
+	}
+	for _, path := range []string{
+		"", "/", "m/", "44'/0'", "M/0", "m//0", "m/0/", "m/2147483648", "m/2147483648'", "m/4294967296",
+		"m/-1", "m/+1", "m/0x1", "m/1''", "m/'", "m/h", "m/1a", "m/ 1", "m/1 ",
+	} {
+		if _, err := ParseDerivationPath(path); err == nil {
+			t.Fatalf("Expected an error parsing the derivation path %q", path)
+		}
+		if _, err := master.DerivePath(path); err == nil {
+			t.Fatalf("Expected an error deriving the derivation path %q", path)
+		}
+	}
+}
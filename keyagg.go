@@ -0,0 +1,161 @@
+package secp256k1
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// keyAggListTag is the BIP-327 tag for hashing the list of public keys
+const keyAggListTag = "KeyAgg list"
+
+// keyAggCoefficientTag is the BIP-327 tag for hashing the coefficient of each public key
+const keyAggCoefficientTag = "KeyAgg coefficient"
+
+// AggregatePublicKeyID returns a commitment to the set of public keys, so all the participants can check they agree on it.
+// The keys are sorted by their x-only serialization (like xonly_pubkey_cmp) and hashed as
+// `TaggedHash("KeyAgg list", 0x02 || x_1 || 0x02 || x_2 ...)`, which is the BIP-327 key list hash of the sorted even-Y keys.
+// The order of keys doesn't matter and the slice isn't modified. It returns all zeros if any key is nil or uninitialized.
+func AggregatePublicKeyID(keys []*SchnorrPublicKey) [32]byte {
+	sorted, err := sortedSchnorrPublicKeys(keys)
+	if err != nil {
+		return [32]byte{}
+	}
+	return keyAggListHash(sorted)
+}
+
+// MusigAggregatePublicKeys aggregates the public keys with the MuSig2 (BIP-327) KeyAgg algorithm,
+// so the aggregated key can be computed (e.g. to show an address) before any signing session.
+// The keys are sorted like in AggregatePublicKeyID and lifted to even Y, and the result is `sum(a_i*P_i)` where
+// `a_i = TaggedHash("KeyAgg coefficient", L || 0x02 || x_i)` with L the key list hash,
+// except for the second distinct key in the sorted list whose coefficient is 1.
+// The coefficients make the aggregation resistant to rogue key attacks. The slice isn't modified.
+// It's the same key as the aggregated public key of NewMuSigKeyAggContext, which signs for it.
+func MusigAggregatePublicKeys(keys []*SchnorrPublicKey) (*SchnorrPublicKey, error) {
+	keyAgg, err := NewMuSigKeyAggContext(keys)
+	if err != nil {
+		return nil, err
+	}
+	return keyAgg.AggregatedPublicKey(), nil
+}
+
+// MuSigKeyAggContext is the result of aggregating the signers' public keys with the MuSig2 (BIP-327) KeyAgg algorithm,
+// it holds the signers' keys in signing order, their coefficients and the aggregated public key.
+// The struct itself is an opaque data type that should only be created via NewMuSigKeyAggContext.
+type MuSigKeyAggContext struct {
+	publicKeys   []SerializedECDSAPublicKey
+	coefficients [][32]byte
+	aggregated   ECDSAPublicKey
+}
+
+// NewMuSigKeyAggContext aggregates the x-only public keys of the signers, see MusigAggregatePublicKeys.
+// The keys are sorted and lifted to even Y, so every signer gets the same context regardless of the order of keys,
+// and the keys are passed to BIP-327 as the compressed keys `0x02 || x`. The slice isn't modified.
+func NewMuSigKeyAggContext(keys []*SchnorrPublicKey) (*MuSigKeyAggContext, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("can't aggregate an empty list of public keys")
+	}
+	sorted, err := sortedSchnorrPublicKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	publicKeys := make([]SerializedECDSAPublicKey, len(sorted))
+	for i := range sorted {
+		publicKeys[i][0] = 0x02
+		copy(publicKeys[i][1:], sorted[i][:])
+	}
+	return newMuSigKeyAggContext(publicKeys)
+}
+
+// newMuSigKeyAggContext is BIP-327's KeyAgg of compressed public keys, in the given order.
+func newMuSigKeyAggContext(publicKeys []SerializedECDSAPublicKey) (*MuSigKeyAggContext, error) {
+	points := make([]*ECDSAPublicKey, len(publicKeys))
+	data := make([][]byte, len(publicKeys))
+	for i := range publicKeys {
+		var err error
+		points[i], err = DeserializeECDSAPubKeyCompressedOnly(publicKeys[i][:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid public key at index %d", i)
+		}
+		data[i] = publicKeys[i][:]
+	}
+	listHash := TaggedHash(keyAggListTag, data...)
+
+	// The second distinct key gets the coefficient 1, if all the keys are the same there's no second key.
+	secondKey := -1
+	for i := range publicKeys {
+		if publicKeys[i] != publicKeys[0] {
+			secondKey = i
+			break
+		}
+	}
+	keyAgg := &MuSigKeyAggContext{
+		publicKeys:   append([]SerializedECDSAPublicKey{}, publicKeys...),
+		coefficients: make([][32]byte, len(publicKeys)),
+	}
+	for i := range publicKeys {
+		if secondKey != -1 && publicKeys[i] == publicKeys[secondKey] {
+			keyAgg.coefficients[i] = [32]byte{31: 1}
+		} else {
+			keyAgg.coefficients[i], _ = reduceScalar((*[32]byte)(TaggedHash(keyAggCoefficientTag, listHash[:], publicKeys[i][:])))
+		}
+	}
+	// Everything here is public so the variable time multiplication is fine.
+	aggregated, isInfinity, err := multiScalarMultInternal(nil, keyAgg.coefficients, points)
+	if err != nil {
+		return nil, err
+	}
+	if isInfinity {
+		return nil, errors.New("the aggregated public key is the point at infinity")
+	}
+	keyAgg.aggregated = *aggregated
+	return keyAgg, nil
+}
+
+// AggregatedPublicKey returns the x-only aggregated public key, which verifies the signatures of MuSigPartialSigAgg.
+func (keyAgg *MuSigKeyAggContext) AggregatedPublicKey() *SchnorrPublicKey {
+	aggregated, err := keyAgg.aggregated.ToSchnorr()
+	if err != nil {
+		panic("failed converting the initialized aggregated key. Should never happen")
+	}
+	return aggregated
+}
+
+// coefficient returns the key aggregation coefficient of the compressed public key, and false if it isn't one of the signers.
+func (keyAgg *MuSigKeyAggContext) coefficient(publicKey *SerializedECDSAPublicKey) ([32]byte, bool) {
+	for i := range keyAgg.publicKeys {
+		if keyAgg.publicKeys[i] == *publicKey {
+			return keyAgg.coefficients[i], true
+		}
+	}
+	return [32]byte{}, false
+}
+
+// sortedSchnorrPublicKeys returns the x-only serialization of the keys, sorted lexicographically.
+func sortedSchnorrPublicKeys(keys []*SchnorrPublicKey) ([]SerializedSchnorrPublicKey, error) {
+	serialized := make([]SerializedSchnorrPublicKey, len(keys))
+	for i, key := range keys {
+		if key == nil {
+			return nil, errors.Errorf("the public key at index %d is nil", i)
+		}
+		s, err := key.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		serialized[i] = *s
+	}
+	sort.Slice(serialized, func(i, j int) bool {
+		return bytes.Compare(serialized[i][:], serialized[j][:]) < 0
+	})
+	return serialized, nil
+}
+
+// keyAggListHash returns the BIP-327 hash of the list of keys, lifted to even Y.
+func keyAggListHash(keys []SerializedSchnorrPublicKey) [32]byte {
+	data := make([][]byte, 0, 2*len(keys))
+	for i := range keys {
+		data = append(data, []byte{0x02}, keys[i][:])
+	}
+	return *TaggedHash(keyAggListTag, data...)
+}
@@ -0,0 +1,116 @@
+package secp256k1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestAggregatePublicKeyID(t *testing.T) {
+	r := rand.New(rand.NewSource(66))
+	keys := make([]*SchnorrPublicKey, 5)
+	for i := range keys {
+		keypair := mustSchnorrKeyPair(t, r)
+		var err error
+		keys[i], err = keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	id := AggregatePublicKeyID(keys)
+	original := append([]*SchnorrPublicKey{}, keys...)
+	for i := 0; i < 10; i++ {
+		shuffled := append([]*SchnorrPublicKey{}, keys...)
+		r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		if AggregatePublicKeyID(shuffled) != id {
+			t.Fatalf("Expected the id to not depend on the order of the keys")
+		}
+	}
+	for i := range keys {
+		if keys[i] != original[i] {
+			t.Fatalf("AggregatePublicKeyID shouldn't reorder its input")
+		}
+	}
+	if AggregatePublicKeyID(keys[:4]) == id {
+		t.Fatalf("Expected a different set of keys to have a different id")
+	}
+	if AggregatePublicKeyID(append(keys, keys[0])) == id {
+		t.Fatalf("Expected a repeated key to change the id")
+	}
+	withNil := append([]*SchnorrPublicKey{}, keys...)
+	withNil[2] = nil
+	if AggregatePublicKeyID(withNil) != [32]byte{} || AggregatePublicKeyID([]*SchnorrPublicKey{new(SchnorrPublicKey)}) != [32]byte{} {
+		t.Fatalf("Expected all zeros for nil or uninitialized keys")
+	}
+}
+
+// TestAggregatePublicKeyIDBIP327 checks the id against the BIP-327 key list hash of the sorted keys,
+// the expected value was computed with btcd's musig2 implementation (btcec/v2 v2.3.4).
+func TestAggregatePublicKeyIDBIP327(t *testing.T) {
+	keys := []*SchnorrPublicKey{}
+	for _, key := range []string{
+		"F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"3590A94E768F8E1815C2F24B4D80A8E3149316C3518CE7B7AD338368D038CA66",
+	} {
+		pubkey, err := DeserializeSchnorrPubKey(decodeHex(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, pubkey)
+	}
+	id := AggregatePublicKeyID(keys)
+	expected := decodeHex("59f18aa5f7c221f8843f425d1b1d4b8af135b578ac55e46b6629ca12750d9465")
+	if !bytes.Equal(id[:], expected) {
+		t.Fatalf("Expected the id to be '%x', got '%x'", expected, id)
+	}
+}
+
+// TestMusigAggregatePublicKeys checks the aggregation against BIP-327's KeyAgg of the sorted even-Y keys.
+// The same key repeated is the BIP-327 key_agg vector with key_indices [0, 0, 0],
+// the other was computed with a Python port of the BIP-327 reference code.
+func TestMusigAggregatePublicKeys(t *testing.T) {
+	keys := []*SchnorrPublicKey{}
+	for _, key := range []string{
+		"F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"3590A94E768F8E1815C2F24B4D80A8E3149316C3518CE7B7AD338368D038CA66",
+	} {
+		pubkey, err := DeserializeSchnorrPubKey(decodeHex(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, pubkey)
+	}
+	tests := []struct {
+		keys     []*SchnorrPublicKey
+		expected string
+	}{
+		{keys, "442ace57c726b603fab693cc1915c38cfd4ac3e2eaac3b4810d74960e28a46fd"},
+		{[]*SchnorrPublicKey{keys[2], keys[0], keys[1]}, "442ace57c726b603fab693cc1915c38cfd4ac3e2eaac3b4810d74960e28a46fd"},
+		{[]*SchnorrPublicKey{keys[0], keys[0], keys[0]}, "b436e3bad62b8cd409969a224731c193d051162d8c5ae8b109306127da3aa935"},
+	}
+	for i, test := range tests {
+		aggregated, err := MusigAggregatePublicKeys(test.keys)
+		if err != nil {
+			t.Fatalf("test %d: %s", i, err)
+		}
+		serialized, err := aggregated.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(serialized[:], decodeHex(test.expected)) {
+			t.Fatalf("test %d: expected the aggregated key to be '%s', got '%x'", i, test.expected, serialized[:])
+		}
+	}
+
+	if _, err := MusigAggregatePublicKeys(nil); err == nil {
+		t.Fatalf("Expected an error aggregating no keys")
+	}
+	if _, err := MusigAggregatePublicKeys([]*SchnorrPublicKey{keys[0], nil}); err == nil {
+		t.Fatalf("Expected an error aggregating a nil key")
+	}
+	if _, err := MusigAggregatePublicKeys([]*SchnorrPublicKey{keys[0], new(SchnorrPublicKey)}); err == nil {
+		t.Fatalf("Expected an error aggregating an uninitialized key")
+	}
+}
@@ -0,0 +1,40 @@
+package secp256k1
+
+// keyImageDST is the hash to curve domain separation tag of the key image base point
+const keyImageDST = "go-secp256k1/keyimage_secp256k1_XMD:SHA-256_SSWU_RO_"
+
+// KeyImage computes the key image `I = x*Hp(P)` of the keypair for linkable ring signatures, where P is the x-only public key,
+// Hp is HashToCurve with the tag "go-secp256k1/keyimage_secp256k1_XMD:SHA-256_SSWU_RO_" and x is the private key of P's even Y point.
+// The same x-only public key always gives the same image, even though the keypair's private key can be x or -x,
+// so two signatures by the same key are linked, while the image alone doesn't reveal P.
+func (key *SchnorrKeyPair) KeyImage() (*ECDSAPublicKey, error) {
+	pubkey, isOdd, err := key.schnorrPublicKeyInternal()
+	if err != nil {
+		return nil, err
+	}
+	basePoint, err := KeyImageBasePoint(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	privateKey := key.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	scalar := [32]byte(*privateKey)
+	defer func() { scalar = [32]byte{} }()
+	if isOdd {
+		scalar = ScalarNegate(scalar)
+	}
+	err = basePoint.Mul(scalar)
+	if err != nil {
+		return nil, err
+	}
+	return basePoint, nil
+}
+
+// KeyImageBasePoint returns Hp(P), the point KeyImage multiplies by the private key, so a verifier can check ring signatures.
+func KeyImageBasePoint(pubkey *SchnorrPublicKey) (*ECDSAPublicKey, error) {
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return HashToCurve(serialized[:], []byte(keyImageDST))
+}
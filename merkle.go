@@ -0,0 +1,40 @@
+package secp256k1
+
+import "crypto/sha256"
+
+// maxMerkleProofLength is the maximum depth of a merkle proof, the index can't address more levels than it has bits
+const maxMerkleProofLength = 64
+
+// VerifyMerkleItem verifies that the signature is over the root of a merkle tree that has item at index.
+// The tree uses double SHA256 like bitcoin: the leaf is `SHA256(SHA256(item))` and every node is `SHA256(SHA256(left || right))`,
+// proof holds the sibling hashes from the leaf up to the root, and bit i of index says if the node at level i is a right child.
+// Notice: like bitcoin's merkle trees leaves and nodes aren't domain separated, so a 64 byte item can be mistaken for a node,
+// don't use this with 64 byte items that an attacker controls.
+func (key *SchnorrPublicKey) VerifyMerkleItem(item []byte, proof [][32]byte, index uint64, sig *SchnorrSignature) bool {
+	if len(proof) > maxMerkleProofLength {
+		return false
+	}
+	if len(proof) < maxMerkleProofLength && index>>uint(len(proof)) != 0 {
+		return false
+	}
+	node := doubleSHA256(item)
+	for i, sibling := range proof {
+		if index>>uint(i)&1 == 1 {
+			node = doubleSHA256(sibling[:], node[:])
+		} else {
+			node = doubleSHA256(node[:], sibling[:])
+		}
+	}
+	root := Hash(node)
+	return key.SchnorrVerify(&root, sig)
+}
+
+func doubleSHA256(data ...[]byte) [32]byte {
+	hasher := sha256.New()
+	for _, d := range data {
+		hasher.Write(d)
+	}
+	first := [32]byte{}
+	hasher.Sum(first[:0])
+	return sha256.Sum256(first[:])
+}
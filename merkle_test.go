@@ -0,0 +1,62 @@
+package secp256k1
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestVerifyMerkleItem(t *testing.T) {
+	r := rand.New(rand.NewSource(56))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const depth = 3
+	items := make([][]byte, 1<<depth)
+	levels := [][][32]byte{make([][32]byte, len(items))}
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("item %d", i))
+		levels[0][i] = doubleSHA256(items[i])
+	}
+	for level := 0; level < depth; level++ {
+		parents := make([][32]byte, len(levels[level])/2)
+		for i := range parents {
+			parents[i] = doubleSHA256(levels[level][2*i][:], levels[level][2*i+1][:])
+		}
+		levels = append(levels, parents)
+	}
+	root := Hash(levels[depth][0])
+	signature := mustSchnorrSign(t, keypair, &root)
+
+	for index := range items {
+		proof := make([][32]byte, depth)
+		for level := 0; level < depth; level++ {
+			proof[level] = levels[level][(index>>uint(level))^1]
+		}
+		if !pubkey.VerifyMerkleItem(items[index], proof, uint64(index), signature) {
+			t.Fatalf("Expected item %d to verify", index)
+		}
+		wrongIndex := uint64(index ^ 1)
+		if pubkey.VerifyMerkleItem(items[index], proof, wrongIndex, signature) {
+			t.Fatalf("Expected item %d to not verify at index %d", index, wrongIndex)
+		}
+		if pubkey.VerifyMerkleItem(items[index], proof, uint64(index)+1<<depth, signature) {
+			t.Fatalf("Expected an index with bits beyond the proof to be rejected")
+		}
+		if pubkey.VerifyMerkleItem([]byte("another item"), proof, uint64(index), signature) {
+			t.Fatalf("Expected an item that isn't in the tree to not verify")
+		}
+	}
+	if pubkey.VerifyMerkleItem(items[0], make([][32]byte, maxMerkleProofLength+1), 0, signature) {
+		t.Fatalf("Expected a proof longer than %d to be rejected", maxMerkleProofLength)
+	}
+	// A tree with a single item, the root is the leaf.
+	leafRoot := Hash(doubleSHA256(items[0]))
+	leafSignature := mustSchnorrSign(t, keypair, &leafRoot)
+	if !pubkey.VerifyMerkleItem(items[0], nil, 0, leafSignature) {
+		t.Fatalf("Expected a single item tree to verify")
+	}
+}
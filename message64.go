@@ -0,0 +1,16 @@
+package secp256k1
+
+import "crypto/sha256"
+
+// SchnorrSign64 creates a schnorr signature over a 64 byte message, by signing its SHA256.
+// SchnorrVerify64 is the verifying side of this.
+func (key *SchnorrKeyPair) SchnorrSign64(msg *[64]byte) (*SchnorrSignature, error) {
+	hash := Hash(sha256.Sum256(msg[:]))
+	return key.SchnorrSign(&hash)
+}
+
+// SchnorrVerify64 verifies a schnorr signature created by SchnorrSign64 over the 64 byte message.
+func (key *SchnorrPublicKey) SchnorrVerify64(msg *[64]byte, signature *SchnorrSignature) bool {
+	hash := Hash(sha256.Sum256(msg[:]))
+	return key.SchnorrVerify(&hash, signature)
+}
@@ -0,0 +1,141 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+	"strings"
+)
+
+// mnemonicWords is the BIP-39 English word list, and mnemonicWordIndexes maps each word to its index in the list.
+var mnemonicWords []string
+var mnemonicWordIndexes map[string]int
+
+func init() {
+	mnemonicWords = strings.Fields(englishWordList)
+	if len(mnemonicWords) != 2048 {
+		panic("the BIP-39 word list should have exactly 2048 words")
+	}
+	mnemonicWordIndexes = make(map[string]int, len(mnemonicWords))
+	for i, word := range mnemonicWords {
+		mnemonicWordIndexes[word] = i
+	}
+}
+
+// ToEntropyMnemonic encodes the private key as a 24 word BIP-39 mnemonic, using the private key as the 256 bit entropy.
+// FromEntropyMnemonic is the inverse of this.
+// Notice: this *isn't* standard BIP-39 key derivation and *not* a wallet backup,
+// wallets (and FromMnemonic) restore a different key from the same mnemonic.
+func (key *SchnorrKeyPair) ToEntropyMnemonic() (string, error) {
+	if !key.init {
+		return "", errors.WithStack(errNonInitializedKey)
+	}
+	privateKey := key.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	return entropyToMnemonic(privateKey[:]), nil
+}
+
+// FromMnemonic derives the keypair of a BIP-39 mnemonic and passphrase the way wallets do: the mnemonic is turned
+// into a seed with MnemonicToSeed (PBKDF2), and the keypair is the seed's BIP-32 master key, the key at the path "m".
+// Use NewMasterHDKey with MnemonicToSeed to derive keys at other paths.
+// Notice: this isn't the inverse of ToEntropyMnemonic, see FromEntropyMnemonic.
+func FromMnemonic(phrase, passphrase string) (*SchnorrKeyPair, error) {
+	seed, err := MnemonicToSeed(phrase, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for i := range seed {
+			seed[i] = 0
+		}
+	}()
+	master, err := NewMasterHDKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { master.privateKey = ECDSAPrivateKey{} }()
+	privateKey := master.privateKey.Serialize()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	return DeserializeSchnorrPrivateKey(privateKey)
+}
+
+// FromEntropyMnemonic decodes a 24 word BIP-39 mnemonic created by ToEntropyMnemonic back into a SchnorrKeyPair,
+// using the mnemonic's entropy as the private key. It verifies the mnemonic's checksum and that the entropy is a valid private key.
+// Notice: this *isn't* BIP-39's seed derivation, so it isn't compatible with wallets' mnemonics, see FromMnemonic.
+func FromEntropyMnemonic(phrase string) (*SchnorrKeyPair, error) {
+	entropy, err := mnemonicToEntropy(phrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(entropy) != SerializedPrivateKeySize {
+		return nil, errors.Errorf("a private key mnemonic has to be 24 words, instead got %d", len(strings.Fields(phrase)))
+	}
+	privateKey := SerializedPrivateKey{}
+	copy(privateKey[:], entropy)
+	defer func() { privateKey = SerializedPrivateKey{} }()
+	return DeserializeSchnorrPrivateKey(&privateKey)
+}
+
+// MnemonicToSeed validates a BIP-39 mnemonic of any standard length and derives the 64 byte seed from it,
+// using PBKDF2-HMAC-SHA512 with 2048 iterations and the salt "mnemonic" || passphrase.
+// Notice: the passphrase is used as is, a non ASCII passphrase should be NFKD normalized by the caller as BIP-39 requires.
+func MnemonicToSeed(phrase, passphrase string) ([]byte, error) {
+	_, err := mnemonicToEntropy(phrase)
+	if err != nil {
+		return nil, err
+	}
+	normalized := strings.Join(strings.Fields(phrase), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
+
+// entropyToMnemonic encodes the entropy with its checksum as words of 11 bits each.
+// len(entropy) must be a multiple of 4 bytes.
+func entropyToMnemonic(entropy []byte) string {
+	checksumBits := len(entropy) / 4
+	checksum := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), checksum[0])
+	wordsCount := (len(entropy)*8 + checksumBits) / 11
+
+	words := make([]string, wordsCount)
+	for i := range words {
+		index := 0
+		for bit := i * 11; bit < (i+1)*11; bit++ {
+			index = index<<1 | int(data[bit/8]>>(7-uint(bit%8))&1)
+		}
+		words[i] = mnemonicWords[index]
+	}
+	return strings.Join(words, " ")
+}
+
+// mnemonicToEntropy decodes a 12, 15, 18, 21 or 24 word mnemonic into its entropy, verifying the checksum.
+func mnemonicToEntropy(phrase string) ([]byte, error) {
+	words := strings.Fields(phrase)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, errors.Errorf("invalid mnemonic length, expected 12, 15, 18, 21 or 24 words, got %d", len(words))
+	}
+	totalBits := len(words) * 11
+	checksumBits := totalBits / 33
+	entropyBits := totalBits - checksumBits
+
+	data := make([]byte, (totalBits+7)/8)
+	for i, word := range words {
+		index, ok := mnemonicWordIndexes[word]
+		if !ok {
+			return nil, errors.Errorf("invalid mnemonic, the word '%s' isn't in the BIP-39 word list", word)
+		}
+		for j := 0; j < 11; j++ {
+			if index>>(10-uint(j))&1 == 1 {
+				bit := i*11 + j
+				data[bit/8] |= 1 << (7 - uint(bit%8))
+			}
+		}
+	}
+	entropy := data[:entropyBits/8]
+	checksum := sha256.Sum256(entropy)
+	mask := byte(0xff << (8 - uint(checksumBits)))
+	if data[entropyBits/8]&mask != checksum[0]&mask {
+		return nil, errors.New("invalid mnemonic checksum")
+	}
+	return entropy, nil
+}
@@ -0,0 +1,187 @@
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func TestMnemonicWordList(t *testing.T) {
+	// sha256 of https://github.com/bitcoin/bips/blob/master/bip-0039/english.txt
+	expected := "2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda"
+	hash := sha256.Sum256([]byte(englishWordList))
+	if hex.EncodeToString(hash[:]) != expected {
+		t.Fatalf("Expected the word list hash to be %s, got %x", expected, hash)
+	}
+}
+
+func TestMnemonicVectors(t *testing.T) {
+	// Test vectors taken from https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+		validKey bool
+	}{
+		{
+			"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			"legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth title",
+			"bc09fca1804f7e69da93c2f2028eb238c227f2e9dda30cd63699232578480a4021b146ad717fbb7e451ce9eb835f43620bf5c514db0f8add49f5d121449d3e87",
+			true,
+		},
+		{
+			"8080808080808080808080808080808080808080808080808080808080808080",
+			"letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless",
+			"c0c519bd0e91a2ed54357d9d1ebef6f5af218a153624cf4f2da911a0ed8f7a09e2ef61af0aca007096df430022f7a2b6fb91661a9589097069720d015e4e982f",
+			true,
+		},
+		{
+			"f585c11aec520db57dd353c69554b21a89b20fb0650966fa0a9d6f74fd989d8f",
+			"void come effort suffer camp survey warrior heavy shoot primary clutch crush open amazing screen patrol group space point ten exist slush involve unfold",
+			"01f5bced59dec48e362f2c45b5de68b9fd6c92c6634f44d6d40aab69056506f0e35524a518034ddc1192e1dacd32c1ed3eaa3c3b131c88ed8e7e54c49a5d0998",
+			true,
+		},
+		{ // bigger than the group order
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			"dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+			false,
+		},
+		{ // 12 words, too short for a private key
+			"00000000000000000000000000000000",
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+			false,
+		},
+	}
+
+	for i, test := range tests {
+		seed, err := MnemonicToSeed(test.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("Test vector '%d': %s", i, err)
+		}
+		if !bytes.Equal(seed, decodeHex(test.seed)) {
+			t.Errorf("Test vector '%d': expected seed %s, got %x", i, test.seed, seed)
+		}
+		if entropyToMnemonic(decodeHex(test.entropy)) != test.mnemonic {
+			t.Errorf("Test vector '%d': expected mnemonic '%s', got '%s'", i, test.mnemonic, entropyToMnemonic(decodeHex(test.entropy)))
+		}
+
+		keypair, err := FromEntropyMnemonic(test.mnemonic)
+		if !test.validKey {
+			if err == nil {
+				t.Errorf("Test vector '%d': expected an invalid private key", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test vector '%d': %s", i, err)
+		}
+		if hex.EncodeToString(keypair.SerializePrivateKey()[:]) != test.entropy {
+			t.Errorf("Test vector '%d': expected private key %s, got %s", i, test.entropy, keypair)
+		}
+		mnemonic, err := keypair.ToEntropyMnemonic()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mnemonic != test.mnemonic {
+			t.Errorf("Test vector '%d': expected mnemonic '%s', got '%s'", i, test.mnemonic, mnemonic)
+		}
+	}
+}
+
+func TestFromMnemonic(t *testing.T) {
+	// The bip32_xprv of test vectors from https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+	tests := []struct {
+		mnemonic string
+		xprv     string
+	}{
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF",
+		},
+		{
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"xprv9s21ZrQH143K2gA81bYFHqU68xz1cX2APaSq5tt6MFSLeXnCKV1RVUJt9FWNTbrrryem4ZckN8k4Ls1H6nwdvDTvnV7zEXs2HgPezuVccsq",
+		},
+	}
+	for i, test := range tests {
+		keypair, err := FromMnemonic(test.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("Test vector '%d': %s", i, err)
+		}
+		master, err := ParseXPrv(test.xprv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *keypair.SerializePrivateKey() != *master.PrivateKey().Serialize() {
+			t.Errorf("Test vector '%d': expected the private key of %s, got %s", i, test.xprv, keypair)
+		}
+		withoutPassphrase, err := FromMnemonic(test.mnemonic, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *withoutPassphrase.SerializePrivateKey() == *keypair.SerializePrivateKey() {
+			t.Errorf("Test vector '%d': expected the passphrase to change the key", i)
+		}
+	}
+}
+
+func TestMnemonicRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(10))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		mnemonic, err := keypair.ToEntropyMnemonic()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := FromEntropyMnemonic(mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *decoded != *keypair {
+			t.Fatalf("Expected %s == %s", decoded, keypair)
+		}
+		// A standard BIP-39 restore of the same phrase gives another key.
+		restored, err := FromMnemonic(mnemonic, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *restored.SerializePrivateKey() == *keypair.SerializePrivateKey() {
+			t.Fatalf("Expected FromMnemonic to not restore the key of ToEntropyMnemonic")
+		}
+	}
+}
+
+func TestMnemonicInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"abandon abandon abandon",
+		// Bad checksum
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		// Not in the word list
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon bitcoin",
+		// 25 words
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+	}
+	for i, test := range tests {
+		_, err := MnemonicToSeed(test, "")
+		if err == nil {
+			t.Errorf("Test '%d': expected an error for the mnemonic '%s'", i, test)
+		}
+		_, err = FromEntropyMnemonic(test)
+		if err == nil {
+			t.Errorf("Test '%d': expected an error for the mnemonic '%s'", i, test)
+		}
+		_, err = FromMnemonic(test, "")
+		if err == nil {
+			t.Errorf("Test '%d': expected an error for the mnemonic '%s'", i, test)
+		}
+	}
+	_, err := new(SchnorrKeyPair).ToEntropyMnemonic()
+	if err == nil {
+		t.Errorf("Expected an error for a zeroed keypair")
+	}
+}
@@ -0,0 +1,50 @@
+package secp256k1
+
+// // The ENABLE_MODULE_* macros come from the CFLAGS in secp256k1.go.
+// static int has_module_recovery(void) {
+// #ifdef ENABLE_MODULE_RECOVERY
+//     return 1;
+// #else
+//     return 0;
+// #endif
+// }
+// static int has_module_schnorrsig(void) {
+// #ifdef ENABLE_MODULE_SCHNORRSIG
+//     return 1;
+// #else
+//     return 0;
+// #endif
+// }
+// static int has_module_extrakeys(void) {
+// #ifdef ENABLE_MODULE_EXTRAKEYS
+//     return 1;
+// #else
+//     return 0;
+// #endif
+// }
+// static int has_module_ecdh(void) {
+// #ifdef ENABLE_MODULE_ECDH
+//     return 1;
+// #else
+//     return 0;
+// #endif
+// }
+import "C"
+
+// HasModule returns true if the optional libsecp256k1 module was compiled in.
+// The known modules are "recovery", "schnorrsig", "extrakeys", "ecdh" and "musig", any other name returns false.
+// Notice: the vendored libsecp256k1 doesn't have a musig module, so "musig" is always false, MuSig2 (see MuSigNonceGen) is implemented in Go.
+func HasModule(name string) bool {
+	switch name {
+	case "recovery":
+		return C.has_module_recovery() == 1
+	case "schnorrsig":
+		return C.has_module_schnorrsig() == 1
+	case "extrakeys":
+		return C.has_module_extrakeys() == 1
+	case "ecdh":
+		return C.has_module_ecdh() == 1
+	default:
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package secp256k1
+
+// #include "./depend/secp256k1/include/secp256k1.h"
+// int go_secp256k1_ecmult_multi(const secp256k1_context *ctx, secp256k1_pubkey *result, const unsigned char *g_scalar32,
+//                               const unsigned char *scalars32, const secp256k1_pubkey *points, size_t n);
+import "C"
+import (
+	"github.com/pkg/errors"
+)
+
+// MultiScalarMult computes `scalars[0]*points[0] + scalars[1]*points[1] + ... + scalars[n-1]*points[n-1]`
+// using libsecp256k1's multi-multiplication (Strauss/Pippenger depending on the amount of points)
+// This is much faster than multiplying and adding each point by itself.
+// Notice: this isn't constant time, and shouldn't be used with secret scalars.
+func MultiScalarMult(scalars [][32]byte, points []*ECDSAPublicKey) (*ECDSAPublicKey, error) {
+	result, isInfinity, err := multiScalarMultInternal(nil, scalars, points)
+	if err != nil {
+		return nil, err
+	}
+	if isInfinity {
+		return nil, errors.New("the result of the multiplication is the point at infinity")
+	}
+	return result, nil
+}
+
+// multiScalarMultInternal computes `gScalar*Generator + sum(scalars[i]*points[i])`, gScalar can be nil.
+func multiScalarMultInternal(gScalar *[32]byte, scalars [][32]byte, points []*ECDSAPublicKey) (result *ECDSAPublicKey, isInfinity bool, err error) {
+	if len(scalars) != len(points) {
+		return nil, false, errors.Errorf("the amount of scalars and points should be the same, got %d scalars and %d points", len(scalars), len(points))
+	}
+	if gScalar == nil && len(points) == 0 {
+		return nil, false, errors.New("can't multiply an empty list of points")
+	}
+	var cPtrGScalar *C.uchar
+	if gScalar != nil {
+		if _, overflowed := reduceScalar(gScalar); overflowed {
+			return nil, false, errors.New("the generator's scalar is bigger than the group order")
+		}
+		cPtrGScalar = (*C.uchar)(&gScalar[0])
+	}
+
+	// Arrays that will be passed to C need to be contiguous, and must have at least one element to take their address.
+	cScalars := make([]byte, 32*len(scalars)+1)
+	cPoints := make([]C.secp256k1_pubkey, len(points)+1)
+	for i, point := range points {
+		if !point.init {
+			return nil, false, errors.WithStack(errNonInitializedKey)
+		}
+		if _, overflowed := reduceScalar(&scalars[i]); overflowed {
+			return nil, false, errors.Errorf("scalar number %d is bigger than the group order", i)
+		}
+		copy(cScalars[32*i:], scalars[i][:])
+		cPoints[i] = point.pubkey
+	}
+
+	result = &ECDSAPublicKey{init: true}
+	cPtrScalars := (*C.uchar)(&cScalars[0])
+	ret := C.go_secp256k1_ecmult_multi(context, &result.pubkey, cPtrGScalar, cPtrScalars, &cPoints[0], C.size_t(len(points)))
+	switch ret {
+	case 1:
+		return result, false, nil
+	case 0:
+		return nil, true, nil
+	default:
+		return nil, false, errors.New("failed computing the multi scalar multiplication")
+	}
+}
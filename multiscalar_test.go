@@ -0,0 +1,79 @@
+package secp256k1
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestMultiScalarMult(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	// Check both Strauss' (small n) and Pippenger's (n >= 88) algorithms
+	for _, n := range []int{1, 2, 10, 100} {
+		scalars := make([][32]byte, n)
+		points := make([]*ECDSAPublicKey, n)
+		expected := new(big.Int)
+		for i := 0; i < n; i++ {
+			privkey := mustECDSAPrivateKey(t, r)
+			var err error
+			points[i], err = privkey.ECDSAPublicKey()
+			if err != nil {
+				t.Fatalf("A valid privkey should convert to a pubkey: '%s'", err)
+			}
+			scalars[i] = *fastGenerateTweak(t, r)
+			term := new(big.Int).SetBytes(scalars[i][:])
+			term.Mul(term, new(big.Int).SetBytes(privkey.Serialize()[:]))
+			expected.Add(expected, term)
+		}
+		expected.Mod(expected, Secp256k1Order)
+		serializedExpected := SerializedPrivateKey(intTo32Bytes(expected))
+		expectedPrivKey, err := DeserializeECDSAPrivateKey(&serializedExpected)
+		if err != nil {
+			t.Fatalf("Failed deserializing the expected private key: '%s'", err)
+		}
+		expectedPubKey, err := expectedPrivKey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatalf("A valid privkey should convert to a pubkey: '%s'", err)
+		}
+
+		result, err := MultiScalarMult(scalars, points)
+		if err != nil {
+			t.Fatalf("Failed computing the multi scalar multiplication of %d points: '%s'", n, err)
+		}
+		if !result.IsEqual(expectedPubKey) {
+			t.Errorf("MultiScalarMult of %d points: expected %s, got %s", n, expectedPubKey, result)
+		}
+	}
+}
+
+func TestMultiScalarMultFail(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	privkey := mustECDSAPrivateKey(t, r)
+	pubkey, err := privkey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatalf("A valid privkey should convert to a pubkey: '%s'", err)
+	}
+	one := intTo32Bytes(big.NewInt(1))
+	orderMinusOne := intTo32Bytes(new(big.Int).Sub(Secp256k1Order, big.NewInt(1)))
+
+	_, err = MultiScalarMult([][32]byte{one, orderMinusOne}, []*ECDSAPublicKey{pubkey, pubkey})
+	if err == nil {
+		t.Errorf("P + (n-1)*P is the point at infinity and should fail")
+	}
+	_, err = MultiScalarMult([][32]byte{one}, []*ECDSAPublicKey{pubkey, pubkey})
+	if err == nil {
+		t.Errorf("Different amounts of scalars and points should fail")
+	}
+	_, err = MultiScalarMult(nil, nil)
+	if err == nil {
+		t.Errorf("An empty list of points should fail")
+	}
+	_, err = MultiScalarMult([][32]byte{intTo32Bytes(Secp256k1Order)}, []*ECDSAPublicKey{pubkey})
+	if err == nil {
+		t.Errorf("A scalar equal to the group order should fail")
+	}
+	_, err = MultiScalarMult([][32]byte{one}, []*ECDSAPublicKey{new(ECDSAPublicKey)})
+	if err == nil {
+		t.Errorf("A zeroed public key should fail")
+	}
+}
@@ -0,0 +1,97 @@
+package secp256k1
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"math"
+)
+
+const (
+	// multiSigCountSize is the size of the big endian entry count that prefixes a multi-signature envelope
+	multiSigCountSize = 2
+	// multiSigEntrySize is the size of an `x-only public key (32 bytes) || schnorr signature (64 bytes)` record in a multi-signature envelope
+	multiSigEntrySize = SerializedSchnorrPublicKeySize + SerializedSchnorrSignatureSize
+)
+
+// MultiSigEntry is a public key and its signature in a multi-signature envelope, see SerializeMultiSig.
+type MultiSigEntry struct {
+	PublicKey *SchnorrPublicKey
+	Signature *SchnorrSignature
+}
+
+// SerializeMultiSig serializes the entries into a multi-signature envelope:
+// a 2 byte big endian count followed by `x-only public key (32 bytes) || schnorr signature (64 bytes)` for every entry.
+func SerializeMultiSig(entries []MultiSigEntry) ([]byte, error) {
+	if len(entries) > math.MaxUint16 {
+		return nil, errors.Errorf("a multi-signature envelope can hold at most %d entries, got %d", math.MaxUint16, len(entries))
+	}
+	buf := make([]byte, multiSigCountSize+multiSigEntrySize*len(entries))
+	binary.BigEndian.PutUint16(buf, uint16(len(entries)))
+	for i, entry := range entries {
+		if entry.PublicKey == nil || entry.Signature == nil {
+			return nil, errors.Errorf("entry number %d of the multi-signature is nil", i)
+		}
+		serialized, err := entry.PublicKey.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		record := buf[multiSigCountSize+i*multiSigEntrySize:]
+		copy(record, serialized[:])
+		copy(record[SerializedSchnorrPublicKeySize:], entry.Signature.signature[:])
+	}
+	return buf, nil
+}
+
+// ParseMultiSig parses a multi-signature envelope created by SerializeMultiSig.
+// It's an error if the length doesn't match the count or any of the public keys is invalid.
+func ParseMultiSig(data []byte) ([]MultiSigEntry, error) {
+	if len(data) < multiSigCountSize {
+		return nil, errors.Errorf("a multi-signature envelope is at least %d bytes, got %d", multiSigCountSize, len(data))
+	}
+	count := int(binary.BigEndian.Uint16(data))
+	expectedLength := multiSigCountSize + multiSigEntrySize*count
+	if len(data) != expectedLength {
+		return nil, errors.Errorf("a multi-signature envelope with %d entries should be %d bytes, got %d", count, expectedLength, len(data))
+	}
+	entries := make([]MultiSigEntry, count)
+	for i := range entries {
+		record := data[multiSigCountSize+i*multiSigEntrySize : multiSigCountSize+(i+1)*multiSigEntrySize]
+		pubkey, err := DeserializeSchnorrPubKey(record[:SerializedSchnorrPublicKeySize])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed parsing the public key of entry number %d", i)
+		}
+		signature := SchnorrSignature{}
+		copy(signature.signature[:], record[SerializedSchnorrPublicKeySize:])
+		entries[i] = MultiSigEntry{PublicKey: pubkey, Signature: &signature}
+	}
+	return entries, nil
+}
+
+// VerifyMultiSig returns true if every entry's signature is valid for its public key over the hash.
+// It's an error if there are no entries, an entry is nil, or the same public key appears more than once,
+// because then a single signer would be counted twice.
+func VerifyMultiSig(entries []MultiSigEntry, hash *Hash) (bool, error) {
+	if len(entries) == 0 {
+		return false, errors.New("can't verify an empty multi-signature")
+	}
+	seen := make(map[SerializedSchnorrPublicKey]struct{}, len(entries))
+	for i, entry := range entries {
+		if entry.PublicKey == nil || entry.Signature == nil {
+			return false, errors.Errorf("entry number %d of the multi-signature is nil", i)
+		}
+		serialized, err := entry.PublicKey.Serialize()
+		if err != nil {
+			return false, err
+		}
+		if _, ok := seen[*serialized]; ok {
+			return false, errors.Errorf("the public key of entry number %d appears more than once", i)
+		}
+		seen[*serialized] = struct{}{}
+	}
+	for _, entry := range entries {
+		if !entry.PublicKey.SchnorrVerify(hash, entry.Signature) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,89 @@
+package secp256k1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestMultiSig(t *testing.T) {
+	r := rand.New(rand.NewSource(177))
+	hash := Hash{}
+	r.Read(hash[:])
+	entries := make([]MultiSigEntry, 3)
+	for i := range entries {
+		keypair := mustSchnorrKeyPair(t, r)
+		var err error
+		entries[i].PublicKey, err = keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[i].Signature = mustSchnorrSign(t, keypair, &hash)
+	}
+
+	serialized, err := SerializeMultiSig(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serialized) != 2+96*len(entries) || !bytes.Equal(serialized[:2], []byte{0, 3}) {
+		t.Fatalf("Unexpected envelope encoding: %x", serialized)
+	}
+	parsed, err := ParseMultiSig(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != len(entries) {
+		t.Fatalf("Expected %d entries, got %d", len(entries), len(parsed))
+	}
+	for i := range parsed {
+		if !parsed[i].PublicKey.IsEqual(entries[i].PublicKey) || !parsed[i].Signature.IsEqual(entries[i].Signature) {
+			t.Fatalf("Entry %d didn't round trip", i)
+		}
+	}
+	valid, err := VerifyMultiSig(parsed, &hash)
+	if err != nil || !valid {
+		t.Fatalf("Expected the multi-signature to verify, got: %t, %v", valid, err)
+	}
+
+	otherHash := Hash{1}
+	valid, err = VerifyMultiSig(parsed, &otherHash)
+	if err != nil || valid {
+		t.Fatalf("Expected the multi-signature to not verify over another hash, got: %t, %v", valid, err)
+	}
+	duplicated := append(parsed[:len(parsed):len(parsed)], parsed[0])
+	_, err = VerifyMultiSig(duplicated, &hash)
+	if err == nil {
+		t.Fatalf("Expected an error for a duplicated public key")
+	}
+	_, err = VerifyMultiSig(nil, &hash)
+	if err == nil {
+		t.Fatalf("Expected an error for an empty multi-signature")
+	}
+	_, err = VerifyMultiSig([]MultiSigEntry{{PublicKey: entries[0].PublicKey}}, &hash)
+	if err == nil {
+		t.Fatalf("Expected an error for an entry without a signature")
+	}
+	_, err = SerializeMultiSig([]MultiSigEntry{{Signature: entries[0].Signature}})
+	if err == nil {
+		t.Fatalf("Expected an error serializing an entry without a public key")
+	}
+
+	empty, err := SerializeMultiSig(nil)
+	if err != nil || !bytes.Equal(empty, []byte{0, 0}) {
+		t.Fatalf("Expected an empty envelope to be the zero count, got: %x, %v", empty, err)
+	}
+	for _, malformed := range [][]byte{nil, {0}, serialized[:len(serialized)-1], append(serialized[:len(serialized):len(serialized)], 0)} {
+		_, err := ParseMultiSig(malformed)
+		if err == nil {
+			t.Fatalf("Expected an error parsing a malformed envelope of %d bytes", len(malformed))
+		}
+	}
+	invalidKey := append([]byte{}, serialized...)
+	for i := 2; i < 2+32; i++ {
+		invalidKey[i] = 0xff
+	}
+	_, err = ParseMultiSig(invalidKey)
+	if err == nil {
+		t.Fatalf("Expected an error parsing an envelope with an invalid public key")
+	}
+}
@@ -0,0 +1,415 @@
+package secp256k1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// musigAuxTag is the BIP-327 tag for hashing the nonce generation randomness
+	musigAuxTag = "MuSig/aux"
+	// musigNonceTag is the BIP-327 tag for deriving the secret nonces
+	musigNonceTag = "MuSig/nonce"
+	// musigNonceCoefficientTag is the BIP-327 tag for the coefficient b of the second aggregated nonce
+	musigNonceCoefficientTag = "MuSig/noncecoef"
+
+	// MuSigPublicNonceSize defines the length in bytes of MuSigPublicNonce
+	MuSigPublicNonceSize = 2 * SerializedECDSAPublicKeySize
+	// MuSigAggregateNonceSize defines the length in bytes of MuSigAggregateNonce
+	MuSigAggregateNonceSize = 2 * SerializedECDSAPublicKeySize
+)
+
+// MuSigSecretNonce is a signer's secret nonce for a single MuSig2 signing session, from MuSigNonceGen.
+// It's erased by MuSigPartialSign, and can't be serialized, because signing twice with the same nonce leaks the private key.
+// The struct itself is an opaque data type that should only be created via the supplied methods, and *MUST NOT* be copied.
+type MuSigSecretNonce struct {
+	k1, k2    [32]byte
+	publicKey SerializedECDSAPublicKey
+	used      bool
+}
+
+// MuSigPublicNonce is a signer's public nonce `R1 || R2`, two compressed points, which is sent to the other signers.
+type MuSigPublicNonce [MuSigPublicNonceSize]byte
+
+// String returns the MuSigPublicNonce as the hexadecimal string
+func (nonce MuSigPublicNonce) String() string {
+	return hex.EncodeToString(nonce[:])
+}
+
+// MuSigAggregateNonce is the sum of the signers' public nonces from MuSigNonceAgg, two compressed points
+// where the point at infinity is encoded as 33 zero bytes.
+type MuSigAggregateNonce [MuSigAggregateNonceSize]byte
+
+// String returns the MuSigAggregateNonce as the hexadecimal string
+func (nonce MuSigAggregateNonce) String() string {
+	return hex.EncodeToString(nonce[:])
+}
+
+// MuSigSession is a MuSig2 signing session of a message by the signers of a MuSigKeyAggContext, after the nonce exchange.
+// The struct itself is an opaque data type that should only be created via NewMuSigSession.
+type MuSigSession struct {
+	keyAgg           *MuSigKeyAggContext
+	nonceCoefficient [32]byte
+	finalNonce       ECDSAPublicKey
+	challenge        [32]byte
+}
+
+// MuSigNonceGen generates the keypair's secret and public nonces for a MuSig2 signing session with BIP-327's NonceGen,
+// the public nonce is sent to the other signers in the first round and the secret nonce is used once by MuSigPartialSign.
+// keyAgg, msg and extraInput are optional (can be nil), they're mixed into the nonce as extra protection
+// in case the random number generator is broken. The nonces are bound to the keypair's x-only public key.
+// Notice: the secret nonce *MUST NOT* be reused, use a new one for every session.
+func MuSigNonceGen(key *SchnorrKeyPair, keyAgg *MuSigKeyAggContext, msg *Hash, extraInput []byte) (*MuSigSecretNonce, *MuSigPublicNonce, error) {
+	secretKey, publicKey, err := musigSignerKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { secretKey = [32]byte{} }()
+	var aggregatedPublicKey []byte
+	if keyAgg != nil {
+		serialized, err := keyAgg.AggregatedPublicKey().Serialize()
+		if err != nil {
+			return nil, nil, err
+		}
+		aggregatedPublicKey = serialized[:]
+	}
+	var msgBytes []byte
+	if msg != nil {
+		msgBytes = msg[:]
+	}
+	var random [32]byte
+	err = readRandom(random[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return musigNonceGen(random, secretKey[:], publicKey, aggregatedPublicKey, msgBytes, extraInput)
+}
+
+// musigNonceGen is BIP-327's NonceGen with the randomness rand', secretKey, aggregatedPublicKey and msg can be nil if they're absent.
+func musigNonceGen(random [32]byte, secretKey []byte, publicKey *SerializedECDSAPublicKey, aggregatedPublicKey, msg, extraInput []byte) (
+	*MuSigSecretNonce, *MuSigPublicNonce, error) {
+
+	if secretKey != nil {
+		aux := TaggedHash(musigAuxTag, random[:])
+		for i := range random {
+			random[i] = secretKey[i] ^ aux[i]
+		}
+	}
+	defer func() { random = [32]byte{} }()
+	msgPrefixed := []byte{0}
+	if msg != nil {
+		msgPrefixed = make([]byte, 9, 9+len(msg))
+		msgPrefixed[0] = 1
+		binary.BigEndian.PutUint64(msgPrefixed[1:], uint64(len(msg)))
+		msgPrefixed = append(msgPrefixed, msg...)
+	}
+	var extraInputLength [4]byte
+	binary.BigEndian.PutUint32(extraInputLength[:], uint32(len(extraInput)))
+
+	secretNonce := &MuSigSecretNonce{publicKey: *publicKey}
+	publicNonce := &MuSigPublicNonce{}
+	for i, k := range []*[32]byte{&secretNonce.k1, &secretNonce.k2} {
+		hash := TaggedHash(musigNonceTag, random[:], []byte{SerializedECDSAPublicKeySize}, publicKey[:],
+			[]byte{byte(len(aggregatedPublicKey))}, aggregatedPublicKey, msgPrefixed, extraInputLength[:], extraInput, []byte{byte(i)})
+		*k, _ = reduceScalar((*[32]byte)(hash))
+		*hash = Hash{}
+		R, err := TweakPublicKey(*k)
+		if err != nil {
+			// The nonce is zero, which can only happen with negligible probability.
+			return nil, nil, errors.Wrap(err, "failed generating the nonce")
+		}
+		serialized, err := R.Serialize()
+		if err != nil {
+			return nil, nil, err
+		}
+		copy(publicNonce[i*SerializedECDSAPublicKeySize:], serialized[:])
+	}
+	return secretNonce, publicNonce, nil
+}
+
+// MuSigNonceAgg sums the signers' public nonces into the aggregate nonce of the session, with BIP-327's NonceAgg.
+// It's an error if a public nonce isn't two valid compressed points.
+func MuSigNonceAgg(nonces []*MuSigPublicNonce) (*MuSigAggregateNonce, error) {
+	if len(nonces) == 0 {
+		return nil, errors.New("can't aggregate an empty list of nonces")
+	}
+	aggregateNonce := &MuSigAggregateNonce{}
+	ones := make([][32]byte, len(nonces))
+	points := make([]*ECDSAPublicKey, len(nonces))
+	for half := 0; half < 2; half++ {
+		for i, nonce := range nonces {
+			if nonce == nil {
+				return nil, errors.Errorf("the public nonce at index %d is nil", i)
+			}
+			var err error
+			points[i], err = DeserializeECDSAPubKeyCompressedOnly(nonce[half*SerializedECDSAPublicKeySize : (half+1)*SerializedECDSAPublicKeySize])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid public nonce at index %d", i)
+			}
+			ones[i] = [32]byte{31: 1}
+		}
+		sum, isInfinity, err := multiScalarMultInternal(nil, ones, points)
+		if err != nil {
+			return nil, err
+		}
+		// The point at infinity stays as 33 zero bytes.
+		if !isInfinity {
+			serialized, err := sum.Serialize()
+			if err != nil {
+				return nil, err
+			}
+			copy(aggregateNonce[half*SerializedECDSAPublicKeySize:], serialized[:])
+		}
+	}
+	return aggregateNonce, nil
+}
+
+// NewMuSigSession starts the second round of signing the hashed message by the signers of keyAgg, after the nonce exchange.
+// It computes BIP-327's session values: the nonce coefficient b, the final nonce `R = R1 + b*R2` and the BIP-340 challenge e.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func NewMuSigSession(keyAgg *MuSigKeyAggContext, aggregateNonce *MuSigAggregateNonce, msg *Hash) (*MuSigSession, error) {
+	if keyAgg == nil || aggregateNonce == nil || msg == nil {
+		return nil, errors.New("the key aggregation context, aggregate nonce and message can't be nil")
+	}
+	return newMuSigSession(keyAgg, aggregateNonce, msg[:])
+}
+
+// newMuSigSession is BIP-327's GetSessionValues for a message of any length, without tweaks.
+func newMuSigSession(keyAgg *MuSigKeyAggContext, aggregateNonce *MuSigAggregateNonce, msg []byte) (*MuSigSession, error) {
+	serializedAggregated, err := keyAgg.AggregatedPublicKey().Serialize()
+	if err != nil {
+		return nil, err
+	}
+	session := &MuSigSession{keyAgg: keyAgg}
+	session.nonceCoefficient, _ = reduceScalar((*[32]byte)(TaggedHash(musigNonceCoefficientTag,
+		aggregateNonce[:], serializedAggregated[:], msg)))
+
+	var scalars [][32]byte
+	var points []*ECDSAPublicKey
+	for half, scalar := range [][32]byte{{31: 1}, session.nonceCoefficient} {
+		serialized := aggregateNonce[half*SerializedECDSAPublicKeySize : (half+1)*SerializedECDSAPublicKeySize]
+		if bytes.Equal(serialized, make([]byte, SerializedECDSAPublicKeySize)) {
+			continue
+		}
+		point, err := DeserializeECDSAPubKeyCompressedOnly(serialized)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid aggregate nonce")
+		}
+		scalars = append(scalars, scalar)
+		points = append(points, point)
+	}
+	isInfinity := len(points) == 0
+	var finalNonce *ECDSAPublicKey
+	if !isInfinity {
+		finalNonce, isInfinity, err = multiScalarMultInternal(nil, scalars, points)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if isInfinity {
+		// The final nonce can only be infinity if a signer is malicious, BIP-327 replaces it with the generator then.
+		finalNonce, err = TweakPublicKey([32]byte{31: 1})
+		if err != nil {
+			return nil, err
+		}
+	}
+	session.finalNonce = *finalNonce
+
+	serializedNonce, err := session.finalNonce.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	session.challenge, _ = reduceScalar((*[32]byte)(TaggedHash("BIP0340/challenge", serializedNonce[1:], serializedAggregated[:], msg)))
+	return session, nil
+}
+
+// MuSigPartialSign creates the keypair's partial signature in the session with BIP-327's Sign, using up the secret nonce.
+// The keypair has to be one of the session's signers, and the secret nonce has to be the keypair's.
+// The secret nonce is erased even if this fails, so it can never sign twice.
+func MuSigPartialSign(secretNonce *MuSigSecretNonce, key *SchnorrKeyPair, session *MuSigSession) ([32]byte, error) {
+	if secretNonce == nil || session == nil {
+		return [32]byte{}, errors.New("the secret nonce and session can't be nil")
+	}
+	secretKey, _, err := musigSignerKey(key)
+	if err != nil {
+		secretNonce.erase()
+		return [32]byte{}, err
+	}
+	defer func() { secretKey = [32]byte{} }()
+	return musigPartialSign(secretNonce, secretKey, session)
+}
+
+// musigPartialSign is BIP-327's Sign with any secret key, without tweaks.
+func musigPartialSign(secretNonce *MuSigSecretNonce, secretKey [32]byte, session *MuSigSession) ([32]byte, error) {
+	if secretNonce.used {
+		return [32]byte{}, errors.New("the secret nonce was already used")
+	}
+	k1, k2 := secretNonce.k1, secretNonce.k2
+	defer func() {
+		k1, k2 = [32]byte{}, [32]byte{}
+	}()
+	secretNonce.erase()
+	for _, k := range []*[32]byte{&k1, &k2} {
+		if _, overflowed := reduceScalar(k); overflowed || *k == [32]byte{} {
+			return [32]byte{}, errors.New("the secret nonce is out of range")
+		}
+	}
+	if !hasEvenY(&session.finalNonce) {
+		k1, k2 = ScalarNegate(k1), ScalarNegate(k2)
+	}
+	privateKey, err := DeserializeECDSAPrivateKey((*SerializedPrivateKey)(&secretKey))
+	if err != nil {
+		return [32]byte{}, err
+	}
+	defer func() { privateKey.privateKey = [32]byte{} }()
+	publicKey, err := privateKey.ECDSAPublicKey()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if *serializedPublicKey != secretNonce.publicKey {
+		return [32]byte{}, errors.New("the secret nonce wasn't generated for this key")
+	}
+	coefficient, ok := session.keyAgg.coefficient(serializedPublicKey)
+	if !ok {
+		return [32]byte{}, errors.New("the signer's public key isn't one of the session's public keys")
+	}
+	d := secretKey
+	defer func() { d = [32]byte{} }()
+	if !hasEvenY(&session.keyAgg.aggregated) {
+		d = ScalarNegate(d)
+	}
+	partialSig := ScalarAdd(ScalarAdd(k1, ScalarMul(session.nonceCoefficient, k2)), ScalarMul(ScalarMul(session.challenge, coefficient), d))
+	return partialSig, nil
+}
+
+// MuSigPartialSigVerify returns true if the partial signature is valid for the signer's x-only public key and public nonce
+// in the session, with BIP-327's PartialSigVerify, so a signer that sent a bad partial signature can be identified.
+func MuSigPartialSigVerify(partialSig [32]byte, publicNonce *MuSigPublicNonce, pubkey *SchnorrPublicKey, session *MuSigSession) bool {
+	if publicNonce == nil || pubkey == nil || session == nil {
+		return false
+	}
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		return false
+	}
+	publicKey := SerializedECDSAPublicKey{0x02}
+	copy(publicKey[1:], serialized[:])
+	return musigPartialSigVerify(partialSig, publicNonce, &publicKey, session)
+}
+
+// musigPartialSigVerify is BIP-327's PartialSigVerifyInternal with any compressed public key, without tweaks.
+func musigPartialSigVerify(partialSig [32]byte, publicNonce *MuSigPublicNonce, publicKey *SerializedECDSAPublicKey, session *MuSigSession) bool {
+	if _, overflowed := reduceScalar(&partialSig); overflowed {
+		return false
+	}
+	R1, err := DeserializeECDSAPubKeyCompressedOnly(publicNonce[:SerializedECDSAPublicKeySize])
+	if err != nil {
+		return false
+	}
+	R2, err := DeserializeECDSAPubKeyCompressedOnly(publicNonce[SerializedECDSAPublicKeySize:])
+	if err != nil {
+		return false
+	}
+	P, err := DeserializeECDSAPubKeyCompressedOnly(publicKey[:])
+	if err != nil {
+		return false
+	}
+	coefficient, ok := session.keyAgg.coefficient(publicKey)
+	if !ok {
+		return false
+	}
+	// `s*G == ±(R1 + b*R2) + e*a*g*P`, where the signs match the negations of the nonce and the key in musigPartialSign.
+	nonceSign := [32]byte{31: 1}
+	if !hasEvenY(&session.finalNonce) {
+		nonceSign = ScalarNegate(nonceSign)
+	}
+	keyCoefficient := ScalarMul(session.challenge, coefficient)
+	if !hasEvenY(&session.keyAgg.aggregated) {
+		keyCoefficient = ScalarNegate(keyCoefficient)
+	}
+	expected, isInfinity, err := multiScalarMultInternal(nil,
+		[][32]byte{nonceSign, ScalarMul(nonceSign, session.nonceCoefficient), keyCoefficient}, []*ECDSAPublicKey{R1, R2, P})
+	if err != nil {
+		return false
+	}
+	actual, err := TweakPublicKey(partialSig)
+	if err != nil {
+		// The partial signature is zero.
+		return isInfinity
+	}
+	return !isInfinity && actual.IsEqual(expected)
+}
+
+// MuSigPartialSigAgg sums the partial signatures of all the signers into a BIP-340 signature `R || sum(s_i)`,
+// which verifies against the aggregated public key of the session with SchnorrVerify. It's BIP-327's PartialSigAgg.
+// Notice: this doesn't verify the partial signatures, use MuSigPartialSigVerify to find out which signer misbehaved.
+func MuSigPartialSigAgg(partialSigs [][32]byte, session *MuSigSession) (*SchnorrSignature, error) {
+	if session == nil {
+		return nil, errors.New("the session can't be nil")
+	}
+	if len(partialSigs) == 0 {
+		return nil, errors.New("can't aggregate an empty list of partial signatures")
+	}
+	s := [32]byte{}
+	for i := range partialSigs {
+		if _, overflowed := reduceScalar(&partialSigs[i]); overflowed {
+			return nil, errors.Errorf("the partial signature at index %d is bigger than the group order", i)
+		}
+		s = ScalarAdd(s, partialSigs[i])
+	}
+	serializedNonce, err := session.finalNonce.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	var r [32]byte
+	copy(r[:], serializedNonce[1:])
+	return SchnorrSignatureFromRS(r, s), nil
+}
+
+// erase zeroes the secret nonce and marks it as used.
+func (secretNonce *MuSigSecretNonce) erase() {
+	secretNonce.k1 = [32]byte{}
+	secretNonce.k2 = [32]byte{}
+	secretNonce.used = true
+}
+
+// musigSignerKey returns the keypair's private key of its even Y point and that point compressed,
+// which is the key BIP-327 signs with for the keypair's x-only public key.
+func musigSignerKey(key *SchnorrKeyPair) (secretKey [32]byte, publicKey *SerializedECDSAPublicKey, err error) {
+	if key == nil || !key.init {
+		return [32]byte{}, nil, errors.WithStack(errNonInitializedKey)
+	}
+	pubkey, isOdd, err := key.schnorrPublicKeyInternal()
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	publicKey = &SerializedECDSAPublicKey{0x02}
+	copy(publicKey[1:], serialized[:])
+	privateKey := key.SerializePrivateKey()
+	secretKey = *privateKey
+	*privateKey = SerializedPrivateKey{}
+	if isOdd {
+		secretKey = ScalarNegate(secretKey)
+	}
+	return secretKey, publicKey, nil
+}
+
+// hasEvenY returns true if the point has an even Y coordinate.
+func hasEvenY(point *ECDSAPublicKey) bool {
+	serialized, err := point.Serialize()
+	if err != nil {
+		panic("failed serializing an initialized point. Should never happen")
+	}
+	return serialized[0] == 0x02
+}
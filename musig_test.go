@@ -0,0 +1,490 @@
+package secp256k1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// The test vectors in this file are from BIP-327 (as shipped with btcd's musig2 package, btcec/v2 v2.3.4).
+// They use compressed public keys with any Y, so they go through the internal functions, the public API
+// lifts x-only keys to even Y and is tested end to end in TestMuSigSign.
+
+func musigTestPublicKeys(t *testing.T, hexKeys []string) []SerializedECDSAPublicKey {
+	keys := make([]SerializedECDSAPublicKey, len(hexKeys))
+	for i, key := range hexKeys {
+		if copy(keys[i][:], decodeHex(key)) != SerializedECDSAPublicKeySize {
+			t.Fatalf("Test vector key %d has the wrong size", i)
+		}
+	}
+	return keys
+}
+
+func musigTestPick(keys []SerializedECDSAPublicKey, indices []int) []SerializedECDSAPublicKey {
+	picked := make([]SerializedECDSAPublicKey, len(indices))
+	for i, index := range indices {
+		picked[i] = keys[index]
+	}
+	return picked
+}
+
+func musigTestPublicNonce(s string) *MuSigPublicNonce {
+	nonce := &MuSigPublicNonce{}
+	copy(nonce[:], decodeHex(s))
+	return nonce
+}
+
+func musigTestAggregateNonce(s string) *MuSigAggregateNonce {
+	nonce := &MuSigAggregateNonce{}
+	copy(nonce[:], decodeHex(s))
+	return nonce
+}
+
+func TestMuSigKeyAggVectors(t *testing.T) {
+	pubkeys := musigTestPublicKeys(t, []string{
+		"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"03DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"023590A94E768F8E1815C2F24B4D80A8E3149316C3518CE7B7AD338368D038CA66",
+		"020000000000000000000000000000000000000000000000000000000000000005",
+		"02FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+		"04F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+	})
+	tests := []struct {
+		indices  []int
+		expected string
+	}{
+		{[]int{0, 1, 2}, "90539EEDE565F5D054F32CC0C220126889ED1E5D193BAF15AEF344FE59D4610C"},
+		{[]int{2, 1, 0}, "6204DE8B083426DC6EAF9502D27024D53FC826BF7D2012148A0575435DF54B2B"},
+		{[]int{0, 0, 0}, "B436E3BAD62B8CD409969A224731C193D051162D8C5AE8B109306127DA3AA935"},
+		{[]int{0, 0, 1, 1}, "69BC22BFA5D106306E48A20679DE1D7389386124D07571D0D872686028C26A3E"},
+	}
+	for i, test := range tests {
+		keyAgg, err := newMuSigKeyAggContext(musigTestPick(pubkeys, test.indices))
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		serialized, err := keyAgg.AggregatedPublicKey().Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(serialized[:], decodeHex(test.expected)) {
+			t.Errorf("Test %d: expected '%s', got '%s'", i, test.expected, serialized)
+		}
+	}
+	for _, indices := range [][]int{{0, 3}, {0, 4}, {5, 0}} {
+		if _, err := newMuSigKeyAggContext(musigTestPick(pubkeys, indices)); err == nil {
+			t.Errorf("Expected an invalid public key error for the keys %v", indices)
+		}
+	}
+}
+
+func TestMuSigNonceGenVectors(t *testing.T) {
+	hexOrNil := func(s *string) []byte {
+		if s == nil {
+			return nil
+		}
+		return decodeHex(*s)
+	}
+	str := func(s string) *string { return &s }
+	sk := str("0202020202020202020202020202020202020202020202020202020202020202")
+	aggpk := str("0707070707070707070707070707070707070707070707070707070707070707")
+	extra := str("0808080808080808080808080808080808080808080808080808080808080808")
+	tests := []struct {
+		sk, pk, aggpk, msg, extraIn *string
+		expected                    string
+	}{
+		{sk, str("024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766"), aggpk,
+			str("0101010101010101010101010101010101010101010101010101010101010101"), extra,
+			"227243DCB40EF2A13A981DB188FA433717B506BDFA14B1AE47D5DC027C9C3B9EF2370B2AD206E724243215137C86365699361126991E6FEC816845F837BDDAC3"},
+		{sk, str("024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766"), aggpk, str(""), extra,
+			"CD0F47FE471D6788FF3243F47345EA0A179AEF69476BE8348322EF39C2723318870C2065AFB52DEDF02BF4FDBF6D2F442E608692F50C2374C08FFFE57042A61C"},
+		{sk, str("024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766"), aggpk,
+			str("2626262626262626262626262626262626262626262626262626262626262626262626262626"), extra,
+			"011F8BC60EF061DEEF4D72A0A87200D9994B3F0CD9867910085C38D5366E3E6B9FF03BC0124E56B24069E91EC3F162378983F194E8BD0ED89BE3059649EAE262"},
+		{nil, str("02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"), nil, nil, nil,
+			"890E83616A3BC4640AB9B6374F21C81FF89CDDDBAFAA7475AE2A102A92E3EDB29FD7E874E23342813A60D9646948242646B7951CA046B4B36D7D6078506D3C94"},
+	}
+	for i, test := range tests {
+		pk := musigTestPublicKeys(t, []string{*test.pk})[0]
+		secretNonce, publicNonce, err := musigNonceGen([32]byte{}, hexOrNil(test.sk), &pk, hexOrNil(test.aggpk), hexOrNil(test.msg), hexOrNil(test.extraIn))
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		expected := decodeHex(test.expected)
+		if !bytes.Equal(secretNonce.k1[:], expected[:32]) || !bytes.Equal(secretNonce.k2[:], expected[32:]) {
+			t.Errorf("Test %d: expected the secret nonce '%s', got '%x%x'", i, test.expected, secretNonce.k1, secretNonce.k2)
+		}
+		if secretNonce.publicKey != pk {
+			t.Errorf("Test %d: expected the secret nonce to be bound to '%s', got '%s'", i, pk, secretNonce.publicKey)
+		}
+		for j, k := range [][32]byte{secretNonce.k1, secretNonce.k2} {
+			R, err := TweakPublicKey(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			serialized, err := R.Serialize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(serialized[:], publicNonce[j*SerializedECDSAPublicKeySize:(j+1)*SerializedECDSAPublicKeySize]) {
+				t.Errorf("Test %d: public nonce %d doesn't match the secret nonce", i, j)
+			}
+		}
+	}
+}
+
+func TestMuSigNonceAggVectors(t *testing.T) {
+	pnonces := []string{
+		"020151C80F435648DF67A22B749CD798CE54E0321D034B92B709B567D60A42E66603BA47FBC1834437B3212E89A84D8425E7BF12E0245D98262268EBDCB385D50641",
+		"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60248C264CDD57D3C24D79990B0F865674EB62A0F9018277A95011B41BFC193B833",
+		"020151C80F435648DF67A22B749CD798CE54E0321D034B92B709B567D60A42E6660279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+		"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60379BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+		"04FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60248C264CDD57D3C24D79990B0F865674EB62A0F9018277A95011B41BFC193B833",
+		"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60248C264CDD57D3C24D79990B0F865674EB62A0F9018277A95011B41BFC193B831",
+		"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A602FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+	}
+	pick := func(indices []int) []*MuSigPublicNonce {
+		nonces := make([]*MuSigPublicNonce, len(indices))
+		for i, index := range indices {
+			nonces[i] = musigTestPublicNonce(pnonces[index])
+		}
+		return nonces
+	}
+	tests := []struct {
+		indices  []int
+		expected string
+	}{
+		{[]int{0, 1}, "035FE1873B4F2967F52FEA4A06AD5A8ECCBE9D0FD73068012C894E2E87CCB5804B024725377345BDE0E9C33AF3C43C0A29A9249F2F2956FA8CFEB55C8573D0262DC8"},
+		// The second halves sum to the point at infinity, which is serialized as 33 zero bytes.
+		{[]int{2, 3}, "035FE1873B4F2967F52FEA4A06AD5A8ECCBE9D0FD73068012C894E2E87CCB5804B000000000000000000000000000000000000000000000000000000000000000000"},
+	}
+	for i, test := range tests {
+		aggregateNonce, err := MuSigNonceAgg(pick(test.indices))
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		if !bytes.Equal(aggregateNonce[:], decodeHex(test.expected)) {
+			t.Errorf("Test %d: expected '%s', got '%s'", i, test.expected, aggregateNonce)
+		}
+	}
+	for _, indices := range [][]int{{0, 4}, {5, 1}, {6, 1}} {
+		if _, err := MuSigNonceAgg(pick(indices)); err == nil {
+			t.Errorf("Expected an invalid public nonce error for the nonces %v", indices)
+		}
+	}
+	if _, err := MuSigNonceAgg(nil); err == nil {
+		t.Errorf("Expected an error for an empty list of nonces")
+	}
+}
+
+func TestMuSigSignVerifyVectors(t *testing.T) {
+	var sk [32]byte
+	copy(sk[:], decodeHex("7FB9E0E687ADA1EEBF7ECFE2F21E73EBDB51A7D450948DFE8D76D7F2D1007671"))
+	pubkeys := musigTestPublicKeys(t, []string{
+		"03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9",
+		"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA661",
+		"020000000000000000000000000000000000000000000000000000000000000007",
+	})
+	secretNonces := []string{
+		"508B81A611F100A6B2B6B29656590898AF488BCF2E1F55CF22E5CFB84421FE61FA27FD49B1D50085B481285E1CA205D55C82CC1B31FF5CD54A489829355901F7",
+		"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+	}
+	secretNonce := func(index int) *MuSigSecretNonce {
+		nonce := &MuSigSecretNonce{publicKey: pubkeys[0]}
+		k := decodeHex(secretNonces[index])
+		copy(nonce.k1[:], k[:32])
+		copy(nonce.k2[:], k[32:])
+		return nonce
+	}
+	pnonces := []string{
+		"0337C87821AFD50A8644D820A8F3E02E499C931865C2360FB43D0A0D20DAFE07EA0287BF891D2A6DEAEBADC909352AA9405D1428C15F4B75F04DAE642A95C2548480",
+		"0279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F817980279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+		"032DE2662628C90B03F5E720284EB52FF7D71F4284F627B68A853D78C78E1FFE9303E4C5524E83FFE1493B9077CF1CA6BEB2090C93D930321071AD40B2F44E599046",
+		"0237C87821AFD50A8644D820A8F3E02E499C931865C2360FB43D0A0D20DAFE07EA0387BF891D2A6DEAEBADC909352AA9405D1428C15F4B75F04DAE642A95C2548480",
+		"0200000000000000000000000000000000000000000000000000000000000000090000000000000000000000000000000000000000000000000000000000000000",
+	}
+	aggnonces := []string{
+		"028465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD61037496A3CC86926D452CAFCFD55D25972CA1675D549310DE296BFF42F72EEEA8C9",
+		"000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		"048465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD61037496A3CC86926D452CAFCFD55D25972CA1675D549310DE296BFF42F72EEEA8C9",
+		"028465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD61020000000000000000000000000000000000000000000000000000000000000009",
+		"028465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD6102FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+	}
+	msg := decodeHex("F95466D086770E689964664219266FE5ED215C92AE20BAB5C9D79ADDDDF3C0CF")
+	session := func(keyIndices []int, aggnonceIndex int) (*MuSigSession, error) {
+		keyAgg, err := newMuSigKeyAggContext(musigTestPick(pubkeys, keyIndices))
+		if err != nil {
+			return nil, err
+		}
+		return newMuSigSession(keyAgg, musigTestAggregateNonce(aggnonces[aggnonceIndex]), msg)
+	}
+	toArray := func(s string) [32]byte {
+		var array [32]byte
+		copy(array[:], decodeHex(s))
+		return array
+	}
+
+	valid := []struct {
+		keyIndices, nonceIndices []int
+		aggnonceIndex            int
+		signerIndex              int
+		expected                 string
+	}{
+		{[]int{0, 1, 2}, []int{0, 1, 2}, 0, 0, "012ABBCB52B3016AC03AD82395A1A415C48B93DEF78718E62A7A90052FE224FB"},
+		{[]int{1, 0, 2}, []int{1, 0, 2}, 0, 1, "9FF2F7AAA856150CC8819254218D3ADEEB0535269051897724F9DB3789513A52"},
+		{[]int{1, 2, 0}, []int{1, 2, 0}, 0, 2, "FA23C359F6FAC4E7796BB93BC9F0532A95468C539BA20FF86D7C76ED92227900"},
+		// Both halves of the aggregate nonce are the point at infinity.
+		{[]int{0, 1}, []int{0, 3}, 1, 0, "AE386064B26105404798F75DE2EB9AF5EDA5387B064B83D049CB7C5E08879531"},
+	}
+	for i, test := range valid {
+		publicNonces := make([]*MuSigPublicNonce, len(test.nonceIndices))
+		for j, index := range test.nonceIndices {
+			publicNonces[j] = musigTestPublicNonce(pnonces[index])
+		}
+		aggregateNonce, err := MuSigNonceAgg(publicNonces)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		if !bytes.Equal(aggregateNonce[:], decodeHex(aggnonces[test.aggnonceIndex])) {
+			t.Fatalf("Test %d: expected the aggregate nonce '%s', got '%s'", i, aggnonces[test.aggnonceIndex], aggregateNonce)
+		}
+		s, err := session(test.keyIndices, test.aggnonceIndex)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		nonce := secretNonce(0)
+		partialSig, err := musigPartialSign(nonce, sk, s)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		if partialSig != toArray(test.expected) {
+			t.Errorf("Test %d: expected the partial signature '%s', got '%x'", i, test.expected, partialSig)
+		}
+		if !musigPartialSigVerify(partialSig, publicNonces[test.signerIndex], &pubkeys[0], s) {
+			t.Errorf("Test %d: expected the partial signature to verify", i)
+		}
+		if _, err := musigPartialSign(nonce, sk, s); err == nil {
+			t.Errorf("Test %d: expected an error signing twice with the same secret nonce", i)
+		}
+	}
+
+	signErrors := []struct {
+		keyIndices       []int
+		aggnonceIndex    int
+		secretNonceIndex int
+	}{
+		{[]int{1, 2}, 0, 0},    // The signer's public key isn't in the list.
+		{[]int{1, 0, 3}, 0, 0}, // Signer 2 has an invalid public key.
+		{[]int{1, 2, 0}, 2, 0}, // The aggregate nonce has the wrong tag, 0x04, in the first half.
+		{[]int{1, 2, 0}, 3, 0}, // The second half of the aggregate nonce isn't an X coordinate.
+		{[]int{1, 2, 0}, 4, 0}, // The second half of the aggregate nonce exceeds the field size.
+		{[]int{0, 1, 2}, 0, 1}, // The secret nonce is zero, which may indicate nonce reuse.
+	}
+	for i, test := range signErrors {
+		// The invalid public keys and aggregate nonces are already rejected when creating the session.
+		s, err := session(test.keyIndices, test.aggnonceIndex)
+		if err != nil {
+			continue
+		}
+		if _, err := musigPartialSign(secretNonce(test.secretNonceIndex), sk, s); err == nil {
+			t.Errorf("Sign error test %d: expected an error", i)
+		}
+	}
+
+	verifyFails := []struct {
+		sig          string
+		keyIndices   []int
+		nonceIndices []int
+		signerIndex  int
+	}{
+		// The negation of the valid signature.
+		{"97AC833ADCB1AFA42EBF9E0725616F3C9A0D5B614F6FE283CEAAA37A8FFAF406", []int{0, 1, 2}, []int{0, 1, 2}, 0},
+		// The wrong signer.
+		{"68537CC5234E505BD14061F8DA9E90C220A181855FD8BDB7F127BB12403B4D3B", []int{0, 1, 2}, []int{0, 1, 2}, 1},
+		// The signature exceeds the group order.
+		{"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", []int{0, 1, 2}, []int{0, 1, 2}, 0},
+		// Signer 0 has an invalid public nonce.
+		{"68537CC5234E505BD14061F8DA9E90C220A181855FD8BDB7F127BB12403B4D3B", []int{0, 1, 2}, []int{4, 1, 2}, 0},
+		// Signer 0 has an invalid public key.
+		{"68537CC5234E505BD14061F8DA9E90C220A181855FD8BDB7F127BB12403B4D3B", []int{3, 1, 2}, []int{0, 1, 2}, 0},
+	}
+	for i, test := range verifyFails {
+		s, err := session(test.keyIndices, 0)
+		if err != nil {
+			continue
+		}
+		signerKey := pubkeys[test.keyIndices[test.signerIndex]]
+		if musigPartialSigVerify(toArray(test.sig), musigTestPublicNonce(pnonces[test.nonceIndices[test.signerIndex]]), &signerKey, s) {
+			t.Errorf("Verify fail test %d: expected the partial signature to be invalid", i)
+		}
+	}
+}
+
+func TestMuSigPartialSigAggVectors(t *testing.T) {
+	pubkeys := musigTestPublicKeys(t, []string{
+		"03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9",
+		"02D2DC6F5DF7C56ACF38C7FA0AE7A759AE30E19B37359DFDE015872324C7EF6E05",
+		"03C7FB101D97FF930ACD0C6760852EF64E69083DE0B06AC6335724754BB4B0522C",
+	})
+	msg := decodeHex("599C67EA410D005B9DA90817CF03ED3B1C868E4DA4EDF00A5880B0082C237869")
+	tests := []struct {
+		keyIndices []int
+		aggnonce   string
+		psigs      []string
+		expected   string
+	}{
+		{[]int{0, 1}, "0341432722C5CD0268D829C702CF0D1CBCE57033EED201FD335191385227C3210C03D377F2D258B64AADC0E16F26462323D701D286046A2EA93365656AFD9875982B",
+			[]string{"B15D2CD3C3D22B04DAE438CE653F6B4ECF042F42CFDED7C41B64AAF9B4AF53FB", "6193D6AC61B354E9105BBDC8937A3454A6D705B6D57322A5A472A02CE99FCB64"},
+			"041DA22223CE65C92C9A0D6C2CAC828AAF1EEE56304FEC371DDF91EBB2B9EF0912F1038025857FEDEB3FF696F8B99FA4BB2C5812F6095A2E0004EC99CE18DE1E"},
+		{[]int{0, 2}, "0224AFD36C902084058B51B5D36676BBA4DC97C775873768E58822F87FE437D792028CB15929099EEE2F5DAE404CD39357591BA32E9AF4E162B8D3E7CB5EFE31CB20",
+			[]string{"9A87D3B79EC67228CB97878B76049B15DBD05B8158D17B5B9114D3C226887505", "66F82EA90923689B855D36C6B7E032FB9970301481B99E01CDB4D6AC7C347A15"},
+			"1069B67EC3D2F3C7C08291ACCB17A9C9B8F2819A52EB5DF8726E17E7D6B52E9F01800260A7E9DAC450F4BE522DE4CE12BA91AEAF2B4279219EF74BE1D286ADD9"},
+	}
+	for i, test := range tests {
+		keyAgg, err := newMuSigKeyAggContext(musigTestPick(pubkeys, test.keyIndices))
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		session, err := newMuSigSession(keyAgg, musigTestAggregateNonce(test.aggnonce), msg)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		partialSigs := make([][32]byte, len(test.psigs))
+		for j, psig := range test.psigs {
+			copy(partialSigs[j][:], decodeHex(psig))
+		}
+		signature, err := MuSigPartialSigAgg(partialSigs, session)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		if !bytes.Equal(signature.Serialize()[:], decodeHex(test.expected)) {
+			t.Errorf("Test %d: expected the signature '%s', got '%s'", i, test.expected, signature)
+		}
+		var hash Hash
+		copy(hash[:], msg)
+		if !keyAgg.AggregatedPublicKey().SchnorrVerify(&hash, signature) {
+			t.Errorf("Test %d: expected the aggregated signature to verify", i)
+		}
+		overflowing := append([][32]byte{}, partialSigs...)
+		copy(overflowing[1][:], decodeHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141"))
+		if _, err := MuSigPartialSigAgg(overflowing, session); err == nil {
+			t.Errorf("Test %d: expected an error for a partial signature exceeding the group order", i)
+		}
+	}
+}
+
+func TestMuSigSign(t *testing.T) {
+	r := rand.New(rand.NewSource(501))
+	keypairs := make([]*SchnorrKeyPair, 3)
+	pubkeys := make([]*SchnorrPublicKey, len(keypairs))
+	for i := range keypairs {
+		var err error
+		keypairs[i] = mustSchnorrKeyPair(t, r)
+		pubkeys[i], err = keypairs[i].SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for l := 0; l < loopsN/10; l++ {
+		msg := Hash(*fastGenerateTweak(t, r))
+		// Every signer can aggregate the keys in their own order.
+		shuffled := append([]*SchnorrPublicKey{}, pubkeys...)
+		r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		keyAgg, err := NewMuSigKeyAggContext(shuffled)
+		if err != nil {
+			t.Fatal(err)
+		}
+		aggregated, err := MusigAggregatePublicKeys(pubkeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !aggregated.IsEqual(keyAgg.AggregatedPublicKey()) {
+			t.Fatalf("Expected NewMuSigKeyAggContext to match MusigAggregatePublicKeys")
+		}
+
+		secretNonces := make([]*MuSigSecretNonce, len(keypairs))
+		publicNonces := make([]*MuSigPublicNonce, len(keypairs))
+		for i, keypair := range keypairs {
+			var extraInput []byte
+			if i == 1 {
+				extraInput = []byte("session id")
+			}
+			secretNonces[i], publicNonces[i], err = MuSigNonceGen(keypair, keyAgg, &msg, extraInput)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		aggregateNonce, err := MuSigNonceAgg(publicNonces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, err := NewMuSigSession(keyAgg, aggregateNonce, &msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		partialSigs := make([][32]byte, len(keypairs))
+		for i, keypair := range keypairs {
+			partialSigs[i], err = MuSigPartialSign(secretNonces[i], keypair, session)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !MuSigPartialSigVerify(partialSigs[i], publicNonces[i], pubkeys[i], session) {
+				t.Fatalf("Expected the partial signature of signer %d to verify", i)
+			}
+			if MuSigPartialSigVerify(partialSigs[i], publicNonces[(i+1)%len(keypairs)], pubkeys[i], session) {
+				t.Fatalf("Expected the partial signature of signer %d to not verify with another signer's nonce", i)
+			}
+			if _, err := MuSigPartialSign(secretNonces[i], keypair, session); err == nil {
+				t.Fatalf("Expected an error reusing the secret nonce of signer %d", i)
+			}
+		}
+		signature, err := MuSigPartialSigAgg(partialSigs, session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !aggregated.SchnorrVerify(&msg, signature) {
+			t.Fatalf("Expected the aggregated signature to verify")
+		}
+		partialSigs[0] = ScalarAdd(partialSigs[0], [32]byte{31: 1})
+		signature, err = MuSigPartialSigAgg(partialSigs, session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if aggregated.SchnorrVerify(&msg, signature) {
+			t.Fatalf("Expected a signature with a bad partial signature to not verify")
+		}
+	}
+
+	// A secret nonce only signs with the key it was generated for, and a signer has to be in the session.
+	keyAgg, err := NewMuSigKeyAggContext(pubkeys[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Hash{1}
+	secretNonce, publicNonce, err := MuSigNonceGen(keypairs[0], nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aggregateNonce, err := MuSigNonceAgg([]*MuSigPublicNonce{publicNonce, publicNonce})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := NewMuSigSession(keyAgg, aggregateNonce, &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MuSigPartialSign(secretNonce, keypairs[1], session); err == nil {
+		t.Errorf("Expected an error signing with another key's secret nonce")
+	}
+	secretNonce, _, err = MuSigNonceGen(keypairs[2], nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MuSigPartialSign(secretNonce, keypairs[2], session); err == nil {
+		t.Errorf("Expected an error signing by a key that isn't in the session")
+	}
+	if _, _, err := MuSigNonceGen(&SchnorrKeyPair{}, nil, nil, nil); err == nil {
+		t.Errorf("Expected an error generating a nonce for an uninitialized keypair")
+	}
+}
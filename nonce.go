@@ -0,0 +1,74 @@
+package secp256k1
+
+import "github.com/pkg/errors"
+
+// nonceTag is the tagged hash tag used by GenerateNonce
+const nonceTag = "go-secp256k1/nonce"
+
+// GenerateNonce deterministically derives a secret nonce and its public nonce point `R = nonceSecret*Generator`
+// for interactive signing protocols, as `TaggedHash("go-secp256k1/nonce", sessionID || private key || x-only public key || msg)`.
+// The same inputs always give the same nonce, so it can be recomputed in the second round instead of stored.
+// Notice: sessionID *MUST* be unique for every signing session. Reusing it with the same key and message in
+// a session where the other parties' nonces differ leaks the private key.
+func GenerateNonce(sessionID [32]byte, key *SchnorrKeyPair, msg *Hash) (nonceSecret [32]byte, noncePoint *ECDSAPublicKey, err error) {
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	serializedPubkey, err := pubkey.Serialize()
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	privateKey := key.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+
+	// The chance of the hash not being a valid scalar is less than 2^-127, the counter only exists so this can't loop forever.
+	for counter := 0; counter < 256; counter++ {
+		nonce := TaggedHash(nonceTag, sessionID[:], privateKey[:], serializedPubkey[:], msg[:], []byte{byte(counter)})
+		noncePrivateKey, err := DeserializeECDSAPrivateKey((*SerializedPrivateKey)(nonce))
+		if err != nil {
+			continue
+		}
+		noncePoint, err = noncePrivateKey.ECDSAPublicKey()
+		if err != nil {
+			return [32]byte{}, nil, err
+		}
+		return *noncePrivateKey.Serialize(), noncePoint, nil
+	}
+	return [32]byte{}, nil, errors.New("failed generating a valid nonce. Should never happen")
+}
+
+// SchnorrRespond computes the signer's response `s = k + e*x % Group Order` of a schnorr signing protocol,
+// where k is the secret nonce, e is the challenge (e.g. from SchnorrChallenge) and x is the private key.
+// Like BIP-340 x is negated if the key's public key has an odd Y, so the response verifies against the x-only public key.
+// Notice: the nonce is used as is, if the nonce point has an odd Y the caller has to negate the nonce (see ScalarNegate) first.
+func SchnorrRespond(nonceSecret [32]byte, challenge [32]byte, key *SchnorrKeyPair) ([32]byte, error) {
+	_, isOdd, err := key.schnorrPublicKeyInternal()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if _, overflowed := reduceScalar(&nonceSecret); overflowed || nonceSecret == [32]byte{} {
+		return [32]byte{}, errors.New("the nonce has to be bigger than zero and smaller than the group order")
+	}
+	if _, overflowed := reduceScalar(&challenge); overflowed {
+		return [32]byte{}, errors.New("the challenge has to be smaller than the group order")
+	}
+	privateKey := key.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	x := [32]byte(*privateKey)
+	if isOdd {
+		x = ScalarNegate(x)
+	}
+	response := ScalarAdd(nonceSecret, ScalarMul(challenge, x))
+	x = [32]byte{}
+	return response, nil
+}
+
+// AggregateNonces sums the participants' public nonce points (e.g. from GenerateNonce) into the aggregate nonce point R
+// of a multi-party signing session. The sum doesn't depend on the order of the nonces.
+// It's an error if there are no nonces, a nonce isn't initialized, or the sum is the point at infinity,
+// which can only happen by chance with negligible probability or if a participant chose its nonce to cancel the others.
+// Notice: as with a single nonce, if R has an odd Y every participant has to negate its nonce secret before responding.
+func AggregateNonces(nonces []*ECDSAPublicKey) (*ECDSAPublicKey, error) {
+	return combineECDSAPublicKeys(nonces)
+}
@@ -0,0 +1,28 @@
+package secp256k1
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// verifyObserver wraps the observer function, because atomic.Value can't store a nil function.
+type verifyObserver struct {
+	fn func(valid bool, dur time.Duration)
+}
+
+// currentVerifyObserver holds the verifyObserver set by SetVerifyObserver
+var currentVerifyObserver atomic.Value
+
+// SetVerifyObserver sets a function that's called after every schnorr and ECDSA signature verification with its result and duration,
+// e.g. for metrics. This covers SchnorrVerify, ECDSAVerify, their Context variants and everything built on them,
+// but not SchnorrVerifyRaw and batch verification. Passing nil removes the observer.
+// It's safe to call concurrently with verifications. The observer is called on the verifying goroutine, so it should be fast.
+func SetVerifyObserver(fn func(valid bool, dur time.Duration)) {
+	currentVerifyObserver.Store(verifyObserver{fn: fn})
+}
+
+// loadVerifyObserver returns the observer set by SetVerifyObserver, or nil if there's none.
+func loadVerifyObserver() func(valid bool, dur time.Duration) {
+	observer, _ := currentVerifyObserver.Load().(verifyObserver)
+	return observer.fn
+}
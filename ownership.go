@@ -0,0 +1,15 @@
+package secp256k1
+
+// ownershipTag is the tagged hash tag used for ownership proofs, so a proof can't be confused with a signature over a message
+const ownershipTag = "go-secp256k1/ownership"
+
+// ProveOwnership proves knowledge of the private key by signing `TaggedHash("go-secp256k1/ownership", challenge)`.
+// The challenge should be a fresh random value chosen by the verifier, so an old proof can't be replayed.
+func (key *SchnorrKeyPair) ProveOwnership(challenge [32]byte) (*SchnorrSignature, error) {
+	return key.SchnorrSign(TaggedHash(ownershipTag, challenge[:]))
+}
+
+// VerifyOwnership verifies a proof created by ProveOwnership over the same challenge.
+func (key *SchnorrPublicKey) VerifyOwnership(challenge [32]byte, proof *SchnorrSignature) bool {
+	return key.SchnorrVerify(TaggedHash(ownershipTag, challenge[:]), proof)
+}
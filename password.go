@@ -0,0 +1,52 @@
+package secp256k1
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+// The Argon2id parameters used by KeyFromPassword, the second recommended option of RFC 9106:
+// 3 passes over 64 MiB of memory with 4 lanes.
+const (
+	passwordKeyTime    = 3
+	passwordKeyMemory  = 64 * 1024 // In KiB
+	passwordKeyThreads = 4
+	// passwordKeyLength is 64 bytes, so reducing it modulo the group order has a negligible bias.
+	passwordKeyLength = 64
+
+	// MinPasswordSaltSize is the minimum length in bytes of the salt for KeyFromPassword
+	MinPasswordSaltSize = 16
+)
+
+// scalarTwoTo256 is `2^256 % Group Order` in big endian, used to reduce 64 byte numbers modulo the group order.
+var scalarTwoTo256 = [32]byte{
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+	0x45, 0x51, 0x23, 0x19, 0x50, 0xb7, 0x5f, 0xc4, 0x40, 0x2d, 0xa1, 0x73, 0x2f, 0xc9, 0xbe, 0xbf,
+}
+
+// KeyFromPassword deterministically derives a keypair from a password and a salt with Argon2id
+// (3 passes, 64 MiB, 4 lanes), reducing the 64 byte output modulo the group order.
+// The salt has to be at least MinPasswordSaltSize bytes, and should be random and unique per user, it's needed to derive the key again.
+// Notice: a key derived from a password is only as strong as the password. Argon2id slows down guessing, but a password
+// that a person can remember can still be guessed, and anyone who guesses it has the key with no way of revoking it.
+// Prefer GenerateSchnorrKeyPair (kept encrypted with the password) whenever possible.
+func KeyFromPassword(password, salt []byte) (*SchnorrKeyPair, error) {
+	if len(password) == 0 {
+		return nil, errors.New("the password can't be empty")
+	}
+	if len(salt) < MinPasswordSaltSize {
+		return nil, errors.Errorf("the salt has to be at least %d bytes, instead got %d", MinPasswordSaltSize, len(salt))
+	}
+	derived := argon2.IDKey(password, salt, passwordKeyTime, passwordKeyMemory, passwordKeyThreads, passwordKeyLength)
+	var high, low [32]byte
+	copy(high[:], derived[:32])
+	copy(low[:], derived[32:])
+	privateKey := SerializedPrivateKey(ScalarAdd(ScalarMul(high, scalarTwoTo256), low))
+	defer func() {
+		for i := range derived {
+			derived[i] = 0
+		}
+		high, low, privateKey = [32]byte{}, [32]byte{}, SerializedPrivateKey{}
+	}()
+	return DeserializeSchnorrPrivateKey(&privateKey)
+}
@@ -0,0 +1,76 @@
+package secp256k1
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// pedersenDST is the hash to curve domain separation tag of the Pedersen commitment generator H
+const pedersenDST = "go-secp256k1/pedersen_secp256k1_XMD:SHA-256_SSWU_RO_"
+
+var (
+	pedersenGeneratorH     *ECDSAPublicKey
+	pedersenGeneratorHOnce sync.Once
+)
+
+// PedersenGeneratorH returns the second generator H of Pedersen commitments: HashToCurve of the compressed serialization of
+// the curve's generator G, with the tag "go-secp256k1/pedersen_secp256k1_XMD:SHA-256_SSWU_RO_".
+// Nobody knows the discrete logarithm of H with respect to G, which is what makes the commitments binding.
+// Notice: this isn't the H of libsecp256k1-zkp or Elements, commitments made with them aren't compatible.
+func PedersenGeneratorH() *ECDSAPublicKey {
+	pedersenGeneratorHOnce.Do(func() {
+		compressedG := make([]byte, SerializedECDSAPublicKeySize)
+		// G's Y coordinate is even
+		compressedG[0] = 0x02
+		copy(compressedG[1:], secp256k1Generator[1:1+32])
+		var err error
+		pedersenGeneratorH, err = HashToCurve(compressedG, []byte(pedersenDST))
+		if err != nil {
+			panic("failed hashing the Pedersen generator. Should never happen")
+		}
+	})
+	point := *pedersenGeneratorH
+	return &point
+}
+
+// PedersenCommit commits to value with the blinding factor: `C = value*H + blinding*G`, where H is PedersenGeneratorH.
+// Both scalars have to be smaller than the group order. The blinding factor *MUST* be random and kept secret,
+// otherwise the commitment reveals the value. It's an error if the commitment is the point at infinity.
+// Commitments are additively homomorphic, see AddCommitments.
+func PedersenCommit(value, blinding [32]byte) (*ECDSAPublicKey, error) {
+	if _, overflowed := reduceScalar(&value); overflowed {
+		return nil, errors.New("the value is bigger than the group order")
+	}
+	if _, overflowed := reduceScalar(&blinding); overflowed {
+		return nil, errors.New("the blinding factor is bigger than the group order")
+	}
+	// The scalars are secret, so this uses the constant time multiplications instead of the variable time multiScalarMultInternal.
+	var zero [32]byte
+	points := make([]*ECDSAPublicKey, 0, 2)
+	if value != zero {
+		valueH := PedersenGeneratorH()
+		err := valueH.Mul(value)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, valueH)
+	}
+	if blinding != zero {
+		blindingG, err := TweakPublicKey(blinding)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, blindingG)
+	}
+	if len(points) == 0 {
+		return nil, errors.New("the commitment is the point at infinity")
+	}
+	return combineECDSAPublicKeys(points)
+}
+
+// AddCommitments returns a + b, which is the commitment to the sum of the values with the sum of the blinding factors.
+// It's an error if the sum is the point at infinity.
+func AddCommitments(a, b *ECDSAPublicKey) (*ECDSAPublicKey, error) {
+	return combineECDSAPublicKeys([]*ECDSAPublicKey{a, b})
+}
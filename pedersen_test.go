@@ -0,0 +1,73 @@
+package secp256k1
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPedersenCommit(t *testing.T) {
+	r := rand.New(rand.NewSource(216))
+	H := PedersenGeneratorH()
+	G, err := TweakPublicKey([32]byte{31: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if H.IsEqual(G) {
+		t.Fatalf("Expected H to be different from G")
+	}
+	// Callers can't modify the shared generator.
+	err = H.Negate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if H.IsEqual(PedersenGeneratorH()) {
+		t.Fatalf("Expected PedersenGeneratorH to return a copy")
+	}
+
+	for i := 0; i < loopsN; i++ {
+		v1, r1 := *fastGenerateTweak(t, r), *fastGenerateTweak(t, r)
+		v2, r2 := *fastGenerateTweak(t, r), *fastGenerateTweak(t, r)
+		c1, err := PedersenCommit(v1, r1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c2, err := PedersenCommit(v2, r2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum, err := AddCommitments(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := PedersenCommit(ScalarAdd(v1, v2), ScalarAdd(r1, r2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sum.IsEqual(expected) {
+			t.Fatalf("Expected the sum of the commitments to commit to the sum of the values")
+		}
+	}
+
+	blinding := *fastGenerateTweak(t, r)
+	zeroValue, err := PedersenCommit([32]byte{}, blinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blindingG, err := TweakPublicKey(blinding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !zeroValue.IsEqual(blindingG) {
+		t.Fatalf("Expected a commitment to zero to be blinding*G")
+	}
+	if _, err := PedersenCommit([32]byte{}, [32]byte{}); err == nil {
+		t.Fatalf("Expected an error for a commitment to zero with a zero blinding factor")
+	}
+	order := intTo32Bytes(Secp256k1Order)
+	if _, err := PedersenCommit(order, blinding); err == nil {
+		t.Fatalf("Expected an error for a value that isn't smaller than the group order")
+	}
+	if _, err := PedersenCommit(blinding, order); err == nil {
+		t.Fatalf("Expected an error for a blinding factor that isn't smaller than the group order")
+	}
+}
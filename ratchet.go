@@ -0,0 +1,79 @@
+package secp256k1
+
+import "github.com/pkg/errors"
+
+// ratchetTag is the tagged hash tag of the ratchet tweak
+const ratchetTag = "go-secp256k1/ratchet"
+
+// Ratchet derives the keypair of the next epoch of a key chain, by adding the tweak
+// `TaggedHash("go-secp256k1/ratchet", x-only public key)` to a copy of the keypair.
+// The old keypair isn't modified, the caller is expected to zero it once the next one is stored.
+// Because the tweak only depends on the public key, a verifier holding the starting public key can follow the chain
+// with SchnorrPublicKey.Ratchet, e.g. with FindRatchetEpoch.
+// Notice: for the same reason this does *NOT* protect past epochs if the current private key leaks,
+// anyone who knows it and the previous public key can subtract the tweak and walk the chain backwards.
+// It only protects against a leak of keys that were already zeroed, with the chain's public keys kept private.
+func (key *SchnorrKeyPair) Ratchet() (*SchnorrKeyPair, error) {
+	if !key.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	tweak, err := ratchetTweak(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	next := key.clone()
+	err = next.Add(tweak)
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// Ratchet returns the public key of the next epoch of a key chain, it matches SchnorrKeyPair.Ratchet.
+// The public key itself isn't modified.
+func (key *SchnorrPublicKey) Ratchet() (*SchnorrPublicKey, error) {
+	tweak, err := ratchetTweak(key)
+	if err != nil {
+		return nil, err
+	}
+	next := *key
+	err = next.Add(tweak)
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// FindRatchetEpoch walks the key chain starting at start (epoch 0) and returns the first epoch, up to and including maxEpoch,
+// whose public key verifies the signature on hash. It returns false if no such epoch is found or start isn't initialized.
+func FindRatchetEpoch(start *SchnorrPublicKey, hash *Hash, signature *SchnorrSignature, maxEpoch uint64) (uint64, bool) {
+	if start == nil || !start.init {
+		return 0, false
+	}
+	pubkey := start
+	for epoch := uint64(0); ; epoch++ {
+		if pubkey.SchnorrVerify(hash, signature) {
+			return epoch, true
+		}
+		if epoch == maxEpoch {
+			return 0, false
+		}
+		var err error
+		pubkey, err = pubkey.Ratchet()
+		if err != nil {
+			return 0, false
+		}
+	}
+}
+
+func ratchetTweak(pubkey *SchnorrPublicKey) ([32]byte, error) {
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return *TaggedHash(ratchetTag, serialized[:]), nil
+}
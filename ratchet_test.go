@@ -0,0 +1,55 @@
+package secp256k1
+
+import "testing"
+
+func TestRatchet(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{0x52, 0x61, 0x74}
+
+	pubkey := start
+	var signature *SchnorrSignature
+	for epoch := 0; epoch < 5; epoch++ {
+		next, err := key.Ratchet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubkey, err = pubkey.Ratchet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nextPubKey, err := next.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !nextPubKey.IsEqual(pubkey) {
+			t.Fatalf("epoch %d: the public ratchet %s doesn't match the keypair's %s", epoch+1, pubkey, nextPubKey)
+		}
+		key = next
+		if epoch == 2 {
+			signature = mustSchnorrSign(t, key, &hash)
+		}
+	}
+
+	epoch, ok := FindRatchetEpoch(start, &hash, signature, 10)
+	if !ok || epoch != 3 {
+		t.Fatalf("Expected the signature to be found at epoch 3, got %d, %t", epoch, ok)
+	}
+	if _, ok := FindRatchetEpoch(start, &hash, signature, 2); ok {
+		t.Fatalf("Expected the signature not to be found before epoch 3")
+	}
+	if _, ok := FindRatchetEpoch(&SchnorrPublicKey{}, &hash, signature, 10); ok {
+		t.Fatalf("Expected an uninitialized start key to not be found")
+	}
+
+	_, err = (&SchnorrKeyPair{}).Ratchet()
+	if err == nil {
+		t.Fatalf("Expected an error when ratcheting an uninitialized keypair")
+	}
+}
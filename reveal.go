@@ -0,0 +1,40 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCommitmentMismatch is returned by VerifyRevealed when the revealed public key isn't the committed one.
+var ErrCommitmentMismatch = errors.New("the revealed public key doesn't match the commitment")
+
+// PublicKeyCommitment returns the commitment to a public key for a commit-reveal protocol: `SHA256(x-only public key)`.
+func PublicKeyCommitment(pubkey *SchnorrPublicKey) (*Hash, error) {
+	if pubkey == nil {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	commitment := Hash(sha256.Sum256(serialized[:]))
+	return &commitment, nil
+}
+
+// VerifyRevealed verifies a schnorr signature by a revealed public key, after checking that the key is the one committed to
+// with PublicKeyCommitment. If it isn't ErrCommitmentMismatch is returned.
+// Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+func VerifyRevealed(commitment *Hash, revealedPubKey *SchnorrPublicKey, hash *Hash, sig *SchnorrSignature) (bool, error) {
+	if commitment == nil {
+		return false, errors.New("the commitment is nil")
+	}
+	revealedCommitment, err := PublicKeyCommitment(revealedPubKey)
+	if err != nil {
+		return false, err
+	}
+	if !revealedCommitment.IsEqual(commitment) {
+		return false, errors.WithStack(ErrCommitmentMismatch)
+	}
+	return revealedPubKey.SchnorrVerify(hash, sig), nil
+}
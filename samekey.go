@@ -0,0 +1,110 @@
+package secp256k1
+
+import (
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// sameKeyTag is the tagged hash tag of the message a SameKeyProof signs
+	sameKeyTag = "go-secp256k1/same-key"
+
+	// SerializedSameKeyProofSize defines the length in bytes of SerializedSameKeyProof
+	SerializedSameKeyProofSize = SerializedSchnorrSignatureSize
+)
+
+// SameKeyProof is a proof that an ECDSA public key and a schnorr x-only public key are backed by the same private key,
+// and that whoever created the proof knows it, e.g. to publicly link an old ECDSA key to a new schnorr key during a migration.
+// The struct itself is an opaque data type that should only be created via the supplied methods.
+type SameKeyProof struct {
+	signature SchnorrSignature
+}
+
+// SerializedSameKeyProof is a byte array representing the storage representation of a SameKeyProof
+type SerializedSameKeyProof [SerializedSameKeyProofSize]byte
+
+// String returns the SerializedSameKeyProof as the hexadecimal string
+func (serialized SerializedSameKeyProof) String() string {
+	return hex.EncodeToString(serialized[:])
+}
+
+// String returns the SameKeyProof as the hexadecimal string
+func (proof SameKeyProof) String() string {
+	return proof.Serialize().String()
+}
+
+// Serialize returns a 64 byte serialized proof
+func (proof *SameKeyProof) Serialize() *SerializedSameKeyProof {
+	serialized := SerializedSameKeyProof(*proof.signature.Serialize())
+	return &serialized
+}
+
+// DeserializeSameKeyProof deserializes a 64 byte serialized proof
+func DeserializeSameKeyProof(serialized *SerializedSameKeyProof) *SameKeyProof {
+	return &SameKeyProof{signature: *DeserializeSchnorrSignature((*SerializedSchnorrSignature)(serialized))}
+}
+
+// ProveSameKey proves that the keypair's full public key (as an ECDSA key) and its x-only public key share the private key.
+// An ECDSA key and an x-only key have the same private key (up to its sign, which BIP-340 ignores) exactly when they have
+// the same x coordinate, which VerifySameKey checks publicly, so the proof is a schnorr signature by the key over
+// `TaggedHash("go-secp256k1/same-key", compressed ECDSA key || x-only key)`, showing knowledge of the private key of both
+// encodings without revealing it.
+func (key *SchnorrKeyPair) ProveSameKey() (*SameKeyProof, error) {
+	if !key.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	serializedPrivateKey := key.SerializePrivateKey()
+	defer func() { *serializedPrivateKey = SerializedPrivateKey{} }()
+	privateKey, err := DeserializeECDSAPrivateKey(serializedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { privateKey.privateKey = [32]byte{} }()
+	ecdsaPubKey, err := privateKey.ECDSAPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	schnorrPubKey, err := key.SchnorrPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := sameKeyHash(ecdsaPubKey, schnorrPubKey)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := key.SchnorrSign(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &SameKeyProof{signature: *signature}, nil
+}
+
+// VerifySameKey returns true if the ECDSA public key and the x-only public key have the same x coordinate (so the same private key,
+// up to its sign) and the proof shows knowledge of that private key. see ProveSameKey
+func VerifySameKey(ecdsaPub *ECDSAPublicKey, schnorrPub *SchnorrPublicKey, proof *SameKeyProof) bool {
+	if ecdsaPub == nil || !ecdsaPub.init || schnorrPub == nil || !schnorrPub.init || proof == nil {
+		return false
+	}
+	converted, err := ecdsaPub.ToSchnorr()
+	if err != nil || !converted.IsEqual(schnorrPub) {
+		return false
+	}
+	hash, err := sameKeyHash(ecdsaPub, schnorrPub)
+	if err != nil {
+		return false
+	}
+	return schnorrPub.SchnorrVerify(hash, &proof.signature)
+}
+
+func sameKeyHash(ecdsaPub *ECDSAPublicKey, schnorrPub *SchnorrPublicKey) (*Hash, error) {
+	serializedECDSA, err := ecdsaPub.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	serializedSchnorr, err := schnorrPub.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return TaggedHash(sameKeyTag, serializedECDSA[:], serializedSchnorr[:]), nil
+}
@@ -0,0 +1,103 @@
+package secp256k1
+
+// #include "./depend/secp256k1/include/secp256k1.h"
+// #include "./depend/secp256k1/src/util.h"
+// #include "./depend/secp256k1/src/scalar_impl.h"
+//
+// static int scalar_reduce(unsigned char *output32, const unsigned char *input32) {
+//     secp256k1_scalar scalar;
+//     int overflow;
+//     secp256k1_scalar_set_b32(&scalar, input32, &overflow);
+//     secp256k1_scalar_get_b32(output32, &scalar);
+//     return overflow;
+// }
+//
+// static void scalar_add(unsigned char *output32, const unsigned char *a32, const unsigned char *b32) {
+//     secp256k1_scalar a, b;
+//     secp256k1_scalar_set_b32(&a, a32, NULL);
+//     secp256k1_scalar_set_b32(&b, b32, NULL);
+//     secp256k1_scalar_add(&a, &a, &b);
+//     secp256k1_scalar_get_b32(output32, &a);
+// }
+//
+// static void scalar_mul(unsigned char *output32, const unsigned char *a32, const unsigned char *b32) {
+//     secp256k1_scalar a, b;
+//     secp256k1_scalar_set_b32(&a, a32, NULL);
+//     secp256k1_scalar_set_b32(&b, b32, NULL);
+//     secp256k1_scalar_mul(&a, &a, &b);
+//     secp256k1_scalar_get_b32(output32, &a);
+// }
+//
+// static void scalar_negate(unsigned char *output32, const unsigned char *a32) {
+//     secp256k1_scalar a;
+//     secp256k1_scalar_set_b32(&a, a32, NULL);
+//     secp256k1_scalar_negate(&a, &a);
+//     secp256k1_scalar_get_b32(output32, &a);
+// }
+//
+// static void scalar_inverse(unsigned char *output32, const unsigned char *a32) {
+//     secp256k1_scalar a;
+//     secp256k1_scalar_set_b32(&a, a32, NULL);
+//     secp256k1_scalar_inverse(&a, &a);
+//     secp256k1_scalar_get_b32(output32, &a);
+// }
+import "C"
+import "bytes"
+
+// HalfOrder is half of the secp256k1 group order (rounded down), in big endian. i.e. `(N-1)/2`
+// ECDSA signatures with S bigger than HalfOrder are "high-S", see IsScalarLowHalf.
+var HalfOrder = [32]byte{
+	0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x5d, 0x57, 0x6e, 0x73, 0x57, 0xa4, 0x50, 0x1d, 0xdf, 0xe9, 0x2f, 0x46, 0x68, 0x1b, 0x20, 0xa0,
+}
+
+// IsScalarLowHalf returns true if the big endian scalar is smaller than or equal to HalfOrder.
+// It returns false for numbers bigger than HalfOrder, including ones that are not reduced modulo the group order.
+func IsScalarLowHalf(s [32]byte) bool {
+	return bytes.Compare(s[:], HalfOrder[:]) <= 0
+}
+
+// reduceScalar reduces a 32 byte big endian number modulo the group order.
+// It returns true if the input was bigger than or equal to the group order.
+func reduceScalar(scalar *[32]byte) (reduced [32]byte, overflowed bool) {
+	cPtrReduced := (*C.uchar)(&reduced[0])
+	cPtrScalar := (*C.uchar)(&scalar[0])
+	overflow := C.scalar_reduce(cPtrReduced, cPtrScalar)
+	return reduced, overflow != 0
+}
+
+// ScalarAdd returns `a + b % Group Order`.
+// The raw scalar functions are meant for advanced protocols that need intermediate values keys can't hold:
+// the inputs are reduced modulo the group order and the result can be zero, nothing is validated.
+// They run in constant time.
+func ScalarAdd(a, b [32]byte) (result [32]byte) {
+	C.scalar_add((*C.uchar)(&result[0]), (*C.uchar)(&a[0]), (*C.uchar)(&b[0]))
+	return result
+}
+
+// ScalarMul returns `a * b % Group Order`. see ScalarAdd
+func ScalarMul(a, b [32]byte) (result [32]byte) {
+	C.scalar_mul((*C.uchar)(&result[0]), (*C.uchar)(&a[0]), (*C.uchar)(&b[0]))
+	return result
+}
+
+// ScalarNegate returns `-a % Group Order`. see ScalarAdd
+func ScalarNegate(a [32]byte) (result [32]byte) {
+	C.scalar_negate((*C.uchar)(&result[0]), (*C.uchar)(&a[0]))
+	return result
+}
+
+// ScalarInverse returns the modular inverse `a^-1 % Group Order`, the inverse of zero is zero. see ScalarAdd
+func ScalarInverse(a [32]byte) (result [32]byte) {
+	C.scalar_inverse((*C.uchar)(&result[0]), (*C.uchar)(&a[0]))
+	return result
+}
+
+// ReverseScalar reverses the byte order of a 32 byte scalar, converting between big endian and little endian.
+// All the scalars in this package are big endian.
+func ReverseScalar(s [32]byte) [32]byte {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	return s
+}
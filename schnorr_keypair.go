@@ -2,21 +2,28 @@ package secp256k1
 
 // #include "./depend/secp256k1/include/secp256k1_extrakeys.h"
 // #include "./depend/secp256k1/include/secp256k1_schnorrsig.h"
+// int go_secp256k1_keypair_restore(secp256k1_keypair *keypair, const unsigned char *seckey32, const unsigned char *pubkey64);
 import "C"
 import "C"
 import (
-	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"github.com/pkg/errors"
+	"io"
+	"math/big"
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
 // SchnorrKeyPair is a type representing a pair of Secp256k1 private and public keys.
 // This can be used to create Schnorr signatures
 type SchnorrKeyPair struct {
-	keypair C.secp256k1_keypair
-	init    bool
+	// signCount is accessed atomically, it's the first field so it's 64-bit aligned on 32-bit platforms.
+	signCount uint64
+	keypair   C.secp256k1_keypair
+	init      bool
 }
 
 // SerializedPrivateKey is a byte array representing the storage representation of a SchnorrKeyPair
@@ -58,22 +65,78 @@ func DeserializeSchnorrPrivateKeyFromSlice(data []byte) (key *SchnorrKeyPair, er
 	return DeserializeSchnorrPrivateKey(serializedKey)
 }
 
+// PrivateKeyFromUint64 returns a SchnorrKeyPair with the private key v, encoded as a big endian 32 byte scalar.
+// This is mostly useful for testing edge cases, it fails for zero.
+func PrivateKeyFromUint64(v uint64) (*SchnorrKeyPair, error) {
+	serialized := SerializedPrivateKey{}
+	binary.BigEndian.PutUint64(serialized[SerializedPrivateKeySize-8:], v)
+	return DeserializeSchnorrPrivateKey(&serialized)
+}
+
+// PrivateKeyFromBigInt returns a SchnorrKeyPair with the private key v.
+// It fails if v isn't in the range (0, Group Order), it does not reduce v modulo the group order.
+func PrivateKeyFromBigInt(v *big.Int) (*SchnorrKeyPair, error) {
+	if v.Sign() < 0 || v.BitLen() > SerializedPrivateKeySize*8 {
+		return nil, errors.New("invalid SchnorrKeyPair (negative or bigger than the group order)")
+	}
+	serialized := SerializedPrivateKey{}
+	b := v.Bytes()
+	copy(serialized[SerializedPrivateKeySize-len(b):], b)
+	return DeserializeSchnorrPrivateKey(&serialized)
+}
+
 // GenerateSchnorrKeyPair generates a random valid private key from `crypto/rand`
 func GenerateSchnorrKeyPair() (key *SchnorrKeyPair, err error) {
-	rawKey := SerializedPrivateKey{}
-	for {
-		n, err := rand.Read(rawKey[:])
-		if err != nil {
-			return nil, err
-		}
-		if n != len(rawKey) {
-			panic("The standard library promises that this should never happen")
-		}
-		key, err = DeserializeSchnorrPrivateKey(&rawKey)
-		if err == nil {
-			return key, nil
-		}
+	rawKey, err := generatePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { *rawKey = SerializedPrivateKey{} }()
+	return DeserializeSchnorrPrivateKey(rawKey)
+}
+
+// SerializedFullKeyPairSize is the length in bytes of a keypair serialized with SerializeFull
+const SerializedFullKeyPairSize = 96
+
+// SerializeFull serializes the keypair as `private key || X || Y` where X and Y are the coordinates of the full public key,
+// so DeserializeFullUnchecked can restore it without deriving the public key again.
+// It returns all zeros if the keypair isn't initialized.
+func (key *SchnorrKeyPair) SerializeFull() [SerializedFullKeyPairSize]byte {
+	serialized := [SerializedFullKeyPairSize]byte{}
+	if !key.init {
+		return serialized
+	}
+	pubkey := ECDSAPublicKey{init: true}
+	ret := C.secp256k1_keypair_pub(C.secp256k1_context_no_precomp, &pubkey.pubkey, &key.keypair)
+	if ret != 1 {
+		panic("failed getting the public key from the keypair. Should never happen (upstream promise to return 1)")
 	}
+	uncompressed, err := pubkey.serializeUncompressed()
+	if err != nil {
+		panic("failed serializing an initialized public key. Should never happen")
+	}
+	privateKey := key.SerializePrivateKey()
+	copy(serialized[:32], privateKey[:])
+	copy(serialized[32:], uncompressed[1:])
+	*privateKey = SerializedPrivateKey{}
+	return serialized
+}
+
+// DeserializeFullUnchecked restores a keypair serialized with SerializeFull.
+// It checks that the private key is valid and that the public key is on the curve, but it *doesn't* check that
+// the public key matches the private key, checking it means computing sk*G which is exactly the cost this skips.
+// A mismatched keypair produces signatures that don't verify against its own public key, so this should only be used
+// with data from a trusted store that this package serialized.
+// Use DeserializeSchnorrPrivateKey if the public key should be derived from scratch.
+func DeserializeFullUnchecked(data *[SerializedFullKeyPairSize]byte) (*SchnorrKeyPair, error) {
+	key := SchnorrKeyPair{init: true}
+	cPtrPrivKey := (*C.uchar)(&data[0])
+	cPtrPubKey := (*C.uchar)(&data[32])
+	ret := C.go_secp256k1_keypair_restore(&key.keypair, cPtrPrivKey, cPtrPubKey)
+	if ret != 1 {
+		return nil, errors.New("invalid serialized keypair, the private key is out of range or the public key isn't on the curve")
+	}
+	return &key, nil
 }
 
 // SerializePrivateKey returns the private key in the keypair.
@@ -101,19 +164,201 @@ func (key *SchnorrKeyPair) Add(tweak [32]byte) error {
 	return nil
 }
 
+// AddReduced is like Add, but first reduces the tweak modulo the group order and returns the reduced tweak that was applied.
+// Add rejects tweaks that are bigger than or equal to the group order, AddReduced accepts them.
+// Notice: BIP-32 and BIP-341 require rejecting such tweaks, so use Add when implementing them.
+func (key *SchnorrKeyPair) AddReduced(tweak [32]byte) (applied [32]byte, err error) {
+	applied, _ = reduceScalar(&tweak)
+	err = key.Add(applied)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return applied, nil
+}
+
+// AddWithParity is like Add, but also returns true if the tweaked public key has an odd Y coordinate.
+// This is the parity bit needed for a taproot control block.
+func (key *SchnorrKeyPair) AddWithParity(tweak [32]byte) (isOdd bool, err error) {
+	err = key.Add(tweak)
+	if err != nil {
+		return false, err
+	}
+	_, isOdd, err = key.schnorrPublicKeyInternal()
+	return isOdd, err
+}
+
+// NormalizeForEvenY negates the private key in place if the keypair's public key has an odd Y coordinate,
+// so the private key is the one of the even Y point BIP-340 signs with, and returns true if it was negated.
+// The x-only public key stays the same. This is the adjustment BIP-341 does to the internal key before tweaking it.
+func (key *SchnorrKeyPair) NormalizeForEvenY() (flipped bool, err error) {
+	_, isOdd, err := key.schnorrPublicKeyInternal()
+	if err != nil || !isOdd {
+		return false, err
+	}
+	privateKey := key.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	cPtrPrivateKey := (*C.uchar)(&privateKey[0])
+	ret := C.secp256k1_ec_seckey_negate(C.secp256k1_context_no_precomp, cPtrPrivateKey)
+	if ret != 1 {
+		panic("Failed Negating the private key. Should never happen")
+	}
+	ret = C.secp256k1_keypair_create(context, &key.keypair, cPtrPrivateKey)
+	if ret != 1 {
+		panic("failed recreating the keypair from a negated valid private key. Should never happen")
+	}
+	return true, nil
+}
+
+// DeriveTagged deterministically derives a child keypair bound to the label, by adding the tweak
+// `TaggedHash(label, xonly public key)` to a copy of the keypair. The keypair itself isn't modified.
+func (key *SchnorrKeyPair) DeriveTagged(label string) (*SchnorrKeyPair, error) {
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	child := key.clone()
+	err = child.Add(*TaggedHash(label, serialized[:]))
+	if err != nil {
+		return nil, err
+	}
+	return &child, nil
+}
+
+// appKeyTag is the tagged hash tag used by DeriveAppKey
+const appKeyTag = "go-secp256k1/appkey"
+
+// DeriveAppKey deterministically derives a child keypair for an application and epoch, by adding the tweak
+// `TaggedHash("go-secp256k1/appkey", appID || epoch as 8 bytes big endian)` to a copy of the keypair.
+// Incrementing the epoch gives a fresh key, an application can forget old epochs but they can always be derived again from the master keypair.
+// Notice: the tweak is public, so anyone who knows a child private key, the appID and the epoch can compute the master private key.
+func (key *SchnorrKeyPair) DeriveAppKey(appID string, epoch uint64) (*SchnorrKeyPair, error) {
+	if !key.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	var serializedEpoch [8]byte
+	binary.BigEndian.PutUint64(serializedEpoch[:], epoch)
+	child := key.clone()
+	err := child.Add(*TaggedHash(appKeyTag, []byte(appID), serializedEpoch[:]))
+	if err != nil {
+		return nil, err
+	}
+	return &child, nil
+}
+
+// viewKeyTag is the tagged hash tag used by DeriveViewKey
+const viewKeyTag = "go-secp256k1/viewkey"
+
+// DeriveViewKey deterministically derives a view keypair from the spend keypair, as the private key
+// `TaggedHash("go-secp256k1/viewkey", spend private key)`. The view key can be given to a scanning service to detect payments.
+// Unlike DeriveTagged the derivation isn't a public tweak, so the spend private key can't be computed from the view private key.
+func (spend *SchnorrKeyPair) DeriveViewKey() (*SchnorrKeyPair, error) {
+	if !spend.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	privateKey := spend.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	viewPrivateKey := TaggedHash(viewKeyTag, privateKey[:])
+	defer func() { *viewPrivateKey = Hash{} }()
+	return DeserializeSchnorrPrivateKey((*SerializedPrivateKey)(viewPrivateKey))
+}
+
+// clone returns a copy of the keypair, without its sign count.
+func (key *SchnorrKeyPair) clone() SchnorrKeyPair {
+	return SchnorrKeyPair{keypair: key.keypair, init: key.init}
+}
+
+// AddPrivateKeys returns a new keypair with the private key `a + b % Group Order`.
+// The full (non x-only) public key of the result is the sum of the full public keys of a and b.
+// This fails if the result is zero.
+func AddPrivateKeys(a, b *SchnorrKeyPair) (*SchnorrKeyPair, error) {
+	return combinePrivateKeys(a, b, false)
+}
+
+// SubPrivateKeys returns a new keypair with the private key `a - b % Group Order`.
+// This fails if the result is zero (i.e. a == b).
+func SubPrivateKeys(a, b *SchnorrKeyPair) (*SchnorrKeyPair, error) {
+	return combinePrivateKeys(a, b, true)
+}
+
+func combinePrivateKeys(a, b *SchnorrKeyPair, negateB bool) (*SchnorrKeyPair, error) {
+	if !a.init || !b.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	result := a.SerializePrivateKey()
+	tweak := b.SerializePrivateKey()
+	defer func() {
+		*result = SerializedPrivateKey{}
+		*tweak = SerializedPrivateKey{}
+	}()
+	cPtrResult := (*C.uchar)(&result[0])
+	cPtrTweak := (*C.uchar)(&tweak[0])
+	if negateB {
+		ret := C.secp256k1_ec_seckey_negate(C.secp256k1_context_no_precomp, cPtrTweak)
+		if ret != 1 {
+			panic("Failed Negating the private key. Should never happen")
+		}
+	}
+	ret := C.secp256k1_ec_seckey_tweak_add(C.secp256k1_context_no_precomp, cPtrResult, cPtrTweak)
+	if ret != 1 {
+		return nil, errors.New("failed combining the private keys, the result is zero")
+	}
+	return DeserializeSchnorrPrivateKey(result)
+}
+
 // SchnorrPublicKey generates a PublicKey for the corresponding private key.
 func (key *SchnorrKeyPair) SchnorrPublicKey() (*SchnorrPublicKey, error) {
 	pubkey, _, err := key.schnorrPublicKeyInternal()
 	return pubkey, err
 }
 
+// Export returns the private key, the x-only public key and whether the full public key's Y coordinate is odd,
+// all derived from a single copy of the keypair so they're consistent with each other.
+// Notice: this doesn't make the keypair safe for concurrent use, mutating it while calling Export is still a data race.
+func (key *SchnorrKeyPair) Export() (*SerializedPrivateKey, *SchnorrPublicKey, bool, error) {
+	snapshot := key.clone()
+	defer func() { snapshot = SchnorrKeyPair{} }()
+	pubkey, isOdd, err := snapshot.schnorrPublicKeyInternal()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return snapshot.SerializePrivateKey(), pubkey, isOdd, nil
+}
+
+// CachedPublicKey returns the x-only public key that the keypair caches next to the private key, or nil if the keypair isn't initialized.
+// It never derives the public key from the private key, so it's cheap. SchnorrPublicKey does the same but also returns an error.
+func (key *SchnorrKeyPair) CachedPublicKey() *SchnorrPublicKey {
+	pubkey, _, err := key.schnorrPublicKeyInternal()
+	if err != nil {
+		return nil
+	}
+	return pubkey
+}
+
+// Matches returns true if pubkey is the x-only public key of the keypair.
+// It returns false if either key isn't initialized.
+func (key *SchnorrKeyPair) Matches(pubkey *SchnorrPublicKey) bool {
+	if pubkey == nil || !pubkey.init {
+		return false
+	}
+	derived, err := key.SchnorrPublicKey()
+	if err != nil {
+		return false
+	}
+	return derived.IsEqual(pubkey)
+}
+
 func (key *SchnorrKeyPair) schnorrPublicKeyInternal() (pubkey *SchnorrPublicKey, wasOdd bool, err error) {
 	if !key.init {
 		return nil, false, errors.WithStack(errNonInitializedKey)
 	}
 	pubkey = &SchnorrPublicKey{init: true}
 	cParity := C.int(42)
-	ret := C.secp256k1_keypair_xonly_pub(context, &pubkey.pubkey, &cParity, &key.keypair)
+	// keypair_xonly_pub only loads the cached public key, there's no point multiplication so no tables are needed.
+	ret := C.secp256k1_keypair_xonly_pub(C.secp256k1_context_no_precomp, &pubkey.pubkey, &cParity, &key.keypair)
 	if ret != 1 {
 		return nil, false, errors.New("the keypair contains invalid data")
 	}
@@ -126,14 +371,142 @@ func (key *SchnorrKeyPair) schnorrPublicKeyInternal() (pubkey *SchnorrPublicKey,
 // Notice: the [32] byte array *MUST* be a hash of a message.
 func (key *SchnorrKeyPair) SchnorrSign(hash *Hash) (*SchnorrSignature, error) {
 	var auxilaryRand [32]byte
-	n, err := rand.Read(auxilaryRand[:])
-	if err != nil || n != len(auxilaryRand) {
+	err := readRandom(auxilaryRand[:])
+	if err != nil {
 		return nil, err
 	}
 	return key.schnorrSignInternal(hash, &auxilaryRand)
 }
 
+// SchnorrSignFixedTime creates a schnorr signature like SchnorrSign, and then sleeps until at least minDuration has passed
+// since it was called, so the time it takes doesn't depend on the signing (errors included) as long as signing is faster than minDuration.
+// This is meant to blunt remote timing measurements of a signing service, the signing itself is already constant time.
+// Notice: the sleep only guarantees a lower bound, scheduling makes the actual duration a bit longer and noisy.
+func (key *SchnorrKeyPair) SchnorrSignFixedTime(hash *Hash, minDuration time.Duration) (*SchnorrSignature, error) {
+	start := time.Now()
+	defer func() {
+		if remaining := minDuration - time.Since(start); remaining > 0 {
+			time.Sleep(remaining)
+		}
+	}()
+	return key.SchnorrSign(hash)
+}
+
+// SchnorrSignWithAuxRand creates a schnorr signature like SchnorrSign, but with caller supplied auxiliary randomness instead of reading `crypto/rand`.
+// auxiliaryRand can be nil, in which case the signature is deterministic (derived only from the private key and the hash).
+// This is allowed by BIP-340 and is still secure against nonce reuse, but it loses the protection that fresh randomness
+// gives against side channel and fault attacks on the nonce derivation, so prefer SchnorrSign when an entropy source is available.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func (key *SchnorrKeyPair) SchnorrSignWithAuxRand(hash *Hash, auxiliaryRand *[32]byte) (*SchnorrSignature, error) {
+	return key.schnorrSignInternal(hash, auxiliaryRand)
+}
+
+// SchnorrSignWithMixedAux creates a schnorr signature like SchnorrSign, but with auxiliary randomness that is the XOR
+// of 32 bytes read from each of the sources (e.g. a hardware RNG and `crypto/rand`), so it's as strong as the strongest source.
+// A source that fails to return 32 bytes is skipped, and if all of them fail (or none were given) an error is returned.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func (key *SchnorrKeyPair) SchnorrSignWithMixedAux(hash *Hash, sources ...io.Reader) (*SchnorrSignature, error) {
+	var auxilaryRand, buf [32]byte
+	succeeded := 0
+	for _, source := range sources {
+		_, err := io.ReadFull(source, buf[:])
+		if err != nil {
+			continue
+		}
+		for i := range auxilaryRand {
+			auxilaryRand[i] ^= buf[i]
+		}
+		succeeded++
+	}
+	if succeeded == 0 {
+		return nil, errors.Errorf("failed reading auxiliary randomness from all %d sources", len(sources))
+	}
+	return key.schnorrSignInternal(hash, &auxilaryRand)
+}
+
+// SchnorrSignBatch creates a schnorr signature for each of the hashes like SchnorrSign,
+// but reads the auxiliary randomness of all the signatures at once.
+// Notice: the [32] byte arrays *MUST* be hashes of messages.
+func (key *SchnorrKeyPair) SchnorrSignBatch(hashes []*Hash) ([]*SchnorrSignature, error) {
+	if !key.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	for i, hash := range hashes {
+		if hash == nil {
+			return nil, errors.Errorf("hash number %d is nil", i)
+		}
+	}
+	auxilaryRand := make([]byte, 32*len(hashes))
+	defer func() {
+		for i := range auxilaryRand {
+			auxilaryRand[i] = 0
+		}
+	}()
+	err := readRandom(auxilaryRand)
+	if err != nil {
+		return nil, err
+	}
+	signatures := make([]*SchnorrSignature, len(hashes))
+	var aux [32]byte
+	for i, hash := range hashes {
+		copy(aux[:], auxilaryRand[32*i:])
+		signatures[i], err = key.schnorrSignInternal(hash, &aux)
+		if err != nil {
+			return nil, err
+		}
+	}
+	aux = [32]byte{}
+	return signatures, nil
+}
+
+// SignAccountable signs the hash and returns the signature together with the x-only public key it was created with,
+// both from the same copy of the keypair. The signature is verified against that public key before it's returned,
+// so the caller only has to check the public key itself (e.g. against an allow-list).
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func (key *SchnorrKeyPair) SignAccountable(hash *Hash) (*SchnorrSignature, *SchnorrPublicKey, error) {
+	snapshot := key.clone()
+	defer func() { snapshot = SchnorrKeyPair{} }()
+	pubkey, err := snapshot.SchnorrPublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := snapshot.SchnorrSign(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	atomic.AddUint64(&key.signCount, 1)
+	if !pubkey.SchnorrVerify(hash, signature) {
+		return nil, nil, errors.New("the signature doesn't verify against the keypair's public key. Should never happen")
+	}
+	return signature, pubkey, nil
+}
+
+// SignAndPublicKey deserializes the private key, signs the hash and returns the signature with the matching public key.
+// The intermediate keypair is zeroed before returning.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func SignAndPublicKey(privateKey *SerializedPrivateKey, hash *Hash) (*SchnorrSignature, *SchnorrPublicKey, error) {
+	keypair, err := DeserializeSchnorrPrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { keypair.keypair = C.secp256k1_keypair{} }()
+
+	signature, err := keypair.SchnorrSign(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return signature, pubkey, nil
+}
+
 func (key *SchnorrKeyPair) schnorrSignInternal(hash *Hash, auxiliaryRand *[32]byte) (*SchnorrSignature, error) {
+	return key.schnorrSignWithContext(context, hash, auxiliaryRand)
+}
+
+func (key *SchnorrKeyPair) schnorrSignWithContext(ctx *C.secp256k1_context, hash *Hash, auxiliaryRand *[32]byte) (*SchnorrSignature, error) {
 	if !key.init {
 		return nil, errors.WithStack(errNonInitializedKey)
 	}
@@ -141,13 +514,20 @@ func (key *SchnorrKeyPair) schnorrSignInternal(hash *Hash, auxiliaryRand *[32]by
 	cPtrSig := (*C.uchar)(&signature.signature[0])
 	cPtrHash := (*C.uchar)(&hash[0])
 	cPtrAux := unsafe.Pointer(auxiliaryRand)
-	ret := C.secp256k1_schnorrsig_sign(context, cPtrSig, cPtrHash, &key.keypair, C.secp256k1_nonce_function_bip340, cPtrAux)
+	ret := C.secp256k1_schnorrsig_sign(ctx, cPtrSig, cPtrHash, &key.keypair, C.secp256k1_nonce_function_bip340, cPtrAux)
 	if ret != 1 {
 		return nil, errors.New("failed Signing. You should call `DeserializeSchnorrPrivateKey` before calling this")
 	}
+	atomic.AddUint64(&key.signCount, 1)
 	return &signature, nil
 }
 
+// SignCount returns the number of signatures created with this keypair, it's safe to call concurrently with signing.
+// The count belongs to the keypair object: it's kept across in place tweaks, and starts at zero for deserialized or derived keypairs.
+func (key *SchnorrKeyPair) SignCount() uint64 {
+	return atomic.LoadUint64(&key.signCount)
+}
+
 func parityBitToBool(parity C.int) bool {
 	switch parity {
 	case 0:
@@ -2,11 +2,26 @@ package secp256k1
 
 // #include "./depend/secp256k1/include/secp256k1_extrakeys.h"
 // #include "./depend/secp256k1/include/secp256k1_schnorrsig.h"
+//
+// static int schnorrsig_verify_raw(const secp256k1_context* ctx, const unsigned char *sig64, const unsigned char *msg32, const unsigned char *pubkey32) {
+//     secp256k1_xonly_pubkey pubkey;
+//     if (!secp256k1_xonly_pubkey_parse(ctx, &pubkey, pubkey32)) {
+//         return 0;
+//     }
+//     return secp256k1_schnorrsig_verify(ctx, sig64, msg32, &pubkey);
+// }
 import "C"
 import (
+	"bufio"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"github.com/pkg/errors"
+	"io"
+	"math/big"
+	"math/bits"
+	"time"
+	"unsafe"
 )
 
 // SerializedSchnorrPublicKeySize defines the length in bytes of a SerializedSchnorrPublicKey
@@ -64,13 +79,199 @@ func (key SchnorrPublicKey) String() string {
 
 // SchnorrVerify verifies a schnorr signature using the public key and the input hashed message.
 // Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+// Signatures with R equal to zero are rejected before reaching libsecp256k1.
 func (key *SchnorrPublicKey) SchnorrVerify(hash *Hash, signature *SchnorrSignature) bool {
+	return key.schnorrVerifyWithContext(context, hash, signature)
+}
+
+// SchnorrVerifyRaw verifies a schnorr signature like SchnorrVerify, but takes the serialized x-only public key,
+// the hash and the signature as arrays directly, so the hot path doesn't need to construct (and allocate) the typed objects.
+// It returns false if the public key is invalid.
+// Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+func SchnorrVerifyRaw(pubkey *[32]byte, hash *[32]byte, sig *[64]byte) bool {
+	var zero [32]byte
+	if *(*[32]byte)(unsafe.Pointer(&sig[0])) == zero {
+		return false
+	}
+	// The public key is parsed in C, so it stays on the C stack instead of escaping to the heap like a Go value passed to C.
+	return C.schnorrsig_verify_raw(context, (*C.uchar)(&sig[0]), (*C.uchar)(&hash[0]), (*C.uchar)(&pubkey[0])) == 1
+}
+
+// SchnorrVerifyTimed is like SchnorrVerify, but also returns how long the verification took.
+// It's meant for performance monitoring, the duration includes the cgo call overhead.
+func (key *SchnorrPublicKey) SchnorrVerifyTimed(hash *Hash, signature *SchnorrSignature) (valid bool, dur time.Duration) {
+	start := time.Now()
+	valid = key.SchnorrVerify(hash, signature)
+	return valid, time.Since(start)
+}
+
+func (key *SchnorrPublicKey) schnorrVerifyWithContext(ctx *C.secp256k1_context, hash *Hash, signature *SchnorrSignature) (valid bool) {
+	if observer := loadVerifyObserver(); observer != nil {
+		start := time.Now()
+		defer func() { observer(valid, time.Since(start)) }()
+	}
+	if signature.hasZeroR() {
+		return false
+	}
 	cPtrHash := (*C.uchar)(&hash[0])
 	cPtrSig := (*C.uchar)(&signature.signature[0])
-	return C.secp256k1_schnorrsig_verify(context, cPtrSig, cPtrHash, &key.pubkey) == 1
+	return C.secp256k1_schnorrsig_verify(ctx, cPtrSig, cPtrHash, &key.pubkey) == 1
+}
+
+// ErrPublicKeyMismatch is returned by VerifyPinned when the claimed public key isn't the pinned one.
+var ErrPublicKeyMismatch = errors.New("the claimed public key doesn't match the pinned public key")
+
+// VerifyPinned verifies a schnorr signature against the pinned public key (key), after checking that the
+// public key claimed by an untrusted message is the same key. If it isn't ErrPublicKeyMismatch is returned.
+// Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+func (key *SchnorrPublicKey) VerifyPinned(hash *Hash, signature *SchnorrSignature, claimedPubKey *SchnorrPublicKey) (bool, error) {
+	if !key.init {
+		return false, errors.WithStack(errNonInitializedKey)
+	}
+	if !key.IsEqual(claimedPubKey) {
+		return false, errors.WithStack(ErrPublicKeyMismatch)
+	}
+	return key.SchnorrVerify(hash, signature), nil
+}
+
+// IsEquivocation returns true if the public key signed two different hashes, i.e. both signatures verify and the hashes aren't equal.
+// Whether the two messages actually conflict is protocol specific, so that check is left to the caller.
+// It's an error if the public key isn't initialized or any of the arguments is nil.
+func IsEquivocation(pubkey *SchnorrPublicKey, hashA *Hash, sigA *SchnorrSignature, hashB *Hash, sigB *SchnorrSignature) (bool, error) {
+	if pubkey == nil || !pubkey.init {
+		return false, errors.WithStack(errNonInitializedKey)
+	}
+	if hashA == nil || sigA == nil || hashB == nil || sigB == nil {
+		return false, errors.New("the hashes and signatures can't be nil")
+	}
+	if hashA.IsEqual(hashB) {
+		return false, nil
+	}
+	return pubkey.SchnorrVerify(hashA, sigA) && pubkey.SchnorrVerify(hashB, sigB), nil
+}
+
+// SchnorrVerifyMany verifies many schnorr signatures over the same hashed message, each against its matching public key.
+// It returns a result for each public key, a nil or uninitialized public key, a nil signature or a public key without
+// a matching signature (if there are fewer signatures than public keys) is reported as invalid.
+// BIP-340 challenges commit to R and P as well as the message, so every signature is still verified separately.
+func SchnorrVerifyMany(pubkeys []*SchnorrPublicKey, hash *Hash, signatures []*SchnorrSignature) []bool {
+	results := make([]bool, len(pubkeys))
+	if hash == nil {
+		return results
+	}
+	for i := range pubkeys {
+		if i >= len(signatures) || pubkeys[i] == nil || !pubkeys[i].init || signatures[i] == nil {
+			continue
+		}
+		results[i] = pubkeys[i].SchnorrVerify(hash, signatures[i])
+	}
+	return results
+}
+
+// schnorrRecordSize is the size of a (public key, hash, signature) record read by VerifyRecordStream and VerifyBatchBuffer
+const schnorrRecordSize = SerializedSchnorrPublicKeySize + HashSize + SerializedSchnorrSignatureSize
+
+// VerifyRecordStream reads records of `x-only public key (32 bytes) || hash (32 bytes) || schnorr signature (64 bytes)` from r until EOF,
+// and verifies each of them. It returns the index of the first record that fails to verify (including invalid public keys),
+// or -1 if all of them are valid. A read error or a truncated last record is returned as an error with an index of -1,
+// since no record has failed to verify.
+func VerifyRecordStream(r io.Reader) (int, error) {
+	reader := bufio.NewReader(r)
+	record := [schnorrRecordSize]byte{}
+	for i := 0; ; i++ {
+		_, err := io.ReadFull(reader, record[:])
+		if err == io.EOF {
+			return -1, nil
+		}
+		if err != nil {
+			return -1, errors.Wrapf(err, "failed reading record %d", i)
+		}
+		pubkey, err := DeserializeSchnorrPubKey(record[:SerializedSchnorrPublicKeySize])
+		if err != nil {
+			return i, nil
+		}
+		hash := Hash{}
+		copy(hash[:], record[SerializedSchnorrPublicKeySize:])
+		signature := SchnorrSignature{}
+		copy(signature.signature[:], record[SerializedSchnorrPublicKeySize+HashSize:])
+		if !pubkey.SchnorrVerify(&hash, &signature) {
+			return i, nil
+		}
+	}
+}
+
+// SerializeBatchForVerify packs the batch into one contiguous buffer of records in the VerifyRecordStream format:
+// `x-only public key (32 bytes) || hash (32 bytes) || schnorr signature (64 bytes)`. VerifyBatchBuffer verifies such a buffer.
+func SerializeBatchForVerify(pubkeys []*SchnorrPublicKey, hashes []*Hash, signatures []*SchnorrSignature) ([]byte, error) {
+	if len(pubkeys) != len(hashes) || len(pubkeys) != len(signatures) {
+		return nil, errors.Errorf("the number of public keys (%d), hashes (%d) and signatures (%d) should be the same",
+			len(pubkeys), len(hashes), len(signatures))
+	}
+	buf := make([]byte, schnorrRecordSize*len(pubkeys))
+	for i := range pubkeys {
+		if pubkeys[i] == nil || hashes[i] == nil || signatures[i] == nil {
+			return nil, errors.Errorf("entry number %d of the batch is nil", i)
+		}
+		serialized, err := pubkeys[i].Serialize()
+		if err != nil {
+			return nil, err
+		}
+		record := buf[i*schnorrRecordSize : (i+1)*schnorrRecordSize]
+		copy(record, serialized[:])
+		copy(record[SerializedSchnorrPublicKeySize:], hashes[i][:])
+		copy(record[SerializedSchnorrPublicKeySize+HashSize:], signatures[i].signature[:])
+	}
+	return buf, nil
+}
+
+// VerifyBatchBuffer verifies every record of a buffer created by SerializeBatchForVerify, and returns a result for each record.
+// A record with an invalid public key is reported as invalid. It's an error if the buffer isn't a whole number of records.
+func VerifyBatchBuffer(buf []byte) ([]bool, error) {
+	if len(buf)%schnorrRecordSize != 0 {
+		return nil, errors.Errorf("the buffer length has to be a multiple of %d, instead got %d", schnorrRecordSize, len(buf))
+	}
+	results := make([]bool, len(buf)/schnorrRecordSize)
+	var pubkey, hash [32]byte
+	var signature [64]byte
+	for i := range results {
+		record := buf[i*schnorrRecordSize : (i+1)*schnorrRecordSize]
+		copy(pubkey[:], record)
+		copy(hash[:], record[SerializedSchnorrPublicKeySize:])
+		copy(signature[:], record[SerializedSchnorrPublicKeySize+HashSize:])
+		results[i] = SchnorrVerifyRaw(&pubkey, &hash, &signature)
+	}
+	return results, nil
+}
+
+var (
+	// ErrXOutOfRange is the reason of an XOnlyParseError when the x coordinate isn't less than the field prime.
+	// This usually means the bytes were truncated or mis-encoded before reaching the parser.
+	ErrXOutOfRange = errors.New("the x coordinate isn't less than the field prime")
+	// ErrXNotOnCurve is the reason of an XOnlyParseError when there's no point on the curve with the x coordinate.
+	ErrXNotOnCurve = errors.New("the x coordinate isn't on the curve")
+)
+
+// XOnlyParseError is returned by DeserializeSchnorrPubKey when the bytes aren't a valid x-only public key.
+// The reason can be compared with errors.Is against ErrXOutOfRange and ErrXNotOnCurve.
+type XOnlyParseError struct {
+	// Data is the x coordinate that failed to parse
+	Data SerializedSchnorrPublicKey
+	// Reason is either ErrXOutOfRange or ErrXNotOnCurve
+	Reason error
+}
+
+// Error implements the error interface.
+func (e *XOnlyParseError) Error() string {
+	return fmt.Sprintf("failed parsing the public key %s: %s", e.Data, e.Reason)
+}
+
+// Unwrap returns the reason, so it can be compared with errors.Is.
+func (e *XOnlyParseError) Unwrap() error {
+	return e.Reason
 }
 
 // DeserializeSchnorrPubKey deserializes a serialized schnorr public key, verifying it's valid.
+// If it isn't the error is an *XOnlyParseError.
 func DeserializeSchnorrPubKey(serializedPubKey []byte) (*SchnorrPublicKey, error) {
 	if len(serializedPubKey) != SerializedSchnorrPublicKeySize {
 		return nil, errors.New(fmt.Sprintf("serializedPubKey has to be %d bytes, instead got :%d", SerializedSchnorrPublicKeySize, len(serializedPubKey)))
@@ -79,11 +280,91 @@ func DeserializeSchnorrPubKey(serializedPubKey []byte) (*SchnorrPublicKey, error
 	cPtr := (*C.uchar)(&serializedPubKey[0])
 	ret := C.secp256k1_xonly_pubkey_parse(C.secp256k1_context_no_precomp, &key.pubkey, cPtr)
 	if ret != 1 {
-		return nil, errors.New("failed parsing the public key")
+		parseErr := &XOnlyParseError{Reason: ErrXNotOnCurve}
+		copy(parseErr.Data[:], serializedPubKey)
+		if new(big.Int).SetBytes(serializedPubKey).Cmp(secp256k1FieldPrime) >= 0 {
+			parseErr.Reason = ErrXOutOfRange
+		}
+		return nil, errors.WithStack(parseErr)
 	}
 	return &key, nil
 }
 
+// ValidateSchnorrPublicKeys parses every serialized x-only public key, and returns the parse error of each key
+// (nil for valid keys, the same length as keys) and whether all the keys are valid.
+// Unlike DeserializeSchnorrPubKey in a loop it doesn't stop at the first bad key, so every malformed entry can be reported.
+// The errors are DeserializeSchnorrPubKey's errors prefixed with the index, so errors.As still finds an XOnlyParseError.
+func ValidateSchnorrPublicKeys(keys [][]byte) ([]error, bool) {
+	errs := make([]error, len(keys))
+	ok := true
+	for i, key := range keys {
+		_, err := DeserializeSchnorrPubKey(key)
+		if err != nil {
+			errs[i] = errors.Wrapf(err, "invalid public key at index %d", i)
+			ok = false
+		}
+	}
+	return errs, ok
+}
+
+// ReadSchnorrPublicKey reads exactly SerializedSchnorrPublicKeySize bytes from r and deserializes them, verifying it's a valid public key.
+// If r returns fewer bytes the error is io.EOF (nothing was read) or io.ErrUnexpectedEOF (a short read).
+func ReadSchnorrPublicKey(r io.Reader) (*SchnorrPublicKey, error) {
+	serialized := SerializedSchnorrPublicKey{}
+	_, err := io.ReadFull(r, serialized[:])
+	if err != nil {
+		return nil, err
+	}
+	return DeserializeSchnorrPubKey(serialized[:])
+}
+
+// WriteTo writes the serialized schnorr public key to w. it implements io.WriterTo
+func (key *SchnorrPublicKey) WriteTo(w io.Writer) (int64, error) {
+	serialized, err := key.Serialize()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(serialized[:])
+	return int64(n), err
+}
+
+// IsValid returns true if the key was created via the supplied methods and holds a valid curve point.
+// libsecp256k1 never produces the point at infinity (tweaks resulting in it fail), so this fails only for zeroed or corrupted keys.
+func (key *SchnorrPublicKey) IsValid() bool {
+	if key == nil || !key.init || key.pubkey == (C.secp256k1_xonly_pubkey{}) {
+		return false
+	}
+	serialized := SerializedSchnorrPublicKey{}
+	cPtr := (*C.uchar)(&serialized[0])
+	ret := C.secp256k1_xonly_pubkey_serialize(C.secp256k1_context_no_precomp, cPtr, &key.pubkey)
+	if ret != 1 {
+		return false
+	}
+	parsed := C.secp256k1_xonly_pubkey{}
+	return C.secp256k1_xonly_pubkey_parse(C.secp256k1_context_no_precomp, &parsed, cPtr) == 1
+}
+
+// LeadingZeroBits returns the number of leading zero bits of the 32 byte x-only serialization, e.g. for scoring vanity keys.
+// It returns 0 if the key isn't initialized.
+func (key *SchnorrPublicKey) LeadingZeroBits() int {
+	serialized, err := key.Serialize()
+	if err != nil {
+		return 0
+	}
+	return leadingZeroBits(serialized[:])
+}
+
+func leadingZeroBits(data []byte) int {
+	count := 0
+	for _, b := range data {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
 // Serialize serializes a schnorr public key
 func (key *SchnorrPublicKey) Serialize() (*SerializedSchnorrPublicKey, error) {
 	if !key.init {
@@ -99,12 +380,172 @@ func (key *SchnorrPublicKey) Serialize() (*SerializedSchnorrPublicKey, error) {
 }
 
 // Add a tweak to the public key by doing `key + tweak*Generator`. this adds it in place.
-// This is meant for creating BIP-32(HD) wallets
+// This is meant for creating BIP-32(HD) wallets, e.g. deriving child public keys in a watch-only wallet.
+// The key is lifted to its even Y point first, so the result is the same as SchnorrKeyPair.Add with the same tweak.
+// It fails without modifying the key if the tweak isn't smaller than the group order or the result is the point at infinity.
 func (key *SchnorrPublicKey) Add(tweak [32]byte) error {
 	_, err := key.addInternal(tweak)
 	return err
 }
 
+// AddWithParity is like Add, but also returns true if the tweaked public key has an odd Y coordinate.
+// This is the parity bit needed for a taproot control block.
+func (key *SchnorrPublicKey) AddWithParity(tweak [32]byte) (isOdd bool, err error) {
+	return key.addInternal(tweak)
+}
+
+// AddSchnorrPublicKeys returns the x-only sum of the public keys, each lifted to the point with an even Y coordinate as in BIP-340.
+// The result doesn't depend on the order of the keys. It fails if the sum is the point at infinity.
+func AddSchnorrPublicKeys(keys ...*SchnorrPublicKey) (*SchnorrPublicKey, error) {
+	fullKeys := make([]*ECDSAPublicKey, len(keys))
+	for i, key := range keys {
+		if key == nil {
+			return nil, errors.WithStack(errNonInitializedKey)
+		}
+		var err error
+		fullKeys[i], err = key.toECDSA()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sum, err := combineECDSAPublicKeys(fullKeys)
+	if err != nil {
+		return nil, err
+	}
+	result := SchnorrPublicKey{init: true}
+	ret := C.secp256k1_xonly_pubkey_from_pubkey(C.secp256k1_context_no_precomp, &result.pubkey, nil, &sum.pubkey)
+	if ret != 1 {
+		panic("Should never fail. we just created the public key so it can't be invalid")
+	}
+	return &result, nil
+}
+
+// ErrHashNotAllowed is returned by VerifyAllowedHash when the hash isn't in the allow-list.
+var ErrHashNotAllowed = errors.New("the hash isn't in the allow-list")
+
+// VerifyAllowedHash verifies a schnorr signature over the hash, after checking that the hash is one of the allowed hashes
+// (e.g. the hashes of the commands a key may authorize). If it isn't ErrHashNotAllowed is returned.
+// The membership check compares the hash against every allowed hash in constant time, so the timing doesn't leak which one matched.
+// Notice: the [32] byte array *MUST* be a hash of a message you hashed yourself.
+func (pubkey *SchnorrPublicKey) VerifyAllowedHash(hash *Hash, sig *SchnorrSignature, allowed []*Hash) (bool, error) {
+	if !pubkey.init {
+		return false, errors.WithStack(errNonInitializedKey)
+	}
+	found := 0
+	for _, allowedHash := range allowed {
+		if allowedHash == nil {
+			continue
+		}
+		found |= subtle.ConstantTimeCompare(hash[:], allowedHash[:])
+	}
+	if found != 1 {
+		return false, errors.WithStack(ErrHashNotAllowed)
+	}
+	return pubkey.SchnorrVerify(hash, sig), nil
+}
+
+// VerifyAny verifies the signature against each of the public keys in order (e.g. the old and new key during a rotation),
+// and returns the index of the first one it's valid for, or -1 and false if there's none. nil and uninitialized keys are skipped.
+func VerifyAny(pubkeys []*SchnorrPublicKey, hash *Hash, sig *SchnorrSignature) (matched int, ok bool) {
+	for i, pubkey := range pubkeys {
+		if pubkey == nil || !pubkey.init {
+			continue
+		}
+		if pubkey.SchnorrVerify(hash, sig) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// VerifyAgainstAggregate verifies the signature against the aggregate of the cosigners' keys, as computed by AddSchnorrPublicKeys.
+// The plain sum doesn't depend on the order of the keys, so the signer and the verifier can't combine them differently.
+// It's an error if there are no cosigners, a key isn't initialized, or the keys sum to the point at infinity.
+// Notice: a plain sum of keys is open to rogue key attacks, every cosigner has to prove they own their key (e.g. with ProveOwnership)
+// before it's added to the set.
+func VerifyAgainstAggregate(cosigners []*SchnorrPublicKey, hash *Hash, signature *SchnorrSignature) (bool, error) {
+	if len(cosigners) == 0 {
+		return false, errors.New("can't aggregate an empty set of cosigners")
+	}
+	aggregate, err := AddSchnorrPublicKeys(cosigners...)
+	if err != nil {
+		return false, err
+	}
+	return aggregate.SchnorrVerify(hash, signature), nil
+}
+
+// SessionKey combines two public keys with AddSchnorrPublicKeys and adds sharedTweak to the result.
+// Both parties get the same key regardless of the order of a and b.
+func SessionKey(a, b *SchnorrPublicKey, sharedTweak [32]byte) (*SchnorrPublicKey, error) {
+	sessionKey, err := AddSchnorrPublicKeys(a, b)
+	if err != nil {
+		return nil, err
+	}
+	err = sessionKey.Add(sharedTweak)
+	if err != nil {
+		return nil, err
+	}
+	return sessionKey, nil
+}
+
+// toECDSA lifts the x-only public key to the full public key with an even Y coordinate.
+func (key *SchnorrPublicKey) toECDSA() (*ECDSAPublicKey, error) {
+	serialized, err := key.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	compressed := SerializedECDSAPublicKey{0x02}
+	copy(compressed[1:], serialized[:])
+	return DeserializeECDSAPubKey(compressed[:])
+}
+
+// IsLiftable returns true if the x-only public key lifts to a curve point with an even Y.
+// DeserializeSchnorrPubKey already rejects x coordinates that aren't on the curve, so this is only false for uninitialized keys,
+// it's meant for double checking keys that came from somewhere else.
+func (key *SchnorrPublicKey) IsLiftable() bool {
+	if key == nil || !key.init {
+		return false
+	}
+	_, err := key.toECDSA()
+	return err == nil
+}
+
+// TweakAddCheck returns true if tweakedKey is the result of adding tweak to key. i.e. `tweakedKey == key + tweak*Generator`.
+// tweakedIsOdd is the parity of the tweaked key as returned by AddWithParity, the check fails if it doesn't match.
+func (key *SchnorrPublicKey) TweakAddCheck(tweakedKey *SchnorrPublicKey, tweakedIsOdd bool, tweak [32]byte) bool {
+	if !key.init || tweakedKey == nil || !tweakedKey.init {
+		return false
+	}
+	serializedTweaked, err := tweakedKey.Serialize()
+	if err != nil {
+		return false
+	}
+	cParity := C.int(0)
+	if tweakedIsOdd {
+		cParity = 1
+	}
+	cPtrTweaked := (*C.uchar)(&serializedTweaked[0])
+	cPtrTweak := (*C.uchar)(&tweak[0])
+	return C.secp256k1_xonly_pubkey_tweak_add_check(context, cPtrTweaked, cParity, &key.pubkey, cPtrTweak) == 1
+}
+
+// VerifyDerivationChain returns true if adding the tweaks to master one after the other (like SchnorrPublicKey.Add) gives expected.
+// Each step is an x-only tweak, so this matches deriving the private key with SchnorrKeyPair.Add and the same tweaks.
+// It returns false if a tweak is invalid (bigger than the group order or cancelling the key) or either key isn't initialized.
+func VerifyDerivationChain(master *SchnorrPublicKey, tweaks [][32]byte, expected *SchnorrPublicKey) bool {
+	if master == nil || !master.init || expected == nil || !expected.init {
+		return false
+	}
+	derived := *master
+	for _, tweak := range tweaks {
+		err := derived.Add(tweak)
+		if err != nil {
+			return false
+		}
+	}
+	return derived.IsEqual(expected)
+}
+
 func (key *SchnorrPublicKey) addInternal(tweak [32]byte) (bool, error) {
 	if !key.init {
 		return false, errors.WithStack(errNonInitializedKey)
@@ -1,8 +1,10 @@
 package secp256k1
 
 import (
+	"bytes"
 	"encoding/hex"
 	"github.com/pkg/errors"
+	"io"
 )
 
 const (
@@ -30,6 +32,24 @@ func (signature *SchnorrSignature) IsEqual(target *SchnorrSignature) bool {
 	return *signature.Serialize() == *target.Serialize()
 }
 
+// ZeroSchnorrSignature returns a SchnorrSignature of 64 zero bytes.
+// it can be used as a sentinel for a missing signature, it will never pass verification.
+func ZeroSchnorrSignature() *SchnorrSignature {
+	return &SchnorrSignature{}
+}
+
+// IsZero returns true if the signature is all zeros. i.e. the sentinel returned by ZeroSchnorrSignature
+func (signature *SchnorrSignature) IsZero() bool {
+	return signature.signature == [SerializedSchnorrSignatureSize]byte{}
+}
+
+// hasZeroR returns true if R is zero, zero isn't a valid x coordinate so such a signature can never be valid.
+// Notice: unlike ECDSA, S == 0 is allowed by BIP-340 (any S below the group order is valid).
+func (signature *SchnorrSignature) hasZeroR() bool {
+	var zero [32]byte
+	return bytes.Equal(signature.signature[:32], zero[:])
+}
+
 // String returns the SerializedSchnorrSignature as the hexadecimal string
 func (serialized SerializedSchnorrSignature) String() string {
 	return hex.EncodeToString(serialized[:])
@@ -62,3 +82,141 @@ func DeserializeSchnorrSignatureFromSlice(data []byte) (signature *SchnorrSignat
 	copy(signature.signature[:], data)
 	return
 }
+
+// SerializedSchnorrSignatureWithRParitySize is the size of a schnorr signature followed by the Y parity byte of R.
+const SerializedSchnorrSignatureWithRParitySize = SerializedSchnorrSignatureSize + 1
+
+// SerializeWithRParity returns the 64 byte signature followed by the Y parity of R (0 for even, 1 for odd), for protocols
+// that store schnorr signatures in a 65 byte recoverable-like format. It's an error if R's x coordinate isn't on the curve.
+// Notice: BIP-340 always lifts R to the point with an even Y, so for BIP-340 signatures the parity byte is always 0
+// and carries no information, it only exists for compatibility with such formats.
+func (signature *SchnorrSignature) SerializeWithRParity() ([SerializedSchnorrSignatureWithRParitySize]byte, error) {
+	r, _ := signature.Split()
+	if _, err := DeserializeSchnorrPubKey(r[:]); err != nil {
+		return [SerializedSchnorrSignatureWithRParitySize]byte{}, errors.Wrap(err, "invalid signature R")
+	}
+	serialized := [SerializedSchnorrSignatureWithRParitySize]byte{}
+	copy(serialized[:], signature.signature[:])
+	serialized[SerializedSchnorrSignatureSize] = 0 // lift_x(R) always has an even Y
+	return serialized, nil
+}
+
+// DeserializeSchnorrSignatureWithRParity parses a signature serialized with SerializeWithRParity.
+// It's an error if R's x coordinate isn't on the curve, or the parity byte isn't 0 since a BIP-340 R always has an even Y.
+func DeserializeSchnorrSignatureWithRParity(data []byte) (*SchnorrSignature, error) {
+	if len(data) != SerializedSchnorrSignatureWithRParitySize {
+		return nil, errors.Errorf("invalid schnorr signature with R parity length got %d, expected %d", len(data),
+			SerializedSchnorrSignatureWithRParitySize)
+	}
+	switch data[SerializedSchnorrSignatureSize] {
+	case 0:
+	case 1:
+		return nil, errors.New("the signature's R has an odd Y, BIP-340 signatures always have an even Y")
+	default:
+		return nil, errors.Errorf("invalid R parity byte %d, expected 0 or 1", data[SerializedSchnorrSignatureSize])
+	}
+	if _, err := DeserializeSchnorrPubKey(data[:32]); err != nil {
+		return nil, errors.Wrap(err, "invalid signature R")
+	}
+	return DeserializeSchnorrSignatureFromSlice(data[:SerializedSchnorrSignatureSize])
+}
+
+// Split returns the R (the x coordinate of the nonce) and S halves of the signature.
+func (signature *SchnorrSignature) Split() (r [32]byte, s [32]byte) {
+	copy(r[:], signature.signature[:32])
+	copy(s[:], signature.signature[32:])
+	return r, s
+}
+
+// NegateS returns a new signature with the same R and S replaced by `-S % Group Order`, the signature itself isn't modified.
+// This is meant for adaptor signature protocols that combine signatures with different nonce parities,
+// the result wouldn't pass BIP-340 verification by itself.
+func (signature *SchnorrSignature) NegateS() *SchnorrSignature {
+	r, s := signature.Split()
+	return SchnorrSignatureFromRS(r, ScalarNegate(s))
+}
+
+// HasNonce returns true if the signature's R is the x coordinate of expectedR, e.g. a nonce point precommitted to in an earlier round.
+// Only the x coordinate is compared, since BIP-340 signatures don't encode R's parity.
+// It returns false if expectedR is nil or isn't initialized.
+func (signature *SchnorrSignature) HasNonce(expectedR *ECDSAPublicKey) bool {
+	if expectedR == nil {
+		return false
+	}
+	serialized, err := expectedR.Serialize()
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(signature.signature[:32], serialized[1:])
+}
+
+// FindNonceReuse returns the pairs of indices of signatures that share the same R, i.e. were made with the same nonce.
+// Each pair is [earlier, later], and a nonce used by k signatures yields every one of its k*(k-1)/2 pairs.
+// Signing two different messages with the same nonce and key leaks the private key, so any result is a bug or an attack,
+// but notice that the same signature appearing twice is also reported. nil signatures are skipped.
+func FindNonceReuse(sigs []*SchnorrSignature) [][2]int {
+	var pairs [][2]int
+	seen := make(map[[32]byte][]int, len(sigs))
+	for i, sig := range sigs {
+		if sig == nil {
+			continue
+		}
+		r, _ := sig.Split()
+		for _, earlier := range seen[r] {
+			pairs = append(pairs, [2]int{earlier, i})
+		}
+		seen[r] = append(seen[r], i)
+	}
+	return pairs
+}
+
+// SchnorrSignatureFromRS creates a SchnorrSignature from its R and S halves. it's the inverse of Split
+func SchnorrSignatureFromRS(r, s [32]byte) *SchnorrSignature {
+	signature := &SchnorrSignature{}
+	copy(signature.signature[:32], r[:])
+	copy(signature.signature[32:], s[:])
+	return signature
+}
+
+// ReadSchnorrSignature reads exactly SerializedSchnorrSignatureSize bytes from r into a SchnorrSignature.
+// If r returns fewer bytes the error is io.EOF (nothing was read) or io.ErrUnexpectedEOF (a short read).
+func ReadSchnorrSignature(r io.Reader) (*SchnorrSignature, error) {
+	signature := &SchnorrSignature{}
+	_, err := io.ReadFull(r, signature.signature[:])
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// WriteTo writes the 64 byte serialized signature to w. it implements io.WriterTo
+func (signature *SchnorrSignature) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(signature.signature[:])
+	return int64(n), err
+}
+
+// SchnorrChallenge computes the BIP-340 challenge `e = TaggedHash("BIP0340/challenge", R || P || m) mod n`
+// where R is the x coordinate of the signature's nonce, P is the x-only public key and m is the signed hash.
+// The only error is an uninitialized public key, which has no serialization to hash. It's returned instead of a panic,
+// like SchnorrPublicKey.Serialize does, since a zero challenge would silently pass for a valid one.
+func SchnorrChallenge(r *[32]byte, pubkey *SchnorrPublicKey, hash *Hash) ([32]byte, error) {
+	serializedPubKey, err := pubkey.Serialize()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	challenge := TaggedHash("BIP0340/challenge", r[:], serializedPubKey[:], hash[:])
+	reduced, _ := reduceScalar((*[32]byte)(challenge))
+	return reduced, nil
+}
+
+// DebugSchnorrVerify verifies the signature like SchnorrVerify, but also returns the intermediate values,
+// the signature's R and S halves and the BIP-340 challenge e, so a failing signature can be diagnosed.
+// A valid signature satisfies `S*G == R + e*P`, recomputing the challenge with a different hash or key will show which one is off.
+func DebugSchnorrVerify(pubkey *SchnorrPublicKey, hash *Hash, sig *SchnorrSignature) (valid bool, R *[32]byte, s *[32]byte, challenge [32]byte, err error) {
+	r, sHalf := sig.Split()
+	challenge, err = SchnorrChallenge(&r, pubkey, hash)
+	if err != nil {
+		return false, &r, &sHalf, [32]byte{}, err
+	}
+	return pubkey.SchnorrVerify(hash, sig), &r, &sHalf, challenge, nil
+}
@@ -0,0 +1,93 @@
+package secp256k1
+
+import (
+	"encoding/asn1"
+	"math/big"
+)
+
+var (
+	// oidPublicKeyECDSA is id-ecPublicKey from RFC 5480
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	// oidNamedCurveSecp256k1 is the secp256k1 curve OID from SEC 2
+	oidNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	// oidPrimeField is prime-field from X9.62
+	oidPrimeField = asn1.ObjectIdentifier{1, 2, 840, 10045, 1, 1}
+)
+
+// The secp256k1 domain parameters from SEC 2, section 2.4.1
+var (
+	secp256k1FieldPrime, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	secp256k1GroupOrder, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secp256k1Generator     = []byte{
+		0x04,
+		0x79, 0xbe, 0x66, 0x7e, 0xf9, 0xdc, 0xbb, 0xac, 0x55, 0xa0, 0x62, 0x95, 0xce, 0x87, 0x0b, 0x07,
+		0x02, 0x9b, 0xfc, 0xdb, 0x2d, 0xce, 0x28, 0xd9, 0x59, 0xf2, 0x81, 0x5b, 0x16, 0xf8, 0x17, 0x98,
+		0x48, 0x3a, 0xda, 0x77, 0x26, 0xa3, 0xc4, 0x65, 0x5d, 0xa4, 0xfb, 0xfc, 0x0e, 0x11, 0x08, 0xa8,
+		0xfd, 0x17, 0xb4, 0x48, 0xa6, 0x85, 0x54, 0x19, 0x9c, 0x47, 0xd0, 0x8f, 0xfb, 0x10, 0xd4, 0xb8,
+	}
+)
+
+// sec1PublicKeyInfo is the SubjectPublicKeyInfo structure from RFC 5280
+type sec1PublicKeyInfo struct {
+	Algorithm sec1AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type sec1AlgorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue
+}
+
+// sec1ECParameters is the explicit ECParameters structure from SEC 1, section C.2
+type sec1ECParameters struct {
+	Version  int
+	FieldID  sec1FieldID
+	Curve    sec1Curve
+	Base     []byte
+	Order    *big.Int
+	Cofactor int
+}
+
+type sec1FieldID struct {
+	FieldType asn1.ObjectIdentifier
+	Prime     *big.Int
+}
+
+type sec1Curve struct {
+	A []byte
+	B []byte
+}
+
+// MarshalSEC1 encodes the public key as a DER SubjectPublicKeyInfo with an uncompressed point, as OpenSSL does.
+// If explicitParams is true the full secp256k1 domain parameters (field, curve, generator, order and cofactor)
+// are encoded instead of the named curve OID, for counterparts that don't support named curves.
+func (key *ECDSAPublicKey) MarshalSEC1(explicitParams bool) ([]byte, error) {
+	point, err := key.serializeUncompressed()
+	if err != nil {
+		return nil, err
+	}
+	var params []byte
+	if explicitParams {
+		// y^2 = x^3 + 7
+		curveB := make([]byte, 32)
+		curveB[31] = 7
+		params, err = asn1.Marshal(sec1ECParameters{
+			Version: 1,
+			FieldID: sec1FieldID{FieldType: oidPrimeField, Prime: secp256k1FieldPrime},
+			Curve:   sec1Curve{A: make([]byte, 32), B: curveB},
+			Base:    secp256k1Generator,
+			Order:   secp256k1GroupOrder,
+			// The cofactor is optional, OpenSSL always includes it.
+			Cofactor: 1,
+		})
+	} else {
+		params, err = asn1.Marshal(oidNamedCurveSecp256k1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sec1PublicKeyInfo{
+		Algorithm: sec1AlgorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: params}},
+		PublicKey: asn1.BitString{Bytes: point[:], BitLength: len(point) * 8},
+	})
+}
@@ -0,0 +1,45 @@
+package secp256k1
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMarshalSEC1(t *testing.T) {
+	// Generated with `openssl ec -pubout -outform DER [-param_enc explicit]`
+	tests := []struct {
+		pubKey   []byte
+		named    []byte
+		explicit []byte
+	}{
+		{
+			decodeHex("0275bd75385b927bdad91eded4d8b2cdcc861c4d100c2d7c0a10e26d29d6ccfcbd"),
+			decodeHex("3056301006072a8648ce3d020106052b8104000a0342000475bd75385b927bdad91eded4d8b2cdcc861c4d100c2d7c0a10e26d29d6ccfcbdd3c6c36e6847820a014bd4ccb7921c0daf1ccde879228209cde02102e51dda14"),
+			decodeHex("308201333081ec06072a8648ce3d02013081e0020101302c06072a8648ce3d0101022100fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f3044042000000000000000000000000000000000000000000000000000000000000000000420000000000000000000000000000000000000000000000000000000000000000704410479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8022100fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd03641410201010342000475bd75385b927bdad91eded4d8b2cdcc861c4d100c2d7c0a10e26d29d6ccfcbdd3c6c36e6847820a014bd4ccb7921c0daf1ccde879228209cde02102e51dda14"),
+		},
+	}
+	for i, test := range tests {
+		pubkey, err := DeserializeECDSAPubKey(test.pubKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		named, err := pubkey.MarshalSEC1(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(named, test.named) {
+			t.Errorf("Test '%d': expected named curve encoding %x, got %x", i, test.named, named)
+		}
+		explicit, err := pubkey.MarshalSEC1(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(explicit, test.explicit) {
+			t.Errorf("Test '%d': expected explicit encoding %x, got %x", i, test.explicit, explicit)
+		}
+	}
+	_, err := new(ECDSAPublicKey).MarshalSEC1(false)
+	if err == nil {
+		t.Errorf("Expected an error for a zeroed public key")
+	}
+}
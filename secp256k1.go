@@ -2,18 +2,100 @@ package secp256k1
 
 // // **This is CGO's build system. CGO parses the following comments as build instructions.**
 // // Including the headers and code, and defining the default macros
-// #cgo CFLAGS: -I./depend/secp256k1 -I./depend/secp256k1/src/
-// #cgo CFLAGS: -DSECP256K1_BUILD=1 -DECMULT_WINDOW_SIZE=15 -DENABLE_MODULE_SCHNORRSIG=1 -DENABLE_MODULE_EXTRAKEYS=1
+// #cgo CFLAGS: -I./depend/secp256k1 -I./depend/secp256k1/src/ -I./depend/secp256k1/include/
+// #cgo CFLAGS: -DSECP256K1_BUILD=1 -DECMULT_WINDOW_SIZE=15 -DENABLE_MODULE_SCHNORRSIG=1 -DENABLE_MODULE_EXTRAKEYS=1 -DENABLE_MODULE_RECOVERY=1
 // #cgo CFLAGS: -DECMULT_GEN_PREC_BITS=4
-// // x86_64 can use the Assembly implementation.
-// #cgo amd64 CFLAGS: -DUSE_ASM_X86_64=1
+// // x86_64 can use the Assembly implementation, unless disabled with the `secp256k1_noasm` build tag.
+// #cgo amd64,!secp256k1_noasm CFLAGS: -DUSE_ASM_X86_64=1
 // #include "./depend/secp256k1/include/secp256k1.h"
 // #include "./depend/secp256k1/src/secp256k1.c"
+// #include "./depend/secp256k1/contrib/lax_der_parsing.c"
+//
+// // Helpers that need libsecp256k1's internals, which are only visible in this compilation unit.
+// typedef struct {
+//     const secp256k1_context *ctx;
+//     const unsigned char *scalars32;
+//     const secp256k1_pubkey *points;
+// } go_secp256k1_multi_data;
+//
+// static int go_secp256k1_multi_callback(secp256k1_scalar *sc, secp256k1_ge *pt, size_t idx, void *cbdata) {
+//     const go_secp256k1_multi_data *data = (const go_secp256k1_multi_data *)cbdata;
+//     int overflow;
+//     secp256k1_scalar_set_b32(sc, &data->scalars32[idx * 32], &overflow);
+//     if (overflow) {
+//         return 0;
+//     }
+//     return secp256k1_pubkey_load(data->ctx, pt, &data->points[idx]);
+// }
+//
+// // Returns 1 on success, 0 if the result is the point at infinity and -1 on failure.
+// int go_secp256k1_ecmult_multi(const secp256k1_context *ctx, secp256k1_pubkey *result, const unsigned char *g_scalar32,
+//                               const unsigned char *scalars32, const secp256k1_pubkey *points, size_t n) {
+//     go_secp256k1_multi_data data;
+//     secp256k1_scalar g_scalar;
+//     secp256k1_scratch *scratch;
+//     secp256k1_gej resj;
+//     secp256k1_ge res;
+//     size_t scratch_size;
+//     int overflow;
+//     int ret;
+//
+//     if (g_scalar32 != NULL) {
+//         secp256k1_scalar_set_b32(&g_scalar, g_scalar32, &overflow);
+//         if (overflow) {
+//             return -1;
+//         }
+//     }
+//     if (n < ECMULT_PIPPENGER_THRESHOLD) {
+//         scratch_size = secp256k1_strauss_scratch_size(n) + STRAUSS_SCRATCH_OBJECTS*ALIGNMENT;
+//     } else {
+//         scratch_size = secp256k1_pippenger_scratch_size(n, secp256k1_pippenger_bucket_window(n)) + PIPPENGER_SCRATCH_OBJECTS*ALIGNMENT;
+//     }
+//     scratch = secp256k1_scratch_create(&ctx->error_callback, scratch_size);
+//     if (scratch == NULL) {
+//         return -1;
+//     }
+//     data.ctx = ctx;
+//     data.scalars32 = scalars32;
+//     data.points = points;
+//     ret = secp256k1_ecmult_multi_var(&ctx->error_callback, &ctx->ecmult_ctx, scratch, &resj, g_scalar32 != NULL ? &g_scalar : NULL,
+//                                      go_secp256k1_multi_callback, &data, n);
+//     secp256k1_scratch_destroy(&ctx->error_callback, scratch);
+//     if (!ret) {
+//         return -1;
+//     }
+//     if (secp256k1_gej_is_infinity(&resj)) {
+//         return 0;
+//     }
+//     secp256k1_ge_set_gej(&res, &resj);
+//     secp256k1_pubkey_save(result, &res);
+//     return 1;
+// }
+//
+// // Builds a keypair from a secret key and its 64 byte `X || Y` public key without deriving the public key,
+// // returns 0 if the secret key is invalid or the public key isn't on the curve.
+// int go_secp256k1_keypair_restore(secp256k1_keypair *keypair, const unsigned char *seckey32, const unsigned char *pubkey64) {
+//     secp256k1_scalar sk;
+//     secp256k1_ge pk;
+//     unsigned char uncompressed[65];
+//     int ret;
+//
+//     uncompressed[0] = SECP256K1_TAG_PUBKEY_UNCOMPRESSED;
+//     memcpy(&uncompressed[1], pubkey64, 64);
+//     ret = secp256k1_scalar_set_b32_seckey(&sk, seckey32);
+//     ret &= secp256k1_eckey_pubkey_parse(&pk, uncompressed, sizeof(uncompressed));
+//     if (ret) {
+//         secp256k1_keypair_save(keypair, &sk, &pk);
+//     }
+//     secp256k1_scalar_clear(&sk);
+//     return ret;
+// }
 import "C"
 
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"io"
 
 	"github.com/pkg/errors"
 )
@@ -38,6 +120,40 @@ func init() {
 	}
 }
 
+// maxPrivateKeyGenerationAttempts bounds the retries when generating a private key.
+// The chance of a random 32 bytes not being a valid private key is less than 2^-127, so hitting this means the random source is broken.
+const maxPrivateKeyGenerationAttempts = 1000
+
+// randomReader is the source of randomness for generating keys and signing, it's only replaced in tests.
+var randomReader io.Reader = rand.Reader
+
+// readRandom fills buf with random bytes from randomReader, a short read is returned as an error.
+func readRandom(buf []byte) error {
+	_, err := io.ReadFull(randomReader, buf)
+	if err != nil {
+		return errors.Wrap(err, "failed reading random bytes")
+	}
+	return nil
+}
+
+// generatePrivateKey generates a random valid private key(Group Order > key > 0)
+func generatePrivateKey() (*SerializedPrivateKey, error) {
+	privateKey := SerializedPrivateKey{}
+	cPtr := (*C.uchar)(&privateKey[0])
+	for i := 0; i < maxPrivateKeyGenerationAttempts; i++ {
+		err := readRandom(privateKey[:])
+		if err != nil {
+			return nil, err
+		}
+		ret := C.secp256k1_ec_seckey_verify(C.secp256k1_context_no_precomp, cPtr)
+		if ret == 1 {
+			return &privateKey, nil
+		}
+	}
+	return nil, errors.Errorf("failed generating a valid private key after %d attempts, the random source is probably broken",
+		maxPrivateKeyGenerationAttempts)
+}
+
 // errNonInitializedKey is the error returned when using a zeroed pubkey
 var errNonInitializedKey = errors.New("the key isn't initialized, you should use the generate/deserialize functions")
 
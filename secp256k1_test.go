@@ -2,11 +2,20 @@ package secp256k1
 
 import (
 	"bytes"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"golang.org/x/crypto/argon2"
+	"io"
+	"math"
 	"math/big"
 	"math/rand"
 	"reflect"
 	"testing"
+	"time"
 )
 
 const loopsN = 150
@@ -53,6 +62,30 @@ func fastGenerateTweak(t testing.TB, r *rand.Rand) *[32]byte {
 	}
 }
 
+func mustSchnorrKeyPair(t testing.TB, r *rand.Rand) *SchnorrKeyPair {
+	keypair, err := DeserializeSchnorrPrivateKey((*SerializedPrivateKey)(fastGenerateTweak(t, r)))
+	if err != nil {
+		t.Fatalf("A valid tweak should be a valid private key: '%s'", err)
+	}
+	return keypair
+}
+
+func mustECDSAPrivateKey(t testing.TB, r *rand.Rand) *ECDSAPrivateKey {
+	privkey, err := DeserializeECDSAPrivateKey((*SerializedPrivateKey)(fastGenerateTweak(t, r)))
+	if err != nil {
+		t.Fatalf("A valid tweak should be a valid private key: '%s'", err)
+	}
+	return privkey
+}
+
+func mustSchnorrSign(t testing.TB, keypair *SchnorrKeyPair, hash *Hash) *SchnorrSignature {
+	signature, err := keypair.SchnorrSign(hash)
+	if err != nil {
+		t.Fatalf("Failed signing: key: '%s', msg: '%s', error: '%s'", keypair, hash, err)
+	}
+	return signature
+}
+
 func TestECDSAPublicKey_ToSchnorr(t *testing.T) {
 	r := rand.New(rand.NewSource(42))
 	serializedPrivKey := (*SerializedPrivateKey)(fastGenerateTweak(t, r))
@@ -492,6 +525,3802 @@ func TestHash_IsEqual(t *testing.T) {
 	}
 }
 
+func TestSchnorrReadWriteTo(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatalf("A valid privkey should convert to a pubkey: '%s'", err)
+	}
+	msg := Hash(*fastGenerateTweak(t, r))
+	sig := mustSchnorrSign(t, keypair, &msg)
+
+	buf := bytes.Buffer{}
+	n, err := pubkey.WriteTo(&buf)
+	if err != nil || n != SerializedSchnorrPublicKeySize {
+		t.Fatalf("Failed writing the pubkey, wrote: %d bytes, err: '%s'", n, err)
+	}
+	n, err = sig.WriteTo(&buf)
+	if err != nil || n != SerializedSchnorrSignatureSize {
+		t.Fatalf("Failed writing the signature, wrote: %d bytes, err: '%s'", n, err)
+	}
+
+	readPubkey, err := ReadSchnorrPublicKey(&buf)
+	if err != nil {
+		t.Fatalf("Failed reading the pubkey: '%s'", err)
+	}
+	if !pubkey.IsEqual(readPubkey) {
+		t.Errorf("Expected %s == %s", pubkey, readPubkey)
+	}
+	readSig, err := ReadSchnorrSignature(&buf)
+	if err != nil {
+		t.Fatalf("Failed reading the signature: '%s'", err)
+	}
+	if !sig.IsEqual(readSig) {
+		t.Errorf("Expected %s == %s", sig, readSig)
+	}
+
+	_, err = ReadSchnorrPublicKey(&buf)
+	if err != io.EOF {
+		t.Errorf("Expected io.EOF when reading from an empty reader, instead got: '%s'", err)
+	}
+	_, err = ReadSchnorrSignature(bytes.NewReader(sig.Serialize()[:63]))
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF on a short read, instead got: '%s'", err)
+	}
+
+	_, err = new(SchnorrPublicKey).WriteTo(&buf)
+	if err == nil {
+		t.Errorf("Writing a zeroed public key should fail")
+	}
+}
+
+func TestZeroSchnorrSignature(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	zero := ZeroSchnorrSignature()
+	if !zero.IsZero() {
+		t.Errorf("ZeroSchnorrSignature should be zero: '%s'", zero)
+	}
+	var zeros [SerializedSchnorrSignatureSize]byte
+	deserialized, err := DeserializeSchnorrSignatureFromSlice(zeros[:])
+	if err != nil {
+		t.Fatalf("Failed deserializing a zeroed signature: '%s'", err)
+	}
+	if !deserialized.IsZero() || !deserialized.IsEqual(zero) {
+		t.Errorf("A deserialized zeroed signature should be equal to ZeroSchnorrSignature: '%s'", deserialized)
+	}
+
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatalf("A valid privkey should convert to a pubkey: '%s'", err)
+	}
+	msg := Hash(*fastGenerateTweak(t, r))
+	sig := mustSchnorrSign(t, keypair, &msg)
+	if sig.IsZero() {
+		t.Errorf("A real signature shouldn't be zero: '%s'", sig)
+	}
+	if pubkey.SchnorrVerify(&msg, zero) {
+		t.Errorf("A zeroed signature should never verify")
+	}
+}
+
+func TestVerifyZeroRS(t *testing.T) {
+	ForAllAlgorithms(t, func(t *testing.T, r *rand.Rand, alg alogirthmInterface) {
+		for i := 0; i < loopsN; i++ {
+			privkey := alg.EmptyPrivKey().GenerateNew(t, r)
+			pubkey, _, err := privkey.PublicKey()
+			if err != nil {
+				t.Fatalf("Failed generating a pubkey, privateKey: '%s', error: %s", privkey, err)
+			}
+			msg := Hash(*fastGenerateTweak(t, r))
+			sig, err := privkey.Sign(&msg)
+			if err != nil {
+				t.Fatalf("Failed signing: key: '%s', msg: '%s', error: '%s'", privkey, msg, err)
+			}
+			zeroR := *sig.Serialize()
+			copy(zeroR[:32], make([]byte, 32))
+			zeroS := *sig.Serialize()
+			copy(zeroS[32:], make([]byte, 32))
+			var zeroRS [64]byte
+			for _, serialized := range [][64]byte{zeroR, zeroS, zeroRS} {
+				degenerate, err := sig.DeserializeNew(serialized[:])
+				if err != nil {
+					t.Fatalf("Failed deserializing sig: '%x', error: '%s'", serialized, err)
+				}
+				if pubkey.Verify(&msg, degenerate) {
+					t.Errorf("A signature with a zero R or S should never verify: '%s'", degenerate)
+				}
+			}
+		}
+	})
+}
+
+func TestSchnorrZeroSAllowed(t *testing.T) {
+	// BIP-340 allows S == 0, only a zero R can be rejected without running the verification.
+	var zeroS SerializedSchnorrSignature
+	copy(zeroS[:32], decodeHex("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798"))
+	signature := DeserializeSchnorrSignature(&zeroS)
+	if signature.hasZeroR() {
+		t.Errorf("A signature with a non zero R shouldn't be rejected: '%s'", signature)
+	}
+	var zeroR SerializedSchnorrSignature
+	zeroR[63] = 1
+	signature = DeserializeSchnorrSignature(&zeroR)
+	if !signature.hasZeroR() {
+		t.Errorf("A signature with a zero R should be rejected: '%s'", signature)
+	}
+}
+
+func TestSchnorrChallenge(t *testing.T) {
+	// Test vector 1 from https://github.com/bitcoin/bips/blob/master/bip-0340/test-vectors.csv
+	pubkey, err := DeserializeSchnorrPubKey(decodeHex("DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Hash{}
+	err = msg.SetBytes(decodeHex("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := DeserializeSchnorrSignatureFromSlice(decodeHex("6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := [32]byte{}
+	copy(r[:], sig.Serialize()[:32])
+
+	challenge, err := SchnorrChallenge(&r, pubkey, &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tag := sha256.Sum256([]byte("BIP0340/challenge"))
+	serializedPubKey, err := pubkey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	preimage := append(append(append(append(tag[:], tag[:]...), r[:]...), serializedPubKey[:]...), msg[:]...)
+	expectedHash := sha256.Sum256(preimage)
+	expected := new(big.Int).SetBytes(expectedHash[:])
+	expected.Mod(expected, Secp256k1Order)
+	if intTo32Bytes(expected) != challenge {
+		t.Errorf("Expected challenge %x, got %x", intTo32Bytes(expected), challenge)
+	}
+
+	_, err = SchnorrChallenge(&r, new(SchnorrPublicKey), &msg)
+	if err == nil {
+		t.Errorf("Computing a challenge for a zeroed public key should fail")
+	}
+}
+
+func TestReduceScalar(t *testing.T) {
+	orderPlusOne := intTo32Bytes(new(big.Int).Add(Secp256k1Order, big.NewInt(1)))
+	reduced, overflowed := reduceScalar(&orderPlusOne)
+	if !overflowed || reduced != intTo32Bytes(big.NewInt(1)) {
+		t.Errorf("Expected order+1 to reduce to 1 with an overflow, got %x, overflowed: %t", reduced, overflowed)
+	}
+	orderMinusOne := intTo32Bytes(new(big.Int).Sub(Secp256k1Order, big.NewInt(1)))
+	reduced, overflowed = reduceScalar(&orderMinusOne)
+	if overflowed || reduced != orderMinusOne {
+		t.Errorf("Expected order-1 to stay the same without an overflow, got %x, overflowed: %t", reduced, overflowed)
+	}
+}
+
+func TestSignAndPublicKey(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	serializedPrivKey := (*SerializedPrivateKey)(fastGenerateTweak(t, r))
+	msg := Hash(*fastGenerateTweak(t, r))
+	sig, pubkey, err := SignAndPublicKey(serializedPrivKey, &msg)
+	if err != nil {
+		t.Fatalf("Failed signing: '%s'", err)
+	}
+	keypair, err := DeserializeSchnorrPrivateKey(serializedPrivKey)
+	if err != nil {
+		t.Fatalf("A valid tweak should be a valid private key: '%s'", err)
+	}
+	expectedPubKey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatalf("A valid privkey should convert to a pubkey: '%s'", err)
+	}
+	if !pubkey.IsEqual(expectedPubKey) {
+		t.Errorf("Expected %s == %s", pubkey, expectedPubKey)
+	}
+	if !pubkey.SchnorrVerify(&msg, sig) {
+		t.Errorf("Failed verifying signature: '%s' pubkey: '%s'", sig, pubkey)
+	}
+
+	_, _, err = SignAndPublicKey(&SerializedPrivateKey{}, &msg)
+	if err == nil {
+		t.Errorf("Signing with a zeroed private key should fail")
+	}
+}
+
+func TestSchnorrPublicKey_IsValid(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatalf("A valid privkey should convert to a pubkey: '%s'", err)
+	}
+	for i := 0; i < loopsN; i++ {
+		err = pubkey.Add(*fastGenerateTweak(t, r))
+		if err != nil {
+			t.Fatalf("Failed tweaking the public key: '%s'", err)
+		}
+		if !pubkey.IsValid() {
+			t.Fatalf("A tweaked public key should be valid: '%s'", pubkey)
+		}
+	}
+	if new(SchnorrPublicKey).IsValid() {
+		t.Errorf("A zeroed public key shouldn't be valid")
+	}
+	var nilPubKey *SchnorrPublicKey
+	if nilPubKey.IsValid() {
+		t.Errorf("A nil public key shouldn't be valid")
+	}
+}
+
+func TestAddSubPrivateKeys(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < loopsN; i++ {
+		a := mustSchnorrKeyPair(t, r)
+		b := mustSchnorrKeyPair(t, r)
+		aBig := new(big.Int).SetBytes(a.SerializePrivateKey()[:])
+		bBig := new(big.Int).SetBytes(b.SerializePrivateKey()[:])
+
+		sum, err := AddPrivateKeys(a, b)
+		if err != nil {
+			t.Fatalf("Failed adding private keys: '%s'", err)
+		}
+		expectedSum := new(big.Int).Add(aBig, bBig)
+		expectedSum.Mod(expectedSum, Secp256k1Order)
+		if intTo32Bytes(expectedSum) != *sum.SerializePrivateKey() {
+			t.Fatalf("Expected %x == %x", intTo32Bytes(expectedSum), sum.SerializePrivateKey())
+		}
+
+		diff, err := SubPrivateKeys(a, b)
+		if err != nil {
+			t.Fatalf("Failed subtracting private keys: '%s'", err)
+		}
+		expectedDiff := new(big.Int).Sub(aBig, bBig)
+		expectedDiff.Mod(expectedDiff, Secp256k1Order)
+		if intTo32Bytes(expectedDiff) != *diff.SerializePrivateKey() {
+			t.Fatalf("Expected %x == %x", intTo32Bytes(expectedDiff), diff.SerializePrivateKey())
+		}
+
+		_, err = SubPrivateKeys(a, a)
+		if err == nil {
+			t.Fatalf("Subtracting a key from itself should fail")
+		}
+	}
+	_, err := AddPrivateKeys(new(SchnorrKeyPair), new(SchnorrKeyPair))
+	if err == nil {
+		t.Errorf("Adding zeroed keys should fail")
+	}
+}
+
+func TestAddWithParity(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sawOdd, sawEven := false, false
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		pubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweak := fastGenerateTweak(t, r)
+		privIsOdd, err := keypair.AddWithParity(*tweak)
+		if err != nil {
+			t.Fatalf("Failed adding to the keypair: '%s'", err)
+		}
+		pubIsOdd, err := pubkey.AddWithParity(*tweak)
+		if err != nil {
+			t.Fatalf("Failed adding to the public key: '%s'", err)
+		}
+		if privIsOdd != pubIsOdd {
+			t.Fatalf("Expected the keypair and public key parities to match: %t != %t", privIsOdd, pubIsOdd)
+		}
+		tweakedPubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tweakedPubkey.IsEqual(pubkey) {
+			t.Fatalf("Expected %s == %s", tweakedPubkey, pubkey)
+		}
+		ecdsaKey, err := DeserializeECDSAPrivateKey(keypair.SerializePrivateKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ecdsaPubkey, err := ecdsaKey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := ecdsaPubkey.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expectedOdd := serialized[0] == 0x03; expectedOdd != privIsOdd {
+			t.Fatalf("Expected parity %t, got %t", expectedOdd, privIsOdd)
+		}
+		if privIsOdd {
+			sawOdd = true
+		} else {
+			sawEven = true
+		}
+	}
+	if !sawOdd || !sawEven {
+		t.Fatalf("Expected to see both parities, sawOdd: %t, sawEven: %t", sawOdd, sawEven)
+	}
+}
+
+func TestSchnorrVerifyMany(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	hash := Hash{}
+	r.Read(hash[:])
+	const n = 20
+	pubkeys := make([]*SchnorrPublicKey, n)
+	signatures := make([]*SchnorrSignature, n)
+	for i := 0; i < n; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		var err error
+		pubkeys[i], err = keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		signatures[i] = mustSchnorrSign(t, keypair, &hash)
+	}
+	// Swap two signatures, and invalidate a pubkey and a signature.
+	signatures[3], signatures[4] = signatures[4], signatures[3]
+	pubkeys[7] = new(SchnorrPublicKey)
+	signatures[9] = nil
+
+	results := SchnorrVerifyMany(pubkeys, &hash, signatures)
+	if len(results) != n {
+		t.Fatalf("Expected %d results, got %d", n, len(results))
+	}
+	for i, valid := range results {
+		expected := i != 3 && i != 4 && i != 7 && i != 9
+		if valid != expected {
+			t.Errorf("Signature %d: expected %t, got %t", i, expected, valid)
+		}
+	}
+
+	results = SchnorrVerifyMany(pubkeys, &hash, signatures[:n-1])
+	if len(results) != n || results[n-1] {
+		t.Errorf("Expected a public key without a signature to be invalid, got: %v", results)
+	}
+	for i, valid := range SchnorrVerifyMany(pubkeys, nil, signatures) {
+		if valid {
+			t.Errorf("Signature %d: expected a nil hash to be invalid", i)
+		}
+	}
+}
+
+func TestDeriveTagged(t *testing.T) {
+	r := rand.New(rand.NewSource(6))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		original := *keypair
+		signing, err := keypair.DeriveTagged("signing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *keypair != original {
+			t.Fatalf("DeriveTagged shouldn't modify the keypair")
+		}
+		signingAgain, err := keypair.DeriveTagged("signing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *signing != *signingAgain {
+			t.Fatalf("Expected deterministic derivation: %s != %s", signing, signingAgain)
+		}
+		encryption, err := keypair.DeriveTagged("encryption")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *signing == *encryption {
+			t.Fatalf("Expected different labels to derive different keys")
+		}
+
+		// The public side can derive the same child public key.
+		pubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := pubkey.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = pubkey.Add(*TaggedHash("signing", serialized[:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		childPubkey, err := signing.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !childPubkey.IsEqual(pubkey) {
+			t.Fatalf("Expected %s == %s", childPubkey, pubkey)
+		}
+	}
+	_, err := new(SchnorrKeyPair).DeriveTagged("signing")
+	if err == nil {
+		t.Errorf("Deriving from a zeroed keypair should fail")
+	}
+}
+
+func TestSchnorrSignatureSplit(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < loopsN; i++ {
+		serialized := SerializedSchnorrSignature{}
+		r.Read(serialized[:])
+		signature := DeserializeSchnorrSignature(&serialized)
+		sigR, sigS := signature.Split()
+		if !bytes.Equal(sigR[:], serialized[:32]) || !bytes.Equal(sigS[:], serialized[32:]) {
+			t.Fatalf("Expected %x || %x == %s", sigR, sigS, serialized)
+		}
+		if !SchnorrSignatureFromRS(sigR, sigS).IsEqual(signature) {
+			t.Fatalf("Expected SchnorrSignatureFromRS to be the inverse of Split")
+		}
+	}
+}
+
+func TestSchnorrKeyPair_Matches(t *testing.T) {
+	r := rand.New(rand.NewSource(8))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		other := mustSchnorrKeyPair(t, r)
+		pubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherPubkey, err := other.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !keypair.Matches(pubkey) {
+			t.Fatalf("Expected the keypair to match its own public key")
+		}
+		if keypair.Matches(otherPubkey) {
+			t.Fatalf("Expected the keypair not to match another public key")
+		}
+		if keypair.Matches(nil) || keypair.Matches(new(SchnorrPublicKey)) {
+			t.Fatalf("Expected the keypair not to match a nil/zeroed public key")
+		}
+		if new(SchnorrKeyPair).Matches(pubkey) {
+			t.Fatalf("Expected a zeroed keypair not to match")
+		}
+	}
+}
+
+func TestECDSAVerifyDetailed(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	hash := Hash{}
+	r.Read(hash[:])
+	privkey := mustECDSAPrivateKey(t, r)
+	pubkey, err := privkey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := privkey.ECDSASign(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pubkey.ECDSAVerifyDetailed(&hash, signature)
+	if err != nil {
+		t.Fatalf("Expected a valid signature, got: '%s'", err)
+	}
+
+	otherHash := hash
+	otherHash[0] ^= 1
+	err = pubkey.ECDSAVerifyDetailed(&otherHash, signature)
+	if !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("Expected ErrSignatureMismatch, got: '%v'", err)
+	}
+
+	serialized := signature.Serialize()
+	highS := new(big.Int).Sub(Secp256k1Order, new(big.Int).SetBytes(serialized[32:]))
+	highSBytes := intTo32Bytes(highS)
+	copy(serialized[32:], highSBytes[:])
+	highSSignature, err := DeserializeECDSASignature(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pubkey.ECDSAVerifyDetailed(&hash, highSSignature)
+	if !errors.Is(err, ErrSignatureHighS) {
+		t.Errorf("Expected ErrSignatureHighS, got: '%v'", err)
+	}
+
+	zeroSignature, err := DeserializeECDSASignature(&SerializedECDSASignature{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pubkey.ECDSAVerifyDetailed(&hash, zeroSignature)
+	if !errors.Is(err, ErrSignatureZeroComponent) {
+		t.Errorf("Expected ErrSignatureZeroComponent, got: '%v'", err)
+	}
+
+	err = new(ECDSAPublicKey).ECDSAVerifyDetailed(&hash, signature)
+	if !errors.Is(err, errNonInitializedKey) {
+		t.Errorf("Expected errNonInitializedKey, got: '%v'", err)
+	}
+}
+
+func TestHalfOrder(t *testing.T) {
+	expected := new(big.Int).Rsh(Secp256k1Order, 1)
+	if intTo32Bytes(expected) != HalfOrder {
+		t.Fatalf("Expected HalfOrder to be %x, got %x", intTo32Bytes(expected), HalfOrder)
+	}
+	plusOne := intTo32Bytes(new(big.Int).Add(expected, big.NewInt(1)))
+	if !IsScalarLowHalf(HalfOrder) || IsScalarLowHalf(plusOne) {
+		t.Fatalf("Expected HalfOrder to be the biggest low scalar")
+	}
+	if !IsScalarLowHalf([32]byte{}) {
+		t.Fatalf("Expected zero to be a low scalar")
+	}
+	order := intTo32Bytes(Secp256k1Order)
+	if IsScalarLowHalf(order) {
+		t.Fatalf("Expected the group order not to be a low scalar")
+	}
+
+	// Signatures created by libsecp256k1 are always low-S.
+	r := rand.New(rand.NewSource(11))
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		hash := Hash{}
+		r.Read(hash[:])
+		signature, err := privkey.ECDSASign(&hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sigS [32]byte
+		copy(sigS[:], signature.Serialize()[32:])
+		if !IsScalarLowHalf(sigS) {
+			t.Fatalf("Expected a low-S signature, got: %s", signature)
+		}
+	}
+}
+
+type constantReader byte
+
+func (r constantReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestGenerateBrokenRandom(t *testing.T) {
+	defer func() { randomReader = crand.Reader }()
+
+	// A reader that always returns zeros never gives a valid private key.
+	randomReader = constantReader(0)
+	_, err := GenerateSchnorrKeyPair()
+	if err == nil {
+		t.Errorf("Expected GenerateSchnorrKeyPair to fail with a broken random source")
+	}
+	_, err = GenerateECDSAPrivateKey()
+	if err == nil {
+		t.Errorf("Expected GenerateECDSAPrivateKey to fail with a broken random source")
+	}
+
+	// A short read should be an error, not a nil signature with a nil error.
+	keypair, err := DeserializeSchnorrPrivateKey((*SerializedPrivateKey)(fastGenerateTweak(t, rand.New(rand.NewSource(12)))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := DeserializeECDSAPrivateKey(keypair.SerializePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	randomReader = bytes.NewReader(make([]byte, 16))
+	signature, err := keypair.SchnorrSign(&Hash{})
+	if err == nil || signature != nil {
+		t.Errorf("Expected SchnorrSign to fail on a short read, got: %v, %v", signature, err)
+	}
+	randomReader = bytes.NewReader(make([]byte, 16))
+	ecdsaSignature, err := ecdsaKey.ECDSASign(&Hash{})
+	if err == nil || ecdsaSignature != nil {
+		t.Errorf("Expected ECDSASign to fail on a short read, got: %v, %v", ecdsaSignature, err)
+	}
+	randomReader = bytes.NewReader(make([]byte, 16))
+	_, err = GenerateSchnorrKeyPair()
+	if err == nil {
+		t.Errorf("Expected GenerateSchnorrKeyPair to fail on a short read")
+	}
+
+	randomReader = crand.Reader
+	_, err = GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Errorf("Expected GenerateSchnorrKeyPair to succeed, got: '%s'", err)
+	}
+}
+
+func TestSchnorrSignWithAuxRand(t *testing.T) {
+	// BIP-340 test vector 0 uses the zero auxiliary randomness.
+	keypair, err := DeserializeSchnorrPrivateKeyFromSlice(decodeHex("0000000000000000000000000000000000000000000000000000000000000003"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := DeserializeSchnorrSignatureFromSlice(decodeHex("E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := keypair.SchnorrSignWithAuxRand(&Hash{}, &[32]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signature.IsEqual(expected) {
+		t.Fatalf("Expected %s, got %s", expected, signature)
+	}
+
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{1, 2, 3}
+	deterministic, err := keypair.SchnorrSignWithAuxRand(&hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := keypair.SchnorrSignWithAuxRand(&hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deterministic.IsEqual(again) {
+		t.Fatalf("Expected signing with nil auxiliary randomness to be deterministic: %s != %s", deterministic, again)
+	}
+	if !pubkey.SchnorrVerify(&hash, deterministic) {
+		t.Fatalf("Failed verifying a signature created with nil auxiliary randomness")
+	}
+}
+
+func TestReverseScalar(t *testing.T) {
+	one := [32]byte{31: 1}
+	reversed := ReverseScalar(one)
+	if reversed != [32]byte{0: 1} {
+		t.Fatalf("Expected the little endian 1, got %x", reversed)
+	}
+	r := rand.New(rand.NewSource(13))
+	for i := 0; i < loopsN; i++ {
+		scalar := *fastGenerateTweak(t, r)
+		reversed := ReverseScalar(scalar)
+		for j := range scalar {
+			if scalar[j] != reversed[31-j] {
+				t.Fatalf("Expected %x to be the reverse of %x", reversed, scalar)
+			}
+		}
+		if ReverseScalar(reversed) != scalar {
+			t.Fatalf("Expected reversing twice to return the original scalar")
+		}
+	}
+}
+
+func TestPrivateKeyFromInt(t *testing.T) {
+	one, err := PrivateKeyFromUint64(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *one.SerializePrivateKey() != (SerializedPrivateKey{31: 1}) {
+		t.Fatalf("Expected the private key 1, got %s", one)
+	}
+	maxUint64, err := PrivateKeyFromUint64(^uint64(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).SetBytes(maxUint64.SerializePrivateKey()[:]).Uint64() != ^uint64(0) {
+		t.Fatalf("Expected the private key 2^64-1, got %s", maxUint64)
+	}
+	_, err = PrivateKeyFromUint64(0)
+	if err == nil {
+		t.Errorf("Expected the private key 0 to fail")
+	}
+
+	tests := []struct {
+		v     *big.Int
+		valid bool
+	}{
+		{big.NewInt(1), true},
+		{big.NewInt(2), true},
+		{new(big.Int).Sub(Secp256k1Order, big.NewInt(1)), true},
+		{big.NewInt(0), false},
+		{big.NewInt(-1), false},
+		{Secp256k1Order, false},
+		{new(big.Int).Add(Secp256k1Order, big.NewInt(1)), false},
+		{new(big.Int).Lsh(big.NewInt(1), 256), false},
+	}
+	for i, test := range tests {
+		key, err := PrivateKeyFromBigInt(test.v)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("Test '%d': expected %s to be an invalid private key", i, test.v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test '%d': %s", i, err)
+		}
+		if intTo32Bytes(test.v) != *key.SerializePrivateKey() {
+			t.Errorf("Test '%d': expected %x, got %s", i, intTo32Bytes(test.v), key)
+		}
+	}
+}
+
+func TestRawScalarArithmetic(t *testing.T) {
+	r := rand.New(rand.NewSource(14))
+	bigOrZero := func() [32]byte {
+		if r.Intn(10) == 0 {
+			return [32]byte{}
+		}
+		var scalar [32]byte
+		r.Read(scalar[:])
+		return scalar
+	}
+	for i := 0; i < loopsN; i++ {
+		a, b := bigOrZero(), bigOrZero()
+		aBig := new(big.Int).SetBytes(a[:])
+		bBig := new(big.Int).SetBytes(b[:])
+
+		expected := new(big.Int).Add(aBig, bBig)
+		if result := ScalarAdd(a, b); intTo32Bytes(expected.Mod(expected, Secp256k1Order)) != result {
+			t.Fatalf("ScalarAdd(%x, %x): expected %x, got %x", a, b, intTo32Bytes(expected), result)
+		}
+		expected = new(big.Int).Mul(aBig, bBig)
+		if result := ScalarMul(a, b); intTo32Bytes(expected.Mod(expected, Secp256k1Order)) != result {
+			t.Fatalf("ScalarMul(%x, %x): expected %x, got %x", a, b, intTo32Bytes(expected), result)
+		}
+		expected = new(big.Int).Neg(aBig)
+		if result := ScalarNegate(a); intTo32Bytes(expected.Mod(expected, Secp256k1Order)) != result {
+			t.Fatalf("ScalarNegate(%x): expected %x, got %x", a, intTo32Bytes(expected), result)
+		}
+		expected = new(big.Int).ModInverse(new(big.Int).Mod(aBig, Secp256k1Order), Secp256k1Order)
+		if expected == nil {
+			expected = new(big.Int)
+		}
+		if result := ScalarInverse(a); intTo32Bytes(expected) != result {
+			t.Fatalf("ScalarInverse(%x): expected %x, got %x", a, intTo32Bytes(expected), result)
+		}
+	}
+
+	// Adding a scalar to its negation gives zero.
+	a := *fastGenerateTweak(t, r)
+	if ScalarAdd(a, ScalarNegate(a)) != ([32]byte{}) {
+		t.Fatalf("Expected a + -a to be zero")
+	}
+}
+
+func TestVerifyRecordStream(t *testing.T) {
+	r := rand.New(rand.NewSource(15))
+	const n = 50
+	stream := bytes.Buffer{}
+	for i := 0; i < n; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		pubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := Hash{}
+		r.Read(hash[:])
+		signature := mustSchnorrSign(t, keypair, &hash)
+		_, err = pubkey.WriteTo(&stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream.Write(hash[:])
+		_, err = signature.WriteTo(&stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	records := stream.Bytes()
+
+	index, err := VerifyRecordStream(bytes.NewReader(records))
+	if err != nil || index != -1 {
+		t.Fatalf("Expected all the records to be valid, got: %d, %v", index, err)
+	}
+	index, err = VerifyRecordStream(bytes.NewReader(nil))
+	if err != nil || index != -1 {
+		t.Fatalf("Expected an empty stream to be valid, got: %d, %v", index, err)
+	}
+
+	// Corrupt the hash of record 17
+	corrupted := append([]byte{}, records...)
+	corrupted[17*schnorrRecordSize+SerializedSchnorrPublicKeySize] ^= 1
+	index, err = VerifyRecordStream(bytes.NewReader(corrupted))
+	if err != nil || index != 17 {
+		t.Fatalf("Expected record 17 to fail, got: %d, %v", index, err)
+	}
+
+	index, err = VerifyRecordStream(bytes.NewReader(records[:len(records)-1]))
+	if err == nil || index != -1 {
+		t.Fatalf("Expected an error and no failing record on a truncated record, got: %d, %v", index, err)
+	}
+}
+
+func TestAddSchnorrPublicKeys(t *testing.T) {
+	r := rand.New(rand.NewSource(16))
+	for i := 0; i < loopsN; i++ {
+		keys := make([]*SchnorrPublicKey, 1+i%4)
+		expected := new(big.Int)
+		for j := range keys {
+			keypair := mustSchnorrKeyPair(t, r)
+			var wasOdd bool
+			var err error
+			keys[j], wasOdd, err = keypair.schnorrPublicKeyInternal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The x-only key is lifted to even Y, which is the negated private key if the full key is odd.
+			secret := new(big.Int).SetBytes(keypair.SerializePrivateKey()[:])
+			if wasOdd {
+				secret.Sub(Secp256k1Order, secret)
+			}
+			expected.Add(expected, secret)
+		}
+		expected.Mod(expected, Secp256k1Order)
+		expectedKeypair, err := PrivateKeyFromBigInt(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedPubkey, err := expectedKeypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum, err := AddSchnorrPublicKeys(keys...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sum.IsEqual(expectedPubkey) {
+			t.Fatalf("Expected %s == %s", sum, expectedPubkey)
+		}
+
+		if len(keys) >= 2 {
+			tweak := fastGenerateTweak(t, r)
+			ab, err := SessionKey(keys[0], keys[1], *tweak)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ba, err := SessionKey(keys[1], keys[0], *tweak)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ab.IsEqual(ba) {
+				t.Fatalf("Expected the session key not to depend on the order: %s != %s", ab, ba)
+			}
+		}
+	}
+
+	_, err := AddSchnorrPublicKeys()
+	if err == nil {
+		t.Errorf("Expected an error when adding no keys")
+	}
+	_, err = AddSchnorrPublicKeys(new(SchnorrPublicKey))
+	if err == nil {
+		t.Errorf("Expected an error when adding a zeroed key")
+	}
+
+	// P + -P is the point at infinity.
+	privkey := mustECDSAPrivateKey(t, r)
+	negated := intTo32Bytes(new(big.Int).Sub(Secp256k1Order, new(big.Int).SetBytes(privkey.Serialize()[:])))
+	negatedPrivkey, err := DeserializeECDSAPrivateKey((*SerializedPrivateKey)(&negated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := privkey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	negatedPubkey, err := negatedPrivkey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = combineECDSAPublicKeys([]*ECDSAPublicKey{pubkey, negatedPubkey})
+	if err == nil {
+		t.Errorf("Expected an error when the sum is the point at infinity")
+	}
+}
+
+func TestCanonicalizeECDSASignature(t *testing.T) {
+	r := rand.New(rand.NewSource(17))
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		hash := Hash{}
+		r.Read(hash[:])
+		signature, err := privkey.ECDSASign(&hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		canonical := signature.Serialize()[:]
+
+		der := signature.SerializeDER()
+		parsed, err := DeserializeECDSASignatureDER(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.IsEqual(signature) {
+			t.Fatalf("Expected DER round trip: %s != %s", parsed, signature)
+		}
+
+		// The high-S twin of the signature
+		serialized := signature.Serialize()
+		highS := intTo32Bytes(new(big.Int).Sub(Secp256k1Order, new(big.Int).SetBytes(serialized[32:])))
+		copy(serialized[32:], highS[:])
+		highSSignature, err := DeserializeECDSASignature(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signature.IsHighS() || !highSSignature.IsHighS() {
+			t.Fatalf("Expected only the twin signature to be high-S")
+		}
+
+		for _, input := range [][]byte{canonical, der, serialized[:], highSSignature.SerializeDER()} {
+			result, err := CanonicalizeECDSASignature(input)
+			if err != nil {
+				t.Fatalf("Failed canonicalizing %x: '%s'", input, err)
+			}
+			if !bytes.Equal(result, canonical) {
+				t.Fatalf("Expected %x to be canonicalized to %x, got %x", input, canonical, result)
+			}
+		}
+
+		if !highSSignature.NormalizeS() || highSSignature.NormalizeS() || !highSSignature.IsEqual(signature) {
+			t.Fatalf("Expected NormalizeS to normalize the signature once")
+		}
+	}
+
+	for _, invalid := range [][]byte{nil, {0x30}, decodeHex("3006020100020101"), make([]byte, 64), make([]byte, 65)} {
+		_, err := CanonicalizeECDSASignature(invalid)
+		if err == nil {
+			t.Errorf("Expected an error canonicalizing %x", invalid)
+		}
+	}
+}
+
+func TestECDSAMul(t *testing.T) {
+	r := rand.New(rand.NewSource(19))
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		pubkey, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweak := fastGenerateTweak(t, r)
+		expected := new(big.Int).Mul(new(big.Int).SetBytes(privkey.Serialize()[:]), new(big.Int).SetBytes(tweak[:]))
+		expected.Mod(expected, Secp256k1Order)
+
+		err = privkey.Mul(*tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if intTo32Bytes(expected) != *privkey.Serialize() {
+			t.Fatalf("Expected %x, got %s", intTo32Bytes(expected), privkey)
+		}
+		err = pubkey.Mul(*tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedPubkey, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pubkey.IsEqual(expectedPubkey) {
+			t.Fatalf("Expected %s == %s", pubkey, expectedPubkey)
+		}
+
+		if privkey.Mul([32]byte{}) == nil || pubkey.Mul([32]byte{}) == nil {
+			t.Fatalf("Expected multiplying by zero to fail")
+		}
+		order := intTo32Bytes(Secp256k1Order)
+		if privkey.Mul(order) == nil || pubkey.Mul(order) == nil {
+			t.Fatalf("Expected multiplying by the group order to fail")
+		}
+		// A failed Mul leaves the keys unchanged and usable.
+		if intTo32Bytes(expected) != *privkey.Serialize() {
+			t.Fatalf("Expected a failed Mul to not change the private key, got %s", privkey)
+		}
+		stillExpected, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pubkey.Serialize(); err != nil {
+			t.Fatal(err)
+		}
+		if !pubkey.IsEqual(stillExpected) {
+			t.Fatalf("Expected a failed Mul to not change the public key, got %s", pubkey)
+		}
+	}
+	if new(ECDSAPrivateKey).Mul([32]byte{1}) == nil || new(ECDSAPublicKey).Mul([32]byte{1}) == nil {
+		t.Fatalf("Expected multiplying a zeroed key to fail")
+	}
+}
+
+func TestGenerateNonce(t *testing.T) {
+	r := rand.New(rand.NewSource(20))
+	keypair := mustSchnorrKeyPair(t, r)
+	otherKeypair := mustSchnorrKeyPair(t, r)
+	msg := Hash{}
+	r.Read(msg[:])
+	sessionID := [32]byte{}
+	r.Read(sessionID[:])
+
+	secret, point, err := GenerateNonce(sessionID, keypair, &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noncePrivateKey, err := DeserializeECDSAPrivateKey((*SerializedPrivateKey)(&secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPoint, err := noncePrivateKey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !point.IsEqual(expectedPoint) {
+		t.Fatalf("Expected the nonce point to be the nonce secret times the generator")
+	}
+
+	again, _, err := GenerateNonce(sessionID, keypair, &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != secret {
+		t.Fatalf("Expected the nonce to be deterministic")
+	}
+
+	otherSessionID := sessionID
+	otherSessionID[0] ^= 1
+	otherMsg := msg
+	otherMsg[0] ^= 1
+	seen := map[[32]byte]bool{secret: true}
+	for _, test := range []struct {
+		sessionID [32]byte
+		key       *SchnorrKeyPair
+		msg       *Hash
+	}{
+		{otherSessionID, keypair, &msg},
+		{sessionID, otherKeypair, &msg},
+		{sessionID, keypair, &otherMsg},
+	} {
+		nonce, _, err := GenerateNonce(test.sessionID, test.key, test.msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[nonce] {
+			t.Fatalf("Expected every input to change the nonce")
+		}
+		seen[nonce] = true
+	}
+
+	_, _, err = GenerateNonce(sessionID, new(SchnorrKeyPair), &msg)
+	if err == nil {
+		t.Errorf("Expected an error for a zeroed keypair")
+	}
+}
+
+func TestVerifyPinned(t *testing.T) {
+	r := rand.New(rand.NewSource(21))
+	keypair := mustSchnorrKeyPair(t, r)
+	attacker := mustSchnorrKeyPair(t, r)
+	pinned, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	attackerPubkey, err := attacker.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+	signature := mustSchnorrSign(t, keypair, &hash)
+	attackerSignature := mustSchnorrSign(t, attacker, &hash)
+
+	claimed := *pinned
+	valid, err := pinned.VerifyPinned(&hash, signature, &claimed)
+	if err != nil || !valid {
+		t.Fatalf("Expected a valid signature, got: %t, %v", valid, err)
+	}
+	valid, err = pinned.VerifyPinned(&hash, attackerSignature, &claimed)
+	if err != nil || valid {
+		t.Fatalf("Expected an invalid signature, got: %t, %v", valid, err)
+	}
+	// The attacker's signature is valid for the claimed key, but that's not the pinned key.
+	valid, err = pinned.VerifyPinned(&hash, attackerSignature, attackerPubkey)
+	if !errors.Is(err, ErrPublicKeyMismatch) || valid {
+		t.Fatalf("Expected ErrPublicKeyMismatch, got: %t, %v", valid, err)
+	}
+	_, err = pinned.VerifyPinned(&hash, signature, nil)
+	if !errors.Is(err, ErrPublicKeyMismatch) {
+		t.Fatalf("Expected ErrPublicKeyMismatch for a nil claimed key, got: %v", err)
+	}
+	_, err = new(SchnorrPublicKey).VerifyPinned(&hash, signature, new(SchnorrPublicKey))
+	if err == nil {
+		t.Fatalf("Expected an error for a zeroed pinned key")
+	}
+}
+
+func TestSchnorrVerifyTimed(t *testing.T) {
+	r := rand.New(rand.NewSource(37))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+	signature := mustSchnorrSign(t, keypair, &hash)
+	valid, dur := pubkey.SchnorrVerifyTimed(&hash, signature)
+	if !valid {
+		t.Fatalf("Expected a valid signature")
+	}
+	if dur <= 0 {
+		t.Fatalf("Expected a positive duration, got: %s", dur)
+	}
+	hash[0] ^= 1
+	valid, _ = pubkey.SchnorrVerifyTimed(&hash, signature)
+	if valid {
+		t.Fatalf("Expected an invalid signature for a modified hash")
+	}
+}
+
+func TestNewECDSAPublicKey(t *testing.T) {
+	r := rand.New(rand.NewSource(38))
+	fieldPrime, _ := new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		pubkey, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y := pubkey.Coordinates()
+		// y^2 = x^3 + 7 (mod p)
+		lhs := new(big.Int).Exp(y, big.NewInt(2), fieldPrime)
+		rhs := new(big.Int).Exp(x, big.NewInt(3), fieldPrime)
+		rhs.Add(rhs, big.NewInt(7)).Mod(rhs, fieldPrime)
+		if lhs.Cmp(rhs) != 0 {
+			t.Fatalf("The coordinates (%x, %x) aren't on the curve", x, y)
+		}
+		compressed, err := pubkey.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		xBytes := intTo32Bytes(x)
+		if !bytes.Equal(compressed[1:], xBytes[:]) || compressed[0] != byte(2+y.Bit(0)) {
+			t.Fatalf("The coordinates (%x, %x) don't match the compressed key '%s'", x, y, compressed)
+		}
+		fromCoordinates, err := NewECDSAPublicKey(x, y)
+		if err != nil {
+			t.Fatalf("Failed creating a public key from valid coordinates: '%s'", err)
+		}
+		if !fromCoordinates.IsEqual(pubkey) {
+			t.Fatalf("Expected '%s' to equal '%s'", fromCoordinates, pubkey)
+		}
+		notOnCurve := new(big.Int).Add(y, big.NewInt(1))
+		_, err = NewECDSAPublicKey(x, notOnCurve)
+		if err == nil {
+			t.Fatalf("Expected an error for a point that isn't on the curve: %x, %x", x, notOnCurve)
+		}
+		// y+p is the same field element, but isn't a valid coordinate.
+		_, err = NewECDSAPublicKey(x, new(big.Int).Add(y, fieldPrime))
+		if err == nil {
+			t.Fatalf("Expected an error for a coordinate bigger than the field size")
+		}
+	}
+	_, err := NewECDSAPublicKey(big.NewInt(0), big.NewInt(0))
+	if err == nil {
+		t.Fatalf("Expected an error for (0, 0)")
+	}
+	_, err = NewECDSAPublicKey(big.NewInt(-1), big.NewInt(1))
+	if err == nil {
+		t.Fatalf("Expected an error for a negative coordinate")
+	}
+	_, err = NewECDSAPublicKey(nil, big.NewInt(1))
+	if err == nil {
+		t.Fatalf("Expected an error for a nil coordinate")
+	}
+	x, y := new(ECDSAPublicKey).Coordinates()
+	if x != nil || y != nil {
+		t.Fatalf("Expected nil coordinates for an uninitialized key")
+	}
+}
+
+func TestDebugSchnorrVerify(t *testing.T) {
+	r := rand.New(rand.NewSource(40))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+	signature := mustSchnorrSign(t, keypair, &hash)
+	valid, R, s, challenge, err := DebugSchnorrVerify(pubkey, &hash, signature)
+	if err != nil || !valid {
+		t.Fatalf("Expected a valid signature, got: %t, %v", valid, err)
+	}
+	if !SchnorrSignatureFromRS(*R, *s).IsEqual(signature) {
+		t.Fatalf("R and S don't match the signature '%s'", signature)
+	}
+	expected, err := SchnorrChallenge(R, pubkey, &hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge != expected {
+		t.Fatalf("Expected challenge '%x', got '%x'", expected, challenge)
+	}
+
+	// With the wrong hash R and S stay the same, but the challenge changes.
+	wrongHash := hash
+	wrongHash[0] ^= 1
+	valid, wrongR, wrongS, wrongChallenge, err := DebugSchnorrVerify(pubkey, &wrongHash, signature)
+	if err != nil || valid {
+		t.Fatalf("Expected an invalid signature, got: %t, %v", valid, err)
+	}
+	if *wrongR != *R || *wrongS != *s || wrongChallenge == challenge {
+		t.Fatalf("Expected only the challenge to change for a different hash")
+	}
+
+	_, _, _, _, err = DebugSchnorrVerify(new(SchnorrPublicKey), &hash, signature)
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized public key")
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	signsPerSec, verifiesPerSec := Benchmark(20 * time.Millisecond)
+	if signsPerSec <= 0 || verifiesPerSec <= 0 {
+		t.Fatalf("Expected positive rates, got %f signs/sec and %f verifies/sec", signsPerSec, verifiesPerSec)
+	}
+	// A zero duration still does at least one of each.
+	signsPerSec, verifiesPerSec = Benchmark(0)
+	if signsPerSec <= 0 || verifiesPerSec <= 0 {
+		t.Fatalf("Expected positive rates, got %f signs/sec and %f verifies/sec", signsPerSec, verifiesPerSec)
+	}
+}
+
+func TestSignWithContext(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("transfer 10 coins")
+	aad := []byte("channel 1")
+	signature, err := keypair.SignWithContext(msg, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pubkey.VerifyWithContext(msg, aad, signature) {
+		t.Fatalf("Expected the signature to be valid with the same associated data")
+	}
+	if pubkey.VerifyWithContext(msg, []byte("channel 2"), signature) {
+		t.Fatalf("Expected the signature to be invalid with different associated data")
+	}
+	if pubkey.VerifyWithContext(msg, nil, signature) {
+		t.Fatalf("Expected the signature to be invalid without the associated data")
+	}
+	// Moving a byte from the message to the associated data must not give the same hash.
+	if pubkey.VerifyWithContext(msg[:len(msg)-1], append(msg[len(msg)-1:], aad...), signature) {
+		t.Fatalf("Expected the signature to be invalid when the message boundary moves")
+	}
+	if pubkey.SchnorrVerify(TaggedHash(aadTag, msg, aad), signature) {
+		t.Fatalf("Expected the signature to not verify over the hash without the length prefix")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("the source failed")
+}
+
+func TestSchnorrSignWithMixedAux(t *testing.T) {
+	r := rand.New(rand.NewSource(43))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+
+	a, b := [32]byte{}, [32]byte{}
+	r.Read(a[:])
+	r.Read(b[:])
+	mixed := [32]byte{}
+	for i := range mixed {
+		mixed[i] = a[i] ^ b[i]
+	}
+	signature, err := keypair.SchnorrSignWithMixedAux(&hash, bytes.NewReader(a[:]), failingReader{}, bytes.NewReader(b[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pubkey.SchnorrVerify(&hash, signature) {
+		t.Fatalf("Expected a valid signature")
+	}
+	expected, err := keypair.SchnorrSignWithAuxRand(&hash, &mixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signature.IsEqual(expected) {
+		t.Fatalf("Expected the aux rand to be the XOR of the sources, '%s' != '%s'", signature, expected)
+	}
+
+	_, err = keypair.SchnorrSignWithMixedAux(&hash, failingReader{}, bytes.NewReader(a[:31]))
+	if err == nil {
+		t.Fatalf("Expected an error when all the sources fail")
+	}
+	_, err = keypair.SchnorrSignWithMixedAux(&hash)
+	if err == nil {
+		t.Fatalf("Expected an error without any sources")
+	}
+}
+
+func TestSchnorrKeyPairExport(t *testing.T) {
+	r := rand.New(rand.NewSource(44))
+	sawOdd, sawEven := false, false
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		privateKey, pubkey, isOdd, err := keypair.Export()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *privateKey != *keypair.SerializePrivateKey() {
+			t.Fatalf("Expected the exported private key to be '%s', got '%s'", keypair.SerializePrivateKey(), privateKey)
+		}
+		if !keypair.Matches(pubkey) {
+			t.Fatalf("The exported public key '%s' doesn't match the keypair", pubkey)
+		}
+		ecdsaPrivkey, err := DeserializeECDSAPrivateKey(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ecdsaPubkey, err := ecdsaPrivkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressed, err := ecdsaPubkey.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isOdd != (compressed[0] == 0x03) {
+			t.Fatalf("Expected isOdd to be %t for '%s'", compressed[0] == 0x03, compressed)
+		}
+		sawOdd = sawOdd || isOdd
+		sawEven = sawEven || !isOdd
+	}
+	if !sawOdd || !sawEven {
+		t.Fatalf("Expected both parities in %d keys", loopsN)
+	}
+	_, _, _, err := new(SchnorrKeyPair).Export()
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized keypair")
+	}
+}
+
+func TestSliceFunctionsRejectWrongLengths(t *testing.T) {
+	functions := map[string]func([]byte) error{
+		"Hash.SetBytes": func(data []byte) error {
+			return new(Hash).SetBytes(data)
+		},
+		"DeserializeSchnorrPrivateKeyFromSlice": func(data []byte) error {
+			_, err := DeserializeSchnorrPrivateKeyFromSlice(data)
+			return err
+		},
+		"DeserializeECDSAPrivateKeyFromSlice": func(data []byte) error {
+			_, err := DeserializeECDSAPrivateKeyFromSlice(data)
+			return err
+		},
+		"DeserializeSchnorrPubKey": func(data []byte) error {
+			_, err := DeserializeSchnorrPubKey(data)
+			return err
+		},
+		"DeserializeECDSAPubKey": func(data []byte) error {
+			_, err := DeserializeECDSAPubKey(data)
+			return err
+		},
+		"DeserializeSchnorrSignatureFromSlice": func(data []byte) error {
+			_, err := DeserializeSchnorrSignatureFromSlice(data)
+			return err
+		},
+		"DeserializeECDSASignatureFromSlice": func(data []byte) error {
+			_, err := DeserializeECDSASignatureFromSlice(data)
+			return err
+		},
+		"DeserializeECDSASignatureDER": func(data []byte) error {
+			_, err := DeserializeECDSASignatureDER(data)
+			return err
+		},
+		"DeserializeECDSARecoverableSignatureFromSlice": func(data []byte) error {
+			_, err := DeserializeECDSARecoverableSignatureFromSlice(data)
+			return err
+		},
+		"CanonicalizeECDSASignature": func(data []byte) error {
+			_, err := CanonicalizeECDSASignature(data)
+			return err
+		},
+	}
+	for name, function := range functions {
+		for _, length := range []int{0, 31, 33} {
+			// An all zeros 33 byte compressed public key has a valid length but isn't a valid key, so it errors too.
+			data := make([]byte, length)
+			if err := function(data); err == nil {
+				t.Errorf("%s: expected an error for a %d byte slice", name, length)
+			}
+		}
+		if err := function(nil); err == nil {
+			t.Errorf("%s: expected an error for a nil slice", name)
+		}
+	}
+}
+
+func TestECDHXOnly(t *testing.T) {
+	r := rand.New(rand.NewSource(46))
+	for i := 0; i < loopsN; i++ {
+		a := mustSchnorrKeyPair(t, r)
+		b := mustSchnorrKeyPair(t, r)
+		aPub, err := a.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bPub, err := b.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		aShared, err := a.ECDHXOnly(bPub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bShared, err := b.ECDHXOnly(aPub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !aShared.IsEqual(bShared) {
+			t.Fatalf("Expected both sides to get the same secret, '%s' != '%s'", aShared, bShared)
+		}
+
+		// The secret is the x coordinate of a*b*G.
+		product := new(big.Int).Mul(new(big.Int).SetBytes(a.SerializePrivateKey()[:]), new(big.Int).SetBytes(b.SerializePrivateKey()[:]))
+		product.Mod(product, Secp256k1Order)
+		productBytes := SerializedPrivateKey(intTo32Bytes(product))
+		sharedKey, err := DeserializeSchnorrPrivateKey(&productBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sharedPub, err := sharedKey.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := sharedPub.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *aShared != Hash(*serialized) {
+			t.Fatalf("Expected the secret to be '%s', got '%s'", serialized, aShared)
+		}
+	}
+	_, err := new(SchnorrKeyPair).ECDHXOnly(new(SchnorrPublicKey))
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized keypair")
+	}
+}
+
+func TestSchnorrRespond(t *testing.T) {
+	r := rand.New(rand.NewSource(47))
+	one := [32]byte{31: 1}
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		pubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := Hash{}
+		r.Read(hash[:])
+		sessionID := [32]byte{}
+		r.Read(sessionID[:])
+		nonce, noncePoint, err := GenerateNonce(sessionID, keypair, &hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		serializedNonce, err := noncePoint.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if serializedNonce[0] == 0x03 {
+			nonce = ScalarNegate(nonce)
+		}
+		R := [32]byte{}
+		copy(R[:], serializedNonce[1:])
+		challenge, err := SchnorrChallenge(&R, pubkey, &hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := SchnorrRespond(nonce, challenge, keypair)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature := SchnorrSignatureFromRS(R, response)
+		if !pubkey.SchnorrVerify(&hash, signature) {
+			t.Fatalf("Expected the signature '%s' to be valid", signature)
+		}
+		if !signature.HasNonce(noncePoint) {
+			t.Fatalf("Expected the signature '%s' to have the nonce '%s'", signature, noncePoint)
+		}
+		otherNonce := *noncePoint
+		err = otherNonce.Add(one)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signature.HasNonce(&otherNonce) {
+			t.Fatalf("Expected the signature '%s' to not have the nonce '%s'", signature, &otherNonce)
+		}
+		if signature.HasNonce(nil) || signature.HasNonce(new(ECDSAPublicKey)) {
+			t.Fatalf("Expected HasNonce to be false for a nil or uninitialized nonce")
+		}
+	}
+	keypair, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = SchnorrRespond([32]byte{}, one, keypair)
+	if err == nil {
+		t.Fatalf("Expected an error for a zero nonce")
+	}
+	_, err = SchnorrRespond(intTo32Bytes(Secp256k1Order), one, keypair)
+	if err == nil {
+		t.Fatalf("Expected an error for a nonce equal to the group order")
+	}
+	_, err = SchnorrRespond(one, intTo32Bytes(Secp256k1Order), keypair)
+	if err == nil {
+		t.Fatalf("Expected an error for a challenge equal to the group order")
+	}
+	_, err = SchnorrRespond(one, one, new(SchnorrKeyPair))
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized keypair")
+	}
+}
+
+func TestSchnorrVerifyRaw(t *testing.T) {
+	r := rand.New(rand.NewSource(48))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedPubkey, err := pubkey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+	signature := mustSchnorrSign(t, keypair, &hash)
+	rawPubkey := [32]byte(*serializedPubkey)
+	rawHash := [32]byte(hash)
+	rawSig := [64]byte(*signature.Serialize())
+	if !SchnorrVerifyRaw(&rawPubkey, &rawHash, &rawSig) {
+		t.Fatalf("Expected a valid signature")
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		SchnorrVerifyRaw(&rawPubkey, &rawHash, &rawSig)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected SchnorrVerifyRaw to not allocate, got %f allocations", allocs)
+	}
+
+	badHash := rawHash
+	badHash[0] ^= 1
+	if SchnorrVerifyRaw(&rawPubkey, &badHash, &rawSig) {
+		t.Fatalf("Expected an invalid signature for a modified hash")
+	}
+	// The field size isn't a valid x coordinate.
+	invalidPubkey := [32]byte{}
+	copy(invalidPubkey[:], decodeHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"))
+	if SchnorrVerifyRaw(&invalidPubkey, &rawHash, &rawSig) {
+		t.Fatalf("Expected false for an invalid public key")
+	}
+	zeroS := rawSig
+	copy(zeroS[32:], make([]byte, 32))
+	if SchnorrVerifyRaw(&rawPubkey, &rawHash, &zeroS) {
+		t.Fatalf("Expected false for a zero S")
+	}
+}
+
+func TestSchnorrSignatureNegateS(t *testing.T) {
+	r := rand.New(rand.NewSource(53))
+	for i := 0; i < loopsN; i++ {
+		serialized := SerializedSchnorrSignature{}
+		r.Read(serialized[:32])
+		s := *fastGenerateTweak(t, r)
+		copy(serialized[32:], s[:])
+		signature := DeserializeSchnorrSignature(&serialized)
+
+		negated := signature.NegateS()
+		if *signature.Serialize() != serialized {
+			t.Fatalf("NegateS shouldn't modify the signature")
+		}
+		negatedR, negatedS := negated.Split()
+		if !bytes.Equal(negatedR[:], serialized[:32]) {
+			t.Fatalf("Expected R to stay '%x', got '%x'", serialized[:32], negatedR)
+		}
+		sum := new(big.Int).Add(new(big.Int).SetBytes(s[:]), new(big.Int).SetBytes(negatedS[:]))
+		if sum.Mod(sum, Secp256k1Order).Sign() != 0 {
+			t.Fatalf("Expected S + NegateS's S to be 0 mod the order, S: %x, negated: %x", s, negatedS)
+		}
+		if !negated.NegateS().IsEqual(signature) {
+			t.Fatalf("Negating twice should give the original signature")
+		}
+	}
+	keypair, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+	signature := mustSchnorrSign(t, keypair, &hash)
+	if pubkey.SchnorrVerify(&hash, signature.NegateS()) {
+		t.Fatalf("A signature with a negated S shouldn't verify")
+	}
+}
+
+func TestProveOwnership(t *testing.T) {
+	r := rand.New(rand.NewSource(55))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := [32]byte{}
+	r.Read(challenge[:])
+	proof, err := keypair.ProveOwnership(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pubkey.VerifyOwnership(challenge, proof) {
+		t.Fatalf("Expected a valid ownership proof")
+	}
+	otherChallenge := challenge
+	otherChallenge[0] ^= 1
+	if pubkey.VerifyOwnership(otherChallenge, proof) {
+		t.Fatalf("Expected the proof to not verify for a different challenge")
+	}
+	// A plain signature over the challenge isn't an ownership proof, and the other way around.
+	hash := Hash(challenge)
+	signature := mustSchnorrSign(t, keypair, &hash)
+	if pubkey.VerifyOwnership(challenge, signature) || pubkey.SchnorrVerify(&hash, proof) {
+		t.Fatalf("Expected ownership proofs and signatures over the challenge to be domain separated")
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x00, 0x0f}, 20},
+		{make([]byte, 32), 256},
+	}
+	for _, test := range tests {
+		if got := leadingZeroBits(test.data); got != test.expected {
+			t.Errorf("Expected %d leading zero bits in %x, got %d", test.expected, test.data, got)
+		}
+	}
+
+	r := rand.New(rand.NewSource(58))
+	sawZeros := false
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		pubkey, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		schnorrPubkey, err := pubkey.ToSchnorr()
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, _ := pubkey.Coordinates()
+		expected := 256 - x.BitLen()
+		if pubkey.LeadingZeroBits() != expected || schnorrPubkey.LeadingZeroBits() != expected {
+			t.Fatalf("Expected %d leading zero bits for x = %x, got %d and %d", expected, x, pubkey.LeadingZeroBits(), schnorrPubkey.LeadingZeroBits())
+		}
+		sawZeros = sawZeros || expected > 0
+	}
+	if !sawZeros {
+		t.Fatalf("Expected some keys to have leading zero bits")
+	}
+	if new(SchnorrPublicKey).LeadingZeroBits() != 0 || new(ECDSAPublicKey).LeadingZeroBits() != 0 {
+		t.Fatalf("Expected 0 for uninitialized keys")
+	}
+}
+
+func TestHashDeriveKey(t *testing.T) {
+	r := rand.New(rand.NewSource(60))
+	secret := Hash{}
+	r.Read(secret[:])
+	info := []byte("go-secp256k1 test")
+
+	// Compute HKDF-SHA256 by hand from the RFC 5869 definition.
+	extract := hmac.New(sha256.New, make([]byte, sha256.Size))
+	extract.Write(secret[:])
+	prk := extract.Sum(nil)
+	var expected, previous []byte
+	for i := byte(1); len(expected) < 100; i++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(previous)
+		expand.Write(info)
+		expand.Write([]byte{i})
+		previous = expand.Sum(nil)
+		expected = append(expected, previous...)
+	}
+
+	for _, length := range []int{1, 16, 32, 33, 100} {
+		key, err := secret.DeriveKey(info, length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(key, expected[:length]) {
+			t.Fatalf("Expected a %d byte key '%x', got '%x'", length, expected[:length], key)
+		}
+	}
+	otherInfo, err := secret.DeriveKey([]byte("another purpose"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(otherInfo, expected[:32]) {
+		t.Fatalf("Expected a different info to give a different key")
+	}
+	for _, length := range []int{0, -1, maxDerivedKeyLength + 1} {
+		_, err := secret.DeriveKey(info, length)
+		if err == nil {
+			t.Fatalf("Expected an error for a key length of %d", length)
+		}
+	}
+	_, err = secret.DeriveKey(nil, maxDerivedKeyLength)
+	if err != nil {
+		t.Fatalf("Expected the maximum key length to work: %s", err)
+	}
+}
+
+func TestSchnorrPublicKeyIsLiftable(t *testing.T) {
+	r := rand.New(rand.NewSource(62))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		pubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pubkey.IsLiftable() {
+			t.Fatalf("Expected '%s' to be liftable", pubkey)
+		}
+	}
+	// Around half of the x coordinates aren't on the curve, those can't be deserialized at all.
+	notLiftable := 0
+	for i := 0; i < loopsN; i++ {
+		x := [32]byte{}
+		r.Read(x[:])
+		pubkey, err := DeserializeSchnorrPubKey(x[:])
+		if err != nil {
+			notLiftable++
+			continue
+		}
+		if !pubkey.IsLiftable() {
+			t.Fatalf("Expected the deserialized '%s' to be liftable", pubkey)
+		}
+	}
+	if notLiftable == 0 || notLiftable == loopsN {
+		t.Fatalf("Expected some random x coordinates to be on the curve and some not, %d of %d weren't", notLiftable, loopsN)
+	}
+	if new(SchnorrPublicKey).IsLiftable() || (*SchnorrPublicKey)(nil).IsLiftable() {
+		t.Fatalf("Expected uninitialized and nil keys to not be liftable")
+	}
+}
+
+func TestCachedPublicKey(t *testing.T) {
+	r := rand.New(rand.NewSource(64))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		// The cache has to follow tweaks.
+		err := keypair.Add(*fastGenerateTweak(t, r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		privkey, err := DeserializeECDSAPrivateKey(keypair.SerializePrivateKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		derived, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := derived.ToSchnorr()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached := keypair.CachedPublicKey(); !cached.IsEqual(expected) {
+			t.Fatalf("Expected the cached public key to be '%s', got '%s'", expected, cached)
+		}
+	}
+	if new(SchnorrKeyPair).CachedPublicKey() != nil {
+		t.Fatalf("Expected nil for an uninitialized keypair")
+	}
+}
+
+func TestHasModule(t *testing.T) {
+	for _, name := range []string{"recovery", "schnorrsig", "extrakeys"} {
+		if !HasModule(name) {
+			t.Errorf("Expected the %s module to be compiled in", name)
+		}
+	}
+	for _, name := range []string{"ecdh", "musig", "", "Recovery", "something"} {
+		if HasModule(name) {
+			t.Errorf("Expected HasModule(%q) to be false", name)
+		}
+	}
+}
+
+func TestSignAccountable(t *testing.T) {
+	r := rand.New(rand.NewSource(67))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		hash := Hash{}
+		r.Read(hash[:])
+		signature, pubkey, err := keypair.SignAccountable(&hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !keypair.Matches(pubkey) {
+			t.Fatalf("Expected the returned public key '%s' to be the keypair's", pubkey)
+		}
+		if !pubkey.SchnorrVerify(&hash, signature) {
+			t.Fatalf("Expected the signature '%s' to verify against '%s'", signature, pubkey)
+		}
+	}
+	_, _, err := new(SchnorrKeyPair).SignAccountable(&Hash{})
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized keypair")
+	}
+}
+
+func TestSerializeFull(t *testing.T) {
+	r := rand.New(rand.NewSource(68))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		serialized := keypair.SerializeFull()
+		restored, err := DeserializeFullUnchecked(&serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *restored.SerializePrivateKey() != *keypair.SerializePrivateKey() {
+			t.Fatalf("Expected the restored private key to be '%s', got '%s'", keypair.SerializePrivateKey(), restored.SerializePrivateKey())
+		}
+		if restored.keypair != keypair.keypair {
+			t.Fatalf("Expected the restored keypair to be identical to the original")
+		}
+		hash := Hash{}
+		r.Read(hash[:])
+		signature := mustSchnorrSign(t, restored, &hash)
+		if !restored.CachedPublicKey().SchnorrVerify(&hash, signature) || !keypair.CachedPublicKey().SchnorrVerify(&hash, signature) {
+			t.Fatalf("Expected a signature from the restored keypair to verify")
+		}
+
+		notOnCurve := serialized
+		notOnCurve[95] ^= 1
+		_, err = DeserializeFullUnchecked(&notOnCurve)
+		if err == nil {
+			t.Fatalf("Expected an error for a public key that isn't on the curve")
+		}
+	}
+	zeroKey := [SerializedFullKeyPairSize]byte{}
+	_, err := DeserializeFullUnchecked(&zeroKey)
+	if err == nil {
+		t.Fatalf("Expected an error for a zero keypair")
+	}
+	keypair, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	overflowing := keypair.SerializeFull()
+	orderBytes := intTo32Bytes(Secp256k1Order)
+	copy(overflowing[:32], orderBytes[:])
+	_, err = DeserializeFullUnchecked(&overflowing)
+	if err == nil {
+		t.Fatalf("Expected an error for a private key equal to the group order")
+	}
+	if new(SchnorrKeyPair).SerializeFull() != zeroKey {
+		t.Fatalf("Expected all zeros for an uninitialized keypair")
+	}
+}
+
+func TestVerifyBatchBuffer(t *testing.T) {
+	r := rand.New(rand.NewSource(69))
+	const n = 10
+	pubkeys := make([]*SchnorrPublicKey, n)
+	hashes := make([]*Hash, n)
+	signatures := make([]*SchnorrSignature, n)
+	for i := 0; i < n; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		var err error
+		pubkeys[i], err = keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[i] = &Hash{}
+		r.Read(hashes[i][:])
+		signatures[i] = mustSchnorrSign(t, keypair, hashes[i])
+	}
+	// Make the 4th signature invalid.
+	signatures[3] = signatures[4]
+
+	buf, err := SerializeBatchForVerify(pubkeys, hashes, signatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) != n*schnorrRecordSize {
+		t.Fatalf("Expected a %d byte buffer, got %d", n*schnorrRecordSize, len(buf))
+	}
+	// The buffer has the same format as a record stream.
+	failed, err := VerifyRecordStream(bytes.NewReader(buf))
+	if err != nil || failed != 3 {
+		t.Fatalf("Expected the record stream to fail at record 3, got: %d, %v", failed, err)
+	}
+	results, err := VerifyBatchBuffer(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, valid := range results {
+		if valid != (i != 3) {
+			t.Fatalf("Expected record %d to be %t, got %t", i, i != 3, valid)
+		}
+	}
+
+	results, err = VerifyBatchBuffer(nil)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("Expected no results for an empty buffer, got: %v, %v", results, err)
+	}
+	_, err = VerifyBatchBuffer(buf[:len(buf)-1])
+	if err == nil {
+		t.Fatalf("Expected an error for a truncated buffer")
+	}
+	_, err = SerializeBatchForVerify(pubkeys, hashes[:n-1], signatures)
+	if err == nil {
+		t.Fatalf("Expected an error for mismatched lengths")
+	}
+	pubkeys[0] = new(SchnorrPublicKey)
+	_, err = SerializeBatchForVerify(pubkeys, hashes, signatures)
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized public key")
+	}
+}
+
+func TestIsEquivocation(t *testing.T) {
+	r := rand.New(rand.NewSource(71))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashA, hashB := Hash{}, Hash{}
+	r.Read(hashA[:])
+	r.Read(hashB[:])
+	sigA := mustSchnorrSign(t, keypair, &hashA)
+	sigB := mustSchnorrSign(t, keypair, &hashB)
+	// Signing the same hash twice gives different signatures (with different aux rand), but isn't equivocation.
+	sigA2 := mustSchnorrSign(t, keypair, &hashA)
+
+	tests := []struct {
+		name     string
+		hashA    *Hash
+		sigA     *SchnorrSignature
+		hashB    *Hash
+		sigB     *SchnorrSignature
+		expected bool
+	}{
+		{"two hashes", &hashA, sigA, &hashB, sigB, true},
+		{"same hash", &hashA, sigA, &hashA, sigA2, false},
+		{"invalid first signature", &hashA, sigB, &hashB, sigB, false},
+		{"invalid second signature", &hashA, sigA, &hashB, sigA, false},
+	}
+	for _, test := range tests {
+		equivocation, err := IsEquivocation(pubkey, test.hashA, test.sigA, test.hashB, test.sigB)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if equivocation != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, equivocation)
+		}
+	}
+	_, err = IsEquivocation(new(SchnorrPublicKey), &hashA, sigA, &hashB, sigB)
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized public key")
+	}
+	_, err = IsEquivocation(pubkey, &hashA, sigA, nil, sigB)
+	if err == nil {
+		t.Fatalf("Expected an error for a nil hash")
+	}
+}
+
+func TestTweakPublicKey(t *testing.T) {
+	r := rand.New(rand.NewSource(72))
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		pubkey, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweak := *fastGenerateTweak(t, r)
+		tweakPoint, err := TweakPublicKey(tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweaked := *pubkey
+		err = tweaked.Add(tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum, err := combineECDSAPublicKeys([]*ECDSAPublicKey{pubkey, tweakPoint})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sum.IsEqual(&tweaked) {
+			t.Fatalf("Expected P + TweakPublicKey(t) == P + t*G, '%s' != '%s'", sum, &tweaked)
+		}
+	}
+	_, err := TweakPublicKey([32]byte{})
+	if err == nil {
+		t.Fatalf("Expected an error for a zero tweak")
+	}
+	_, err = TweakPublicKey(intTo32Bytes(Secp256k1Order))
+	if err == nil {
+		t.Fatalf("Expected an error for a tweak equal to the group order")
+	}
+}
+
+func TestVerifyAgainstAggregate(t *testing.T) {
+	r := rand.New(rand.NewSource(73))
+	const n = 4
+	cosigners := make([]*SchnorrPublicKey, n)
+	// The aggregate private key is the sum of the private keys of the even-Y public keys.
+	aggregateKey := new(big.Int)
+	for i := 0; i < n; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		var isOdd bool
+		var err error
+		cosigners[i], isOdd, err = keypair.schnorrPublicKeyInternal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey := new(big.Int).SetBytes(keypair.SerializePrivateKey()[:])
+		if isOdd {
+			privateKey.Sub(Secp256k1Order, privateKey)
+		}
+		aggregateKey.Add(aggregateKey, privateKey)
+	}
+	aggregateKey.Mod(aggregateKey, Secp256k1Order)
+	aggregateKeyPair, err := PrivateKeyFromBigInt(aggregateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+	signature := mustSchnorrSign(t, aggregateKeyPair, &hash)
+
+	valid, err := VerifyAgainstAggregate(cosigners, &hash, signature)
+	if err != nil || !valid {
+		t.Fatalf("Expected the signature to verify against the aggregate, got: %t, %v", valid, err)
+	}
+	reversed := []*SchnorrPublicKey{cosigners[3], cosigners[2], cosigners[1], cosigners[0]}
+	valid, err = VerifyAgainstAggregate(reversed, &hash, signature)
+	if err != nil || !valid {
+		t.Fatalf("Expected the order of the cosigners to not matter, got: %t, %v", valid, err)
+	}
+	valid, err = VerifyAgainstAggregate(cosigners[:n-1], &hash, signature)
+	if err != nil || valid {
+		t.Fatalf("Expected the signature to not verify against a subset of the cosigners, got: %t, %v", valid, err)
+	}
+	_, err = VerifyAgainstAggregate(nil, &hash, signature)
+	if err == nil {
+		t.Fatalf("Expected an error for an empty set of cosigners")
+	}
+	_, err = VerifyAgainstAggregate([]*SchnorrPublicKey{cosigners[0], nil}, &hash, signature)
+	if err == nil {
+		t.Fatalf("Expected an error for a nil cosigner")
+	}
+}
+
+func TestSignCount(t *testing.T) {
+	keypair, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keypair.SignCount() != 0 {
+		t.Fatalf("Expected a new keypair to have a zero sign count, got: %d", keypair.SignCount())
+	}
+	hash := Hash{1}
+	_ = mustSchnorrSign(t, keypair, &hash)
+	_, err = keypair.SchnorrSignWithAuxRand(&hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = keypair.SignAccountable(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keypair.SignCount() != 3 {
+		t.Fatalf("Expected a sign count of 3, got: %d", keypair.SignCount())
+	}
+
+	const goroutines, signsPerGoroutine = 8, 20
+	errs := make(chan error, goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			for j := 0; j < signsPerGoroutine; j++ {
+				_, err := keypair.SchnorrSign(&hash)
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < goroutines; i++ {
+		err := <-errs
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if keypair.SignCount() != 3+goroutines*signsPerGoroutine {
+		t.Fatalf("Expected a sign count of %d, got: %d", 3+goroutines*signsPerGoroutine, keypair.SignCount())
+	}
+
+	derived, err := DeserializeSchnorrPrivateKey(keypair.SerializePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived.SignCount() != 0 {
+		t.Fatalf("Expected a deserialized keypair to have a zero sign count, got: %d", derived.SignCount())
+	}
+	derived, err = keypair.DeriveTagged("child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived.SignCount() != 0 {
+		t.Fatalf("Expected a derived keypair to have a zero sign count, got: %d", derived.SignCount())
+	}
+	if keypair.SignCount() != 3+goroutines*signsPerGoroutine {
+		t.Fatalf("Expected deriving to not change the parent's sign count, got: %d", keypair.SignCount())
+	}
+	serializedPrivateKey, _, _, err := keypair.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := DeserializeSchnorrPrivateKey(serializedPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported.SignCount() != 0 {
+		t.Fatalf("Expected an exported keypair to have a zero sign count, got: %d", exported.SignCount())
+	}
+	_, err = new(SchnorrKeyPair).SchnorrSign(&hash)
+	if err == nil {
+		t.Fatalf("Expected an error signing with an uninitialized keypair")
+	}
+}
+
+func TestParseECDSASignatureLenient(t *testing.T) {
+	r := rand.New(rand.NewSource(175))
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		hash := Hash{}
+		r.Read(hash[:])
+		signature, err := privkey.ECDSASign(&hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized := signature.Serialize()
+		der := signature.SerializeDER()
+		highS := *serialized
+		highSBytes := intTo32Bytes(new(big.Int).Sub(Secp256k1Order, new(big.Int).SetBytes(serialized[32:])))
+		copy(highS[32:], highSBytes[:])
+		// Pad R with an extra leading zero, which strict DER rejects.
+		rLen := int(der[3])
+		padded := append([]byte{0x30, der[1] + 1, 0x02, der[3] + 1, 0x00}, der[4:]...)
+		if _, err := DeserializeECDSASignatureDER(padded); err == nil {
+			t.Fatalf("Expected the strict parser to reject the padded signature %x (R length %d)", padded, rLen)
+		}
+
+		tests := []struct {
+			input   []byte
+			repairs int
+		}{
+			{serialized[:], 0},
+			{der, 0},
+			{highS[:], 1},
+			{padded, 1},
+		}
+		for _, test := range tests {
+			parsed, repairs, err := ParseECDSASignatureLenient(test.input)
+			if err != nil {
+				t.Fatalf("Failed parsing %x leniently: '%s'", test.input, err)
+			}
+			if len(repairs) != test.repairs {
+				t.Fatalf("Expected %d repairs for %x, got: %v", test.repairs, test.input, repairs)
+			}
+			if !parsed.IsEqual(signature) {
+				t.Fatalf("Expected %x to be parsed as %s, got %s", test.input, signature, parsed)
+			}
+		}
+	}
+
+	for _, input := range [][]byte{nil, {0x30}, make([]byte, SerializedECDSASignatureSize), {0x30, 0x06, 0x02, 0x01, 0x00, 0x02, 0x01, 0x01}} {
+		_, _, err := ParseECDSASignatureLenient(input)
+		if err == nil {
+			t.Fatalf("Expected an error parsing %x leniently", input)
+		}
+	}
+}
+
+func TestSelectPublicKey(t *testing.T) {
+	r := rand.New(rand.NewSource(176))
+	keys := make([]*ECDSAPublicKey, 5)
+	for i := range keys {
+		privkey := mustECDSAPrivateKey(t, r)
+		var err error
+		keys[i], err = privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range keys {
+		selected := SelectPublicKey(keys, i)
+		if !selected.IsEqual(keys[i]) {
+			t.Fatalf("Expected SelectPublicKey to return key %d: %s, got: %s", i, keys[i], selected)
+		}
+		if selected == keys[i] {
+			t.Fatalf("Expected SelectPublicKey to return a copy of the key")
+		}
+	}
+	for _, index := range []int{-1, len(keys), len(keys) + 1} {
+		if selected := SelectPublicKey(keys, index); selected != nil {
+			t.Fatalf("Expected nil for an out of range index %d, got: %s", index, selected)
+		}
+	}
+	if selected := SelectPublicKey(nil, 0); selected != nil {
+		t.Fatalf("Expected nil for an empty set of keys, got: %s", selected)
+	}
+	if selected := SelectPublicKey([]*ECDSAPublicKey{keys[0], nil}, 0); selected != nil {
+		t.Fatalf("Expected nil when one of the keys is nil, got: %s", selected)
+	}
+	if selected := SelectPublicKey([]*ECDSAPublicKey{keys[0], {}}, 0); selected != nil {
+		t.Fatalf("Expected nil when one of the keys is uninitialized, got: %s", selected)
+	}
+}
+
+func TestDecompressPoint(t *testing.T) {
+	r := rand.New(rand.NewSource(178))
+	for i := 0; i < loopsN; i++ {
+		privkey := mustECDSAPrivateKey(t, r)
+		pubkey, err := privkey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := pubkey.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var x [32]byte
+		copy(x[:], serialized[1:])
+		oddY := serialized[0] == 0x03
+		decompressed, err := DecompressPoint(x, oddY)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decompressed.IsEqual(pubkey) {
+			t.Fatalf("Expected %s, got %s", pubkey, decompressed)
+		}
+		negated, err := DecompressPoint(x, !oddY)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = pubkey.Negate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !negated.IsEqual(pubkey) {
+			t.Fatalf("Expected the opposite parity to decompress to the negated key %s, got %s", pubkey, negated)
+		}
+	}
+
+	// 5 isn't the x coordinate of any point, since 5^3+7 isn't a square modulo the field size.
+	notOnCurve := [32]byte{31: 5}
+	fieldSize := [32]byte{}
+	copy(fieldSize[:], decodeHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"))
+	for _, x := range [][32]byte{notOnCurve, fieldSize} {
+		for _, oddY := range []bool{false, true} {
+			_, err := DecompressPoint(x, oddY)
+			if err == nil {
+				t.Fatalf("Expected an error decompressing x: %x", x)
+			}
+		}
+	}
+}
+
+func TestDeriveAppKey(t *testing.T) {
+	r := rand.New(rand.NewSource(180))
+	master := mustSchnorrKeyPair(t, r)
+	_, err := master.SchnorrSign(&Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *master.SerializePrivateKey()
+
+	seen := make(map[SerializedPrivateKey]struct{})
+	for _, appID := range []string{"", "wallet", "messaging"} {
+		for epoch := uint64(0); epoch < 3; epoch++ {
+			child, err := master.DeriveAppKey(appID, epoch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := master.DeriveAppKey(appID, epoch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *child.SerializePrivateKey() != *again.SerializePrivateKey() {
+				t.Fatalf("Expected DeriveAppKey to be deterministic for %q epoch %d", appID, epoch)
+			}
+			if _, ok := seen[*child.SerializePrivateKey()]; ok {
+				t.Fatalf("Expected a distinct key for %q epoch %d", appID, epoch)
+			}
+			seen[*child.SerializePrivateKey()] = struct{}{}
+			if child.SignCount() != 0 {
+				t.Fatalf("Expected a derived keypair to have a zero sign count, got: %d", child.SignCount())
+			}
+
+			var serializedEpoch [8]byte
+			binary.BigEndian.PutUint64(serializedEpoch[:], epoch)
+			expected, err := DeserializeSchnorrPrivateKey(&original)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = expected.Add(*TaggedHash("go-secp256k1/appkey", []byte(appID), serializedEpoch[:]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *child.SerializePrivateKey() != *expected.SerializePrivateKey() {
+				t.Fatalf("Expected the child of %q epoch %d to be the master plus the tagged hash tweak", appID, epoch)
+			}
+		}
+	}
+	if *master.SerializePrivateKey() != original {
+		t.Fatalf("Expected DeriveAppKey to not modify the master keypair")
+	}
+	_, err = new(SchnorrKeyPair).DeriveAppKey("wallet", 0)
+	if err == nil {
+		t.Fatalf("Expected an error deriving from an uninitialized keypair")
+	}
+}
+
+func TestAddReduced(t *testing.T) {
+	r := rand.New(rand.NewSource(182))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		expected, err := DeserializeSchnorrPrivateKey(keypair.SerializePrivateKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweak := *fastGenerateTweak(t, r)
+		// Every other tweak is shifted up by the group order, so AddReduced has to reduce it.
+		unreduced := tweak
+		if i%2 == 0 {
+			shifted := new(big.Int).Add(new(big.Int).SetBytes(tweak[:]), Secp256k1Order)
+			if shifted.BitLen() <= 256 {
+				unreduced = intTo32Bytes(shifted)
+			}
+		}
+
+		applied, err := keypair.AddReduced(unreduced)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if applied != tweak {
+			t.Fatalf("Expected the applied tweak to be %x, got %x", tweak, applied)
+		}
+		err = expected.Add(tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *keypair.SerializePrivateKey() != *expected.SerializePrivateKey() {
+			t.Fatalf("Expected AddReduced to add the reduced tweak")
+		}
+	}
+
+	keypair, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := intTo32Bytes(Secp256k1Order)
+	rejecting, err := DeserializeSchnorrPrivateKey(keypair.SerializePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rejecting.Add(order); err == nil {
+		t.Fatalf("Expected Add to reject the group order as a tweak")
+	}
+	before, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := keypair.AddReduced(order)
+	if err != nil || applied != [32]byte{} {
+		t.Fatalf("Expected the group order to be reduced to zero, got: %x, %v", applied, err)
+	}
+	after, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.IsEqual(before) {
+		t.Fatalf("Expected adding a zero tweak to not change the public key")
+	}
+	_, err = new(SchnorrKeyPair).AddReduced(order)
+	if err == nil {
+		t.Fatalf("Expected an error adding to an uninitialized keypair")
+	}
+}
+
+func TestTaggedHashWithPrecomputedMidstate(t *testing.T) {
+	// The midstates libsecp256k1 hardcodes for the BIP-340 tags
+	tests := []struct {
+		tag      string
+		midstate []byte
+	}{
+		{"BIP0340/nonce", decodeHex("46615b35f4bfbff79f8dc67183627ab3602171805735866121a29e5468b07b4c")},
+		{"BIP0340/aux", decodeHex("24dd32194eba7e70ca0fabb90fa3166d3afbe4b14c44df974aac2739249e850a")},
+		{"BIP0340/challenge", decodeHex("9cecba112392538111679112d1627e0f97c87550003cc76590f6116433e9b66a")},
+	}
+	r := rand.New(rand.NewSource(183))
+	for _, test := range tests {
+		midstate := TagMidstate(test.tag)
+		if !bytes.Equal(midstate[:], test.midstate) {
+			t.Fatalf("Expected the midstate of %q to be %x, got %x", test.tag, test.midstate, midstate)
+		}
+		for _, length := range []int{0, 1, 55, 64, 65, 200} {
+			data := make([]byte, length)
+			r.Read(data)
+			expected := TaggedHash(test.tag, data)
+			got := TaggedHashWithPrecomputedMidstate(midstate, data[:length/2], data[length/2:])
+			if !got.IsEqual(expected) {
+				t.Fatalf("Expected the tagged hash of %q over %x to be %s, got %s", test.tag, data, expected, got)
+			}
+		}
+	}
+}
+
+func TestIsWeak(t *testing.T) {
+	weak := []*big.Int{
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(1 << 62),
+		new(big.Int).Sub(Secp256k1Order, big.NewInt(1)),
+		new(big.Int).Sub(Secp256k1Order, big.NewInt(1<<40)),
+		new(big.Int).SetBytes(decodeHex("c4bbcb1fbec99d65bf59d85c8cb62ee2db963f0fe106f483d9afa73bd4e39a8a")), // SHA256("correct horse battery staple")
+	}
+	for _, privateKey := range weak {
+		keypair, err := PrivateKeyFromBigInt(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !keypair.IsWeak() {
+			t.Fatalf("Expected %x to be weak", privateKey)
+		}
+	}
+
+	r := rand.New(rand.NewSource(184))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		if keypair.IsWeak() {
+			t.Fatalf("Expected a random key %s to not be weak", keypair)
+		}
+	}
+	notWeak := []*big.Int{
+		new(big.Int).Lsh(big.NewInt(1), 64),
+		new(big.Int).Sub(Secp256k1Order, new(big.Int).Lsh(big.NewInt(1), 64)),
+	}
+	for _, privateKey := range notWeak {
+		keypair, err := PrivateKeyFromBigInt(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keypair.IsWeak() {
+			t.Fatalf("Expected %x to not be weak", privateKey)
+		}
+	}
+	if new(SchnorrKeyPair).IsWeak() {
+		t.Fatalf("Expected an uninitialized keypair to not be weak")
+	}
+}
+
+func TestVerifyAny(t *testing.T) {
+	r := rand.New(rand.NewSource(186))
+	pubkeys := make([]*SchnorrPublicKey, 3)
+	keypairs := make([]*SchnorrKeyPair, len(pubkeys))
+	for i := range pubkeys {
+		var err error
+		keypairs[i] = mustSchnorrKeyPair(t, r)
+		pubkeys[i], err = keypairs[i].SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+	for i, keypair := range keypairs {
+		sig := mustSchnorrSign(t, keypair, &hash)
+		matched, ok := VerifyAny(pubkeys, &hash, sig)
+		if !ok || matched != i {
+			t.Fatalf("Expected the signature to match key %d, got: %d, %t", i, matched, ok)
+		}
+		matched, ok = VerifyAny([]*SchnorrPublicKey{nil, {}, pubkeys[i]}, &hash, sig)
+		if !ok || matched != 2 {
+			t.Fatalf("Expected nil and uninitialized keys to be skipped, got: %d, %t", matched, ok)
+		}
+		matched, ok = VerifyAny(append(pubkeys[:i:i], pubkeys[i+1:]...), &hash, sig)
+		if ok || matched != -1 {
+			t.Fatalf("Expected no match without the signing key, got: %d, %t", matched, ok)
+		}
+	}
+	matched, ok := VerifyAny(nil, &hash, ZeroSchnorrSignature())
+	if ok || matched != -1 {
+		t.Fatalf("Expected no match for an empty key set, got: %d, %t", matched, ok)
+	}
+}
+
+func TestEnvelopeSigHash(t *testing.T) {
+	payload := []byte("hello")
+	serialized := append(decodeHex("01"+"fffffffffffffffe"+"0000000000000005"), payload...)
+	first := sha256.Sum256(serialized)
+	expected := Hash(sha256.Sum256(first[:]))
+	if hash := EnvelopeSigHash(1, -2, payload); !hash.IsEqual(&expected) {
+		t.Fatalf("Expected the envelope hash to be %s, got %s", expected, hash)
+	}
+
+	hashes := []*Hash{
+		EnvelopeSigHash(1, 1600000000, payload),
+		EnvelopeSigHash(2, 1600000000, payload),
+		EnvelopeSigHash(1, 1600000001, payload),
+		EnvelopeSigHash(1, 1600000000, []byte("hello!")),
+		EnvelopeSigHash(1, 1600000000, nil),
+	}
+	for i := range hashes {
+		for j := i + 1; j < len(hashes); j++ {
+			if hashes[i].IsEqual(hashes[j]) {
+				t.Fatalf("Expected envelopes %d and %d to have different hashes", i, j)
+			}
+		}
+	}
+	if !EnvelopeSigHash(1, 0, nil).IsEqual(EnvelopeSigHash(1, 0, []byte{})) {
+		t.Fatalf("Expected a nil and an empty payload to have the same hash")
+	}
+}
+
+func TestDeriveViewKey(t *testing.T) {
+	r := rand.New(rand.NewSource(188))
+	seen := make(map[SerializedPrivateKey]struct{})
+	for i := 0; i < loopsN; i++ {
+		spend := mustSchnorrKeyPair(t, r)
+		original := *spend.SerializePrivateKey()
+		view, err := spend.DeriveViewKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := spend.DeriveViewKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *view.SerializePrivateKey() != *again.SerializePrivateKey() {
+			t.Fatalf("Expected DeriveViewKey to be deterministic")
+		}
+		expected := SerializedPrivateKey(*TaggedHash("go-secp256k1/viewkey", original[:]))
+		if *view.SerializePrivateKey() != expected {
+			t.Fatalf("Expected the view key to be %s, got %s", expected, view.SerializePrivateKey())
+		}
+		if *spend.SerializePrivateKey() != original || *view.SerializePrivateKey() == original {
+			t.Fatalf("Expected the view key to be a new keypair")
+		}
+		if _, ok := seen[expected]; ok {
+			t.Fatalf("Expected a distinct view key for every spend key")
+		}
+		seen[expected] = struct{}{}
+	}
+	_, err := new(SchnorrKeyPair).DeriveViewKey()
+	if err == nil {
+		t.Fatalf("Expected an error deriving from an uninitialized keypair")
+	}
+}
+
+func TestDeserializeECDSAPubKeyCompressedOnly(t *testing.T) {
+	r := rand.New(rand.NewSource(189))
+	privkey := mustECDSAPrivateKey(t, r)
+	pubkey, err := privkey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := pubkey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := DeserializeECDSAPubKeyCompressedOnly(compressed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.IsEqual(pubkey) {
+		t.Fatalf("Expected %s, got %s", pubkey, parsed)
+	}
+
+	uncompressed, err := pubkey.serializeUncompressed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hybrid := *uncompressed
+	hybrid[0] = 0x06 | uncompressed[64]&1
+	wrongPrefix := *compressed
+	wrongPrefix[0] = 0x04
+	notOnCurve := append([]byte{0x02}, make([]byte, 31)...)
+	notOnCurve = append(notOnCurve, 5)
+	for _, data := range [][]byte{nil, uncompressed[:], hybrid[:], wrongPrefix[:], compressed[:32], notOnCurve} {
+		_, err := DeserializeECDSAPubKeyCompressedOnly(data)
+		if err == nil {
+			t.Fatalf("Expected an error deserializing %x", data)
+		}
+	}
+}
+
+func TestSchnorrSignBatch(t *testing.T) {
+	r := rand.New(rand.NewSource(190))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := make([]*Hash, 20)
+	for i := range hashes {
+		hashes[i] = &Hash{}
+		r.Read(hashes[i][:])
+	}
+	// The same hash twice should still get two different signatures.
+	hashes[1] = hashes[0]
+	signatures, err := keypair.SchnorrSignBatch(hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signatures) != len(hashes) {
+		t.Fatalf("Expected %d signatures, got %d", len(hashes), len(signatures))
+	}
+	for i := range signatures {
+		if !pubkey.SchnorrVerify(hashes[i], signatures[i]) {
+			t.Fatalf("Expected signature %d to be valid", i)
+		}
+	}
+	if signatures[0].IsEqual(signatures[1]) {
+		t.Fatalf("Expected every signature to use its own auxiliary randomness")
+	}
+	if keypair.SignCount() != uint64(len(hashes)) {
+		t.Fatalf("Expected a sign count of %d, got %d", len(hashes), keypair.SignCount())
+	}
+
+	signatures, err = keypair.SchnorrSignBatch(nil)
+	if err != nil || len(signatures) != 0 {
+		t.Fatalf("Expected no signatures for an empty batch, got: %v, %v", signatures, err)
+	}
+	if _, err := keypair.SchnorrSignBatch([]*Hash{hashes[0], nil}); err == nil {
+		t.Fatalf("Expected an error for a nil hash")
+	}
+	if _, err := new(SchnorrKeyPair).SchnorrSignBatch(hashes); err == nil {
+		t.Fatalf("Expected an error signing with an uninitialized keypair")
+	}
+}
+
+func TestEstimateEntropy(t *testing.T) {
+	repeating := SerializedPrivateKey{}
+	for i := range repeating {
+		repeating[i] = byte(i % 4)
+	}
+	distinct := SerializedPrivateKey{}
+	for i := range distinct {
+		distinct[i] = byte(i)
+	}
+	tests := []struct {
+		key      SerializedPrivateKey
+		expected float64
+	}{
+		{SerializedPrivateKey{}, 0},
+		{SerializedPrivateKey{31: 1}, -(31.0/32*math.Log2(31.0/32) + 1.0/32*math.Log2(1.0/32))},
+		{repeating, 2},
+		{distinct, 5},
+	}
+	for _, test := range tests {
+		entropy := test.key.EstimateEntropy()
+		if math.Abs(entropy-test.expected) > 1e-9 {
+			t.Fatalf("Expected the entropy of %s to be %f, got %f", test.key, test.expected, entropy)
+		}
+		if entropy >= LowEntropyThreshold && test.expected < LowEntropyThreshold {
+			t.Fatalf("Expected %s to be below the threshold", test.key)
+		}
+	}
+
+	r := rand.New(rand.NewSource(192))
+	for i := 0; i < loopsN; i++ {
+		key := SerializedPrivateKey(*fastGenerateTweak(t, r))
+		if entropy := key.EstimateEntropy(); entropy < LowEntropyThreshold {
+			t.Fatalf("Expected a random key %s to be above the threshold, got %f", key, entropy)
+		}
+	}
+}
+
+func TestSetVerifyObserver(t *testing.T) {
+	r := rand.New(rand.NewSource(193))
+	keypair := mustSchnorrKeyPair(t, r)
+	schnorrPubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaPrivkey, err := DeserializeECDSAPrivateKey(keypair.SerializePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaPubkey, err := ecdsaPrivkey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+	schnorrSignature := mustSchnorrSign(t, keypair, &hash)
+	ecdsaSignature, err := ecdsaPrivkey.ECDSASign(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherHash := Hash{1}
+
+	var results []bool
+	SetVerifyObserver(func(valid bool, dur time.Duration) {
+		if dur < 0 {
+			t.Errorf("Expected a non negative duration, got %s", dur)
+		}
+		results = append(results, valid)
+	})
+	defer SetVerifyObserver(nil)
+	schnorrPubkey.SchnorrVerify(&hash, schnorrSignature)
+	schnorrPubkey.SchnorrVerify(&otherHash, schnorrSignature)
+	ecdsaPubkey.ECDSAVerify(&hash, ecdsaSignature)
+	ecdsaPubkey.ECDSAVerify(&otherHash, ecdsaSignature)
+	schnorrPubkey.SchnorrVerify(&hash, ZeroSchnorrSignature())
+	expected := []bool{true, false, true, false, false}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected the observer to see %v, got %v", expected, results)
+	}
+
+	SetVerifyObserver(nil)
+	schnorrPubkey.SchnorrVerify(&hash, schnorrSignature)
+	ecdsaPubkey.ECDSAVerify(&hash, ecdsaSignature)
+	if len(results) != len(expected) {
+		t.Fatalf("Expected the observer to not be called after removing it")
+	}
+}
+
+func TestVerifyDerivationChain(t *testing.T) {
+	r := rand.New(rand.NewSource(195))
+	keypair := mustSchnorrKeyPair(t, r)
+	master, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tweaks := make([][32]byte, 5)
+	for i := range tweaks {
+		tweaks[i] = *fastGenerateTweak(t, r)
+		err = keypair.Add(tweaks[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	derived, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !VerifyDerivationChain(master, tweaks, derived) {
+		t.Fatalf("Expected the derivation chain to verify")
+	}
+	if !VerifyDerivationChain(master, nil, master) {
+		t.Fatalf("Expected an empty derivation chain to verify against the master key itself")
+	}
+	if VerifyDerivationChain(master, tweaks[:4], derived) {
+		t.Fatalf("Expected a chain missing a tweak to not verify")
+	}
+	swapped := [][32]byte{tweaks[1], tweaks[0], tweaks[2], tweaks[3], tweaks[4]}
+	if VerifyDerivationChain(master, swapped, derived) {
+		t.Fatalf("Expected a chain with the tweaks in another order to not verify")
+	}
+	invalid := append(tweaks[:len(tweaks):len(tweaks)], intTo32Bytes(Secp256k1Order))
+	if VerifyDerivationChain(master, invalid, derived) {
+		t.Fatalf("Expected a chain with an invalid tweak to not verify")
+	}
+	if VerifyDerivationChain(nil, tweaks, derived) || VerifyDerivationChain(master, tweaks, &SchnorrPublicKey{}) {
+		t.Fatalf("Expected a chain with missing keys to not verify")
+	}
+	if !VerifyDerivationChain(master, tweaks, derived) {
+		t.Fatalf("Expected VerifyDerivationChain to not modify the master key")
+	}
+}
+
+func TestSchnorrSign64(t *testing.T) {
+	r := rand.New(rand.NewSource(196))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := [64]byte{}
+	r.Read(msg[:])
+	signature, err := keypair.SchnorrSign64(&msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pubkey.SchnorrVerify64(&msg, signature) {
+		t.Fatalf("Expected the signature over the 64 byte message to verify")
+	}
+	hash := Hash(sha256.Sum256(msg[:]))
+	if !pubkey.SchnorrVerify(&hash, signature) {
+		t.Fatalf("Expected the signature to be over the SHA256 of the message")
+	}
+	msg[63] ^= 1
+	if pubkey.SchnorrVerify64(&msg, signature) {
+		t.Fatalf("Expected the signature to not verify over another message")
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	err := SelfTest()
+	if err != nil {
+		t.Fatalf("The self test failed: '%s'", err)
+	}
+
+	original := selfTestSchnorrVectors[0].signature
+	defer func() { selfTestSchnorrVectors[0].signature = original }()
+	selfTestSchnorrVectors[0].signature = original[:len(original)-1] + "B"
+	err = SelfTest()
+	if err == nil {
+		t.Fatalf("Expected the self test to fail with a wrong vector")
+	}
+}
+
+func TestStructuredDataHash(t *testing.T) {
+	domainSeparator := sha256.Sum256([]byte("domain"))
+	structHash := sha256.Sum256([]byte("struct"))
+	expected := Hash(sha256.Sum256(append(append([]byte{0x19, 0x01}, domainSeparator[:]...), structHash[:]...)))
+	if hash := StructuredDataHash(domainSeparator, structHash); !hash.IsEqual(&expected) {
+		t.Fatalf("Expected the structured data hash to be %s, got %s", expected, hash)
+	}
+	if StructuredDataHash(structHash, domainSeparator).IsEqual(&expected) {
+		t.Fatalf("Expected swapping the domain separator and the struct hash to change the hash")
+	}
+}
+
+func TestKeyFromPassword(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	keypair, err := KeyFromPassword([]byte("correct horse battery staple"), salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := KeyFromPassword([]byte("correct horse battery staple"), salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *keypair.SerializePrivateKey() != *again.SerializePrivateKey() {
+		t.Fatalf("Expected KeyFromPassword to be deterministic")
+	}
+	otherSalt, err := KeyFromPassword([]byte("correct horse battery staple"), []byte("0123456789abcdeF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *keypair.SerializePrivateKey() == *otherSalt.SerializePrivateKey() {
+		t.Fatalf("Expected a different salt to give a different key")
+	}
+
+	derived := argon2.IDKey([]byte("correct horse battery staple"), salt, 3, 64*1024, 4, 64)
+	expected := intTo32Bytes(new(big.Int).Mod(new(big.Int).SetBytes(derived), Secp256k1Order))
+	if *keypair.SerializePrivateKey() != expected {
+		t.Fatalf("Expected the private key to be the Argon2id output modulo the group order %x, got %s", expected, keypair.SerializePrivateKey())
+	}
+
+	if _, err := KeyFromPassword(nil, salt); err == nil {
+		t.Fatalf("Expected an error for an empty password")
+	}
+	if _, err := KeyFromPassword([]byte("password"), salt[:15]); err == nil {
+		t.Fatalf("Expected an error for a short salt")
+	}
+}
+
+func TestVerifyAllowedHash(t *testing.T) {
+	r := rand.New(rand.NewSource(200))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := make([]*Hash, 4)
+	for i := range allowed {
+		allowed[i] = &Hash{}
+		r.Read(allowed[i][:])
+	}
+	for i, hash := range allowed {
+		sig := mustSchnorrSign(t, keypair, hash)
+		valid, err := pubkey.VerifyAllowedHash(hash, sig, allowed)
+		if err != nil || !valid {
+			t.Fatalf("Expected allowed hash %d to verify, got: %t, %v", i, valid, err)
+		}
+		other := allowed[(i+1)%len(allowed)]
+		valid, err = pubkey.VerifyAllowedHash(other, sig, allowed)
+		if err != nil || valid {
+			t.Fatalf("Expected the signature to not verify over another allowed hash, got: %t, %v", valid, err)
+		}
+	}
+
+	notAllowed := Hash{}
+	r.Read(notAllowed[:])
+	sig := mustSchnorrSign(t, keypair, &notAllowed)
+	for _, list := range [][]*Hash{allowed, nil, {nil}} {
+		valid, err := pubkey.VerifyAllowedHash(&notAllowed, sig, list)
+		if !errors.Is(err, ErrHashNotAllowed) || valid {
+			t.Fatalf("Expected ErrHashNotAllowed for a validly signed hash that isn't allowed, got: %t, %v", valid, err)
+		}
+	}
+	if _, err := new(SchnorrPublicKey).VerifyAllowedHash(allowed[0], sig, allowed); err == nil {
+		t.Fatalf("Expected an error verifying with an uninitialized public key")
+	}
+}
+
+func TestAggregateNonces(t *testing.T) {
+	r := rand.New(rand.NewSource(201))
+	nonces := make([]*ECDSAPublicKey, 3)
+	secretSum := [32]byte{}
+	for i := range nonces {
+		secret := *fastGenerateTweak(t, r)
+		secretSum = ScalarAdd(secretSum, secret)
+		var err error
+		nonces[i], err = TweakPublicKey(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	aggregate, err := AggregateNonces(nonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := TweakPublicKey(secretSum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !aggregate.IsEqual(expected) {
+		t.Fatalf("Expected the aggregate nonce to be %s, got %s", expected, aggregate)
+	}
+	reversed, err := AggregateNonces([]*ECDSAPublicKey{nonces[2], nonces[1], nonces[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reversed.IsEqual(aggregate) {
+		t.Fatalf("Expected the order of the nonces to not matter")
+	}
+
+	negated := *nonces[0]
+	err = negated.Negate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AggregateNonces([]*ECDSAPublicKey{nonces[0], &negated}); err == nil {
+		t.Fatalf("Expected an error when the nonces sum to the point at infinity")
+	}
+	if _, err := AggregateNonces(nil); err == nil {
+		t.Fatalf("Expected an error for no nonces")
+	}
+	if _, err := AggregateNonces([]*ECDSAPublicKey{nonces[0], nil}); err == nil {
+		t.Fatalf("Expected an error for a nil nonce")
+	}
+}
+
+func TestFindNonceReuse(t *testing.T) {
+	r := rand.New(rand.NewSource(202))
+	sigs := make([]*SchnorrSignature, 6)
+	for i := range sigs {
+		s := *fastGenerateTweak(t, r)
+		sigs[i] = SchnorrSignatureFromRS(*fastGenerateTweak(t, r), s)
+	}
+	if pairs := FindNonceReuse(sigs); len(pairs) != 0 {
+		t.Fatalf("Expected no nonce reuse, got %v", pairs)
+	}
+
+	reusedR, _ := sigs[1].Split()
+	sigs[3] = SchnorrSignatureFromRS(reusedR, *fastGenerateTweak(t, r))
+	sigs[5] = SchnorrSignatureFromRS(reusedR, *fastGenerateTweak(t, r))
+	sigs[4] = nil
+	expected := [][2]int{{1, 3}, {1, 5}, {3, 5}}
+	if pairs := FindNonceReuse(sigs); !reflect.DeepEqual(pairs, expected) {
+		t.Fatalf("Expected the pairs %v, got %v", expected, pairs)
+	}
+	if pairs := FindNonceReuse(nil); pairs != nil {
+		t.Fatalf("Expected no pairs for no signatures, got %v", pairs)
+	}
+}
+
+func TestDeserializeSchnorrPubKeyParseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		x        string
+		expected error
+	}{
+		{"field prime", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", ErrXOutOfRange},
+		{"all ones", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", ErrXOutOfRange},
+		// x = 5 gives y^2 = 132, which isn't a quadratic residue modulo the field prime.
+		{"non residue", "0000000000000000000000000000000000000000000000000000000000000005", ErrXNotOnCurve},
+	}
+	for _, test := range tests {
+		data := decodeHex(test.x)
+		_, err := DeserializeSchnorrPubKey(data)
+		if !errors.Is(err, test.expected) {
+			t.Fatalf("%s: expected %v, got: '%v'", test.name, test.expected, err)
+		}
+		var parseErr *XOnlyParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("%s: expected an XOnlyParseError, got: '%v'", test.name, err)
+		}
+		if !bytes.Equal(parseErr.Data[:], data) {
+			t.Fatalf("%s: expected the error to carry %x, got %s", test.name, data, parseErr.Data)
+		}
+	}
+}
+
+func TestVerifyWithAlgorithm(t *testing.T) {
+	hash := Hash{0x41, 0x6c, 0x67}
+	otherHash := Hash{0x4f, 0x74, 0x68}
+
+	schnorrKey, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schnorrPubKey, err := schnorrKey.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedSchnorrPubKey, err := schnorrPubKey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schnorrSig := mustSchnorrSign(t, schnorrKey, &hash)
+
+	ecdsaKey, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaPubKey, err := ecdsaKey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedECDSAPubKey, err := ecdsaPubKey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaSig, err := ecdsaKey.ECDSASign(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		algo      byte
+		pubkey    []byte
+		hash      *Hash
+		sig       []byte
+		expected  bool
+		expectErr bool
+	}{
+		{"valid schnorr", AlgorithmSchnorr, serializedSchnorrPubKey[:], &hash, schnorrSig.Serialize()[:], true, false},
+		{"wrong hash schnorr", AlgorithmSchnorr, serializedSchnorrPubKey[:], &otherHash, schnorrSig.Serialize()[:], false, false},
+		{"valid ecdsa", AlgorithmECDSA, serializedECDSAPubKey[:], &hash, ecdsaSig.Serialize()[:], true, false},
+		{"wrong hash ecdsa", AlgorithmECDSA, serializedECDSAPubKey[:], &otherHash, ecdsaSig.Serialize()[:], false, false},
+		{"ecdsa key as schnorr", AlgorithmSchnorr, serializedECDSAPubKey[:], &hash, schnorrSig.Serialize()[:], false, true},
+		{"schnorr key as ecdsa", AlgorithmECDSA, serializedSchnorrPubKey[:], &hash, ecdsaSig.Serialize()[:], false, true},
+		{"short signature", AlgorithmSchnorr, serializedSchnorrPubKey[:], &hash, schnorrSig.Serialize()[:63], false, true},
+		{"unknown algorithm", 0x03, serializedSchnorrPubKey[:], &hash, schnorrSig.Serialize()[:], false, true},
+	}
+	for _, test := range tests {
+		valid, err := VerifyWithAlgorithm(test.algo, test.pubkey, test.hash, test.sig)
+		if (err != nil) != test.expectErr {
+			t.Fatalf("%s: expected an error: %t, got: '%v'", test.name, test.expectErr, err)
+		}
+		if valid != test.expected {
+			t.Fatalf("%s: expected %t, got %t", test.name, test.expected, valid)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	// The master key of BIP-32 test vector 1
+	ecdsaPubKey, err := DeserializeECDSAPubKey(decodeHex("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [FingerprintSize]byte{0x34, 0x42, 0x19, 0x3e}
+	if fingerprint := ecdsaPubKey.Fingerprint(); fingerprint != expected {
+		t.Fatalf("Expected the fingerprint %x, got %x", expected, fingerprint)
+	}
+
+	schnorrPubKey, err := ecdsaPubKey.ToSchnorr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized, err := schnorrPubKey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := hash160(serialized[:])
+	if fingerprint := schnorrPubKey.Fingerprint(); !bytes.Equal(fingerprint[:], hash[:FingerprintSize]) {
+		t.Fatalf("Expected the x-only fingerprint %x, got %x", hash[:FingerprintSize], fingerprint)
+	}
+
+	if fingerprint := (&SchnorrPublicKey{}).Fingerprint(); fingerprint != [FingerprintSize]byte{} {
+		t.Fatalf("Expected an uninitialized key to have the zero fingerprint, got %x", fingerprint)
+	}
+}
+
+func TestFramedSchnorrSignature(t *testing.T) {
+	r := rand.New(rand.NewSource(207))
+	sigs := make([]*SchnorrSignature, 3)
+	buf := bytes.NewBuffer([]byte{0x00, framedSignatureMagic, 0x13})
+	for i := range sigs {
+		sigs[i] = SchnorrSignatureFromRS(*fastGenerateTweak(t, r), *fastGenerateTweak(t, r))
+		err := sigs[i].WriteFramed(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := buf.Bytes()
+	if len(log) != 3+3*FramedSchnorrSignatureSize {
+		t.Fatalf("Expected %d bytes, got %d", 3+3*FramedSchnorrSignatureSize, len(log))
+	}
+	// Corrupt the second frame's signature.
+	log[3+FramedSchnorrSignatureSize+10] ^= 0x01
+
+	reader := bytes.NewReader(log)
+	for _, expected := range []*SchnorrSignature{sigs[0], sigs[2]} {
+		sig, err := ReadFramed(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sig.IsEqual(expected) {
+			t.Fatalf("Expected %s, got %s", expected, sig)
+		}
+	}
+	if _, err := ReadFramed(reader); err != io.EOF {
+		t.Fatalf("Expected io.EOF at the end of the log, got: '%v'", err)
+	}
+
+	truncated := bytes.NewReader(append(log[3:3+FramedSchnorrSignatureSize-1:3+FramedSchnorrSignatureSize-1], make([]byte, 10)...))
+	if _, err := ReadFramed(truncated); err != io.ErrUnexpectedEOF {
+		t.Fatalf("Expected io.ErrUnexpectedEOF for a truncated frame, got: '%v'", err)
+	}
+}
+
+func TestVerifyFresh(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := NewTimestampedChallenge([]byte("nonce"))
+	signature, err := key.SchnorrSign(ChallengeHash(challenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := pubkey.VerifyFresh(challenge, signature, time.Minute)
+	if err != nil || !valid {
+		t.Fatalf("Expected a fresh challenge to verify, got %t, '%v'", valid, err)
+	}
+
+	issued := time.Unix(int64(binary.BigEndian.Uint64(challenge)), 0)
+	_, err = pubkey.verifyFreshAt(challenge, signature, time.Minute, issued.Add(2*time.Minute))
+	if !errors.Is(err, ErrChallengeExpired) {
+		t.Fatalf("Expected ErrChallengeExpired for an old challenge, got: '%v'", err)
+	}
+	_, err = pubkey.verifyFreshAt(challenge, signature, time.Minute, issued.Add(-2*time.Minute))
+	if !errors.Is(err, ErrChallengeExpired) {
+		t.Fatalf("Expected ErrChallengeExpired for a challenge from the future, got: '%v'", err)
+	}
+
+	tampered := append([]byte{}, challenge...)
+	tampered[len(tampered)-1] ^= 0x01
+	valid, err = pubkey.VerifyFresh(tampered, signature, time.Minute)
+	if err != nil || valid {
+		t.Fatalf("Expected a tampered challenge to not verify, got %t, '%v'", valid, err)
+	}
+	if _, err := pubkey.VerifyFresh(challenge[:ChallengeTimestampSize-1], signature, time.Minute); err == nil {
+		t.Fatalf("Expected an error for a challenge shorter than its timestamp")
+	}
+}
+
+func TestParseECDSASignature(t *testing.T) {
+	key, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{0x43, 0x6f, 0x6d}
+	signature, err := key.ECDSASign(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compact := signature.Serialize()
+	parsed, err := ParseECDSASignature(compact[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *parsed.Serialize() != *compact {
+		t.Fatalf("Expected the compact signature to round trip, got %s instead of %s", parsed.Serialize(), compact)
+	}
+	if !pubkey.ECDSAVerify(&hash, parsed) {
+		t.Fatalf("Expected the parsed compact signature to verify")
+	}
+
+	der := signature.SerializeDER()
+	parsed, err = ParseECDSASignature(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.IsEqual(signature) {
+		t.Fatalf("Expected the DER signature to parse to %s, got %s", signature, parsed)
+	}
+
+	for _, length := range []int{0, minDERSignatureSize - 1, maxDERSignatureSize + 1} {
+		if _, err := ParseECDSASignature(make([]byte, length)); err == nil {
+			t.Fatalf("Expected an error for a %d byte signature", length)
+		}
+	}
+}
+
+func TestVerifyWithPolicy(t *testing.T) {
+	hash := Hash{0x50, 0x6f, 0x6c}
+	schnorrKey, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schnorrPubKey, err := schnorrKey.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedSchnorrPubKey, err := schnorrPubKey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schnorrSig := mustSchnorrSign(t, schnorrKey, &hash)
+	zeroHashSig, err := schnorrKey.SchnorrSign(&Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecdsaKey, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaPubKey, err := ecdsaKey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedECDSAPubKey, err := ecdsaPubKey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaSig, err := ecdsaKey.ECDSASign(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	highS := *ecdsaSig.Serialize()
+	var s [32]byte
+	copy(s[:], highS[32:])
+	s = ScalarNegate(s)
+	copy(highS[32:], s[:])
+
+	tests := []struct {
+		name        string
+		policy      VerifyPolicy
+		pubkey      []byte
+		hash        *Hash
+		sig         []byte
+		expected    bool
+		expectedErr error
+	}{
+		{"schnorr", VerifyPolicy{}, serializedSchnorrPubKey[:], &hash, schnorrSig.Serialize()[:], true, nil},
+		{"ecdsa", VerifyPolicy{}, serializedECDSAPubKey[:], &hash, ecdsaSig.Serialize()[:], true, nil},
+		{"high-S allowed", VerifyPolicy{AllowHighS: true}, serializedECDSAPubKey[:], &hash, highS[:], true, nil},
+		{"high-S rejected", VerifyPolicy{}, serializedECDSAPubKey[:], &hash, highS[:], false, ErrSignatureHighS},
+		{"zero hash allowed", VerifyPolicy{}, serializedSchnorrPubKey[:], &Hash{}, zeroHashSig.Serialize()[:], true, nil},
+		{"zero hash rejected", VerifyPolicy{RejectZeroHash: true}, serializedSchnorrPubKey[:], &Hash{}, zeroHashSig.Serialize()[:], false, ErrZeroHash},
+		{"allowed algorithm", VerifyPolicy{AllowedAlgorithms: []byte{AlgorithmSchnorr}}, serializedSchnorrPubKey[:], &hash, schnorrSig.Serialize()[:], true, nil},
+		{"disallowed algorithm", VerifyPolicy{AllowedAlgorithms: []byte{AlgorithmSchnorr}}, serializedECDSAPubKey[:], &hash, ecdsaSig.Serialize()[:], false, ErrAlgorithmNotAllowed},
+		{"wrong hash", VerifyPolicy{}, serializedSchnorrPubKey[:], &Hash{1}, schnorrSig.Serialize()[:], false, nil},
+	}
+	for _, test := range tests {
+		valid, err := VerifyWithPolicy(test.policy, test.pubkey, test.hash, test.sig)
+		if test.expectedErr == nil && err != nil || test.expectedErr != nil && !errors.Is(err, test.expectedErr) {
+			t.Fatalf("%s: expected the error '%v', got: '%v'", test.name, test.expectedErr, err)
+		}
+		if valid != test.expected {
+			t.Fatalf("%s: expected %t, got %t", test.name, test.expected, valid)
+		}
+	}
+	if _, err := VerifyWithPolicy(VerifyPolicy{}, serializedSchnorrPubKey[:31], &hash, schnorrSig.Serialize()[:]); err == nil {
+		t.Fatalf("Expected an error for a public key of an unknown length")
+	}
+}
+
+func TestSignWithCounter(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte("open the door")
+	signature, err := key.SignWithCounter(payload, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := pubkey.VerifyWithCounter(payload, 7, 6, signature)
+	if err != nil || !valid {
+		t.Fatalf("Expected the signature to verify, got %t, '%v'", valid, err)
+	}
+	valid, err = pubkey.VerifyWithCounter(payload, 8, 6, signature)
+	if err != nil || valid {
+		t.Fatalf("Expected the signature to not verify with another counter, got %t, '%v'", valid, err)
+	}
+	for _, lastSeen := range []uint64{7, 8} {
+		_, err = pubkey.VerifyWithCounter(payload, 7, lastSeen, signature)
+		if !errors.Is(err, ErrCounterReplayed) {
+			t.Fatalf("Expected ErrCounterReplayed with the last seen counter %d, got: '%v'", lastSeen, err)
+		}
+	}
+}
+
+func TestCommitToData(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("invoice 1234: 10 coins")
+	committed, tweak, err := pubkey.CommitToData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed.IsEqual(pubkey) {
+		t.Fatalf("Expected the committed key to be different from the public key")
+	}
+	if !pubkey.VerifyDataCommitment(committed, data) {
+		t.Fatalf("Expected the commitment to verify")
+	}
+	if pubkey.VerifyDataCommitment(committed, []byte("invoice 1234: 11 coins")) {
+		t.Fatalf("Expected the commitment to not verify for other data")
+	}
+
+	// The tweak opens the commitment with TweakAddCheck, and lets the keypair sign for the committed key.
+	err = key.Add(tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tweakedPubKey, isOdd, err := key.schnorrPublicKeyInternal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tweakedPubKey.IsEqual(committed) {
+		t.Fatalf("Expected the tweaked keypair's public key to be the committed key")
+	}
+	if !pubkey.TweakAddCheck(committed, isOdd, tweak) {
+		t.Fatalf("Expected TweakAddCheck to open the commitment")
+	}
+	hash := Hash{0x50, 0x32, 0x43}
+	signature := mustSchnorrSign(t, key, &hash)
+	if !committed.SchnorrVerify(&hash, signature) {
+		t.Fatalf("Expected a signature of the tweaked keypair to verify against the committed key")
+	}
+	if _, _, err := (&SchnorrPublicKey{}).CommitToData(data); err == nil {
+		t.Fatalf("Expected an error committing to an uninitialized public key")
+	}
+}
+
+func TestNormalizeForEvenY(t *testing.T) {
+	r := rand.New(rand.NewSource(228))
+	flips := 0
+	for i := 0; i < loopsN; i++ {
+		key := mustSchnorrKeyPair(t, r)
+		before, wasOdd, err := key.schnorrPublicKeyInternal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey := *key.SerializePrivateKey()
+		flipped, err := key.NormalizeForEvenY()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if flipped != wasOdd {
+			t.Fatalf("Expected flipped to be %t, got %t", wasOdd, flipped)
+		}
+		after, isOdd, err := key.schnorrPublicKeyInternal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isOdd {
+			t.Fatalf("Expected the public key to have an even Y after normalizing")
+		}
+		if !after.IsEqual(before) {
+			t.Fatalf("Expected the x-only public key to stay the same")
+		}
+		expected := privateKey
+		if flipped {
+			flips++
+			expected = SerializedPrivateKey(ScalarNegate(privateKey))
+		}
+		if *key.SerializePrivateKey() != expected {
+			t.Fatalf("Expected the private key to be negated only if the public key had an odd Y")
+		}
+		flipped, err = key.NormalizeForEvenY()
+		if err != nil || flipped {
+			t.Fatalf("Expected normalizing twice to not flip again: %v", err)
+		}
+	}
+	if flips == 0 || flips == loopsN {
+		t.Fatalf("Expected some of the keys to be flipped, got %d out of %d", flips, loopsN)
+	}
+	if _, err := new(SchnorrKeyPair).NormalizeForEvenY(); err == nil {
+		t.Fatalf("Expected an error normalizing an uninitialized keypair")
+	}
+}
+
+func TestVerifyRevealed(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, err := PublicKeyCommitment(pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *commitment != Hash(sha256.Sum256(serialized[:])) {
+		t.Fatalf("Expected the commitment to be the SHA256 of the x-only public key")
+	}
+	hash := Hash{0x23, 0x00}
+	signature := mustSchnorrSign(t, key, &hash)
+	valid, err := VerifyRevealed(commitment, pubkey, &hash, signature)
+	if err != nil || !valid {
+		t.Fatalf("Expected the revealed signature to verify: %v", err)
+	}
+	valid, err = VerifyRevealed(commitment, pubkey, &Hash{0x23, 0x01}, signature)
+	if err != nil || valid {
+		t.Fatalf("Expected the signature to not verify for a different hash: %v", err)
+	}
+
+	other, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPubKey, err := other.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSignature := mustSchnorrSign(t, other, &hash)
+	_, err = VerifyRevealed(commitment, otherPubKey, &hash, otherSignature)
+	if !errors.Is(err, ErrCommitmentMismatch) {
+		t.Fatalf("Expected ErrCommitmentMismatch revealing a different key, got: %v", err)
+	}
+	if _, err := VerifyRevealed(nil, pubkey, &hash, signature); err == nil {
+		t.Fatalf("Expected an error for a nil commitment")
+	}
+	if _, err := VerifyRevealed(commitment, new(SchnorrPublicKey), &hash, signature); err == nil {
+		t.Fatalf("Expected an error for an uninitialized public key")
+	}
+}
+
+func TestSchnorrSignFixedTime(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{0x23, 0x02}
+	const minDuration = 20 * time.Millisecond
+	start := time.Now()
+	signature, err := key.SchnorrSignFixedTime(&hash, minDuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < minDuration {
+		t.Fatalf("Expected signing to take at least %s, took %s", minDuration, elapsed)
+	}
+	if !pubkey.SchnorrVerify(&hash, signature) {
+		t.Fatalf("Expected the signature to verify")
+	}
+
+	// Failures take at least as long too.
+	start = time.Now()
+	if _, err := new(SchnorrKeyPair).SchnorrSignFixedTime(&hash, minDuration); err == nil {
+		t.Fatalf("Expected an error signing with an uninitialized keypair")
+	}
+	if elapsed := time.Since(start); elapsed < minDuration {
+		t.Fatalf("Expected a failure to take at least %s, took %s", minDuration, elapsed)
+	}
+
+	// A zero duration doesn't sleep.
+	signature, err = key.SchnorrSignFixedTime(&hash, 0)
+	if err != nil || !pubkey.SchnorrVerify(&hash, signature) {
+		t.Fatalf("Expected a valid signature with a zero duration: %v", err)
+	}
+}
+
+func TestValidateSchnorrPublicKeys(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fieldPrime := decodeHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	notOnCurve := decodeHex("0000000000000000000000000000000000000000000000000000000000000005")
+	keys := [][]byte{serialized[:], nil, fieldPrime, serialized[:], notOnCurve, serialized[:31]}
+
+	errs, ok := ValidateSchnorrPublicKeys(keys)
+	if ok {
+		t.Fatalf("Expected the keys to not all be valid")
+	}
+	if len(errs) != len(keys) {
+		t.Fatalf("Expected an error slot for each of the %d keys, got %d", len(keys), len(errs))
+	}
+	for _, i := range []int{0, 3} {
+		if errs[i] != nil {
+			t.Fatalf("Expected key %d to be valid, got: %s", i, errs[i])
+		}
+	}
+	for _, i := range []int{1, 2, 4, 5} {
+		if errs[i] == nil {
+			t.Fatalf("Expected key %d to be invalid", i)
+		}
+	}
+	if !errors.Is(errs[2], ErrXOutOfRange) || !errors.Is(errs[4], ErrXNotOnCurve) {
+		t.Fatalf("Expected the parse reasons to be kept, got '%s' and '%s'", errs[2], errs[4])
+	}
+
+	errs, ok = ValidateSchnorrPublicKeys([][]byte{serialized[:], serialized[:]})
+	if !ok || errs[0] != nil || errs[1] != nil {
+		t.Fatalf("Expected all the keys to be valid: %v", errs)
+	}
+	errs, ok = ValidateSchnorrPublicKeys(nil)
+	if !ok || len(errs) != 0 {
+		t.Fatalf("Expected no keys to be valid")
+	}
+}
+
+func TestSerializeWithRParity(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < loopsN; i++ {
+		hash := Hash{byte(i), 0x23, 0x04}
+		signature := mustSchnorrSign(t, key, &hash)
+		serialized, err := signature.SerializeWithRParity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if serialized[SerializedSchnorrSignatureSize] != 0 {
+			t.Fatalf("Expected R to always have an even Y")
+		}
+		if !bytes.Equal(serialized[:SerializedSchnorrSignatureSize], signature.Serialize()[:]) {
+			t.Fatalf("Expected the signature to be followed by the parity byte")
+		}
+		parsed, err := DeserializeSchnorrSignatureWithRParity(serialized[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.IsEqual(signature) || !pubkey.SchnorrVerify(&hash, parsed) {
+			t.Fatalf("Expected the parsed signature to be the same signature")
+		}
+		serialized[SerializedSchnorrSignatureSize] = 1
+		if _, err := DeserializeSchnorrSignatureWithRParity(serialized[:]); err == nil {
+			t.Fatalf("Expected an error for an odd R")
+		}
+		serialized[SerializedSchnorrSignatureSize] = 2
+		if _, err := DeserializeSchnorrSignatureWithRParity(serialized[:]); err == nil {
+			t.Fatalf("Expected an error for an invalid parity byte")
+		}
+	}
+	// x = 5 isn't on the curve.
+	invalidR := SchnorrSignatureFromRS([32]byte{31: 5}, [32]byte{31: 1})
+	if _, err := invalidR.SerializeWithRParity(); err == nil {
+		t.Fatalf("Expected an error serializing a signature whose R isn't on the curve")
+	}
+	if _, err := DeserializeSchnorrSignatureWithRParity(append(invalidR.Serialize()[:], 0)); err == nil {
+		t.Fatalf("Expected an error parsing a signature whose R isn't on the curve")
+	}
+	if _, err := DeserializeSchnorrSignatureWithRParity(make([]byte, SerializedSchnorrSignatureSize)); err == nil {
+		t.Fatalf("Expected an error parsing a 64 byte signature")
+	}
+}
+
+func TestProveSameKey(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := DeserializeECDSAPrivateKey(key.SerializePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaPubKey, err := ecdsaKey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schnorrPubKey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := key.ProveSameKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySameKey(ecdsaPubKey, schnorrPubKey, proof) {
+		t.Fatalf("Expected the proof to verify")
+	}
+	if !VerifySameKey(ecdsaPubKey, schnorrPubKey, DeserializeSameKeyProof(proof.Serialize())) {
+		t.Fatalf("Expected the deserialized proof to verify")
+	}
+
+	// The proof binds the exact ECDSA key, so the negated key (which has the same x-only key) doesn't verify.
+	negated := *ecdsaPubKey
+	err = negated.Negate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifySameKey(&negated, schnorrPubKey, proof) {
+		t.Fatalf("Expected the proof to not verify for the negated ECDSA key")
+	}
+
+	other, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPubKey, err := other.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifySameKey(ecdsaPubKey, otherPubKey, proof) {
+		t.Fatalf("Expected the proof to not verify for a different schnorr key")
+	}
+	otherProof, err := other.ProveSameKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifySameKey(ecdsaPubKey, schnorrPubKey, otherProof) {
+		t.Fatalf("Expected a proof by a different key to not verify")
+	}
+	if VerifySameKey(nil, schnorrPubKey, proof) || VerifySameKey(ecdsaPubKey, new(SchnorrPublicKey), proof) || VerifySameKey(ecdsaPubKey, schnorrPubKey, nil) {
+		t.Fatalf("Expected missing arguments to not verify")
+	}
+	if _, err := new(SchnorrKeyPair).ProveSameKey(); err == nil {
+		t.Fatalf("Expected an error proving with an uninitialized keypair")
+	}
+}
+
+func TestSchnorrPublicKeyAddTweak(t *testing.T) {
+	r := rand.New(rand.NewSource(251))
+	for i := 0; i < loopsN; i++ {
+		keypair := mustSchnorrKeyPair(t, r)
+		pubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := pubkey.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A watch-only wallet only has the serialized public key.
+		watchOnly, err := DeserializeSchnorrPubKey(serialized[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweak := *fastGenerateTweak(t, r)
+		err = keypair.Add(tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = watchOnly.Add(tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !watchOnly.IsEqual(expected) {
+			t.Fatalf("Expected the tweaked public key '%s' to match the tweaked keypair's '%s'", watchOnly, expected)
+		}
+	}
+
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, isOdd, err := keypair.schnorrPublicKeyInternal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *pubkey
+	allOnes := [32]byte{}
+	for i := range allOnes {
+		allOnes[i] = 0xff
+	}
+	if err := pubkey.Add(allOnes); err == nil {
+		t.Fatalf("Expected an error adding a tweak above the group order")
+	}
+	if !pubkey.IsEqual(&original) {
+		t.Fatalf("Expected a failed Add to not modify the key")
+	}
+	// The opposite of the even Y point's private key tweaks the key to the point at infinity.
+	evenPrivateKey := [32]byte(*keypair.SerializePrivateKey())
+	if isOdd {
+		evenPrivateKey = ScalarNegate(evenPrivateKey)
+	}
+	if err := pubkey.Add(ScalarNegate(evenPrivateKey)); err == nil {
+		t.Fatalf("Expected an error when the result is the point at infinity")
+	}
+	if !pubkey.IsEqual(&original) {
+		t.Fatalf("Expected a failed Add to not modify the key")
+	}
+	if err := new(SchnorrPublicKey).Add([32]byte{31: 1}); err == nil {
+		t.Fatalf("Expected an error adding to a zeroed public key")
+	}
+}
+
 func BenchmarkVerify(b *testing.B) {
 	for _, alg := range algorithms {
 		algCopy := alg
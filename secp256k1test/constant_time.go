@@ -0,0 +1,84 @@
+package secp256k1test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/apsaknet/go-secp256k1"
+)
+
+const (
+	// constantTimeSamples is the number of signatures timed for each of the two classes
+	constantTimeSamples = 2000
+	// constantTimeThreshold is the |t| value above which the timings are considered different.
+	// dudect uses 4.5, this is higher since timing a cgo call from Go is much noisier than timing C directly.
+	constantTimeThreshold = 10
+	// constantTimeCropPercentile drops the slowest samples of each class, those are mostly preemption and GC pauses
+	constantTimeCropPercentile = 0.9
+)
+
+// AssertConstantTimeSign does a coarse dudect style check that signing with key takes the same time as signing with
+// a key of very different bits (the private key 1), using Welch's t-test on the interleaved timings of random messages.
+// It fails t if the timings differ significantly. It's a statistical smoke test, passing it doesn't prove the signing is constant time.
+func AssertConstantTimeSign(t testing.TB, key *secp256k1.SchnorrKeyPair) {
+	t.Helper()
+	reference, err := secp256k1.PrivateKeyFromUint64(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := [2]*secp256k1.SchnorrKeyPair{key, reference}
+	timings := [2][]float64{}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var hash secp256k1.Hash
+	var aux [32]byte
+	for i := 0; i < 2*constantTimeSamples; i++ {
+		class := r.Intn(2)
+		if len(timings[class]) == constantTimeSamples {
+			class = 1 - class
+		}
+		r.Read(hash[:])
+		r.Read(aux[:])
+		start := time.Now()
+		_, err := keys[class].SchnorrSignWithAuxRand(&hash, &aux)
+		elapsed := time.Since(start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		timings[class] = append(timings[class], float64(elapsed))
+	}
+	tValue := welchT(crop(timings[0]), crop(timings[1]))
+	if math.Abs(tValue) > constantTimeThreshold {
+		t.Fatalf("Signing time depends on the private key, Welch's t = %.2f (threshold %d)", tValue, constantTimeThreshold)
+	}
+}
+
+func crop(samples []float64) []float64 {
+	sorted := append([]float64{}, samples...)
+	sort.Float64s(sorted)
+	return sorted[:int(float64(len(sorted))*constantTimeCropPercentile)]
+}
+
+func welchT(a, b []float64) float64 {
+	meanA, varianceA := meanAndVariance(a)
+	meanB, varianceB := meanAndVariance(b)
+	denominator := math.Sqrt(varianceA/float64(len(a)) + varianceB/float64(len(b)))
+	if denominator == 0 {
+		return 0
+	}
+	return (meanA - meanB) / denominator
+}
+
+func meanAndVariance(samples []float64) (mean, variance float64) {
+	for _, sample := range samples {
+		mean += sample
+	}
+	mean /= float64(len(samples))
+	for _, sample := range samples {
+		variance += (sample - mean) * (sample - mean)
+	}
+	variance /= float64(len(samples) - 1)
+	return mean, variance
+}
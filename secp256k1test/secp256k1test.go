@@ -0,0 +1,99 @@
+// Package secp256k1test provides helpers for constructing secp256k1 keys and signatures from hex in tests.
+//
+// All the Must functions in this package panic on invalid input, and are meant to be used only with hardcoded test fixtures.
+// TestKeyPair gives stable indexed keypairs, and AssertConstantTimeSign is a statistical check of the signing time for use in test suites.
+package secp256k1test
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/apsaknet/go-secp256k1"
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex string in test fixture: " + err.Error() + ", hex: " + s)
+	}
+	return b
+}
+
+// MustDeserializePrivateKeyHex parses a hex encoded 32 byte private key into a SchnorrKeyPair.
+// It panics on error, and should only be used in tests.
+func MustDeserializePrivateKeyHex(s string) *secp256k1.SchnorrKeyPair {
+	key, err := secp256k1.DeserializeSchnorrPrivateKeyFromSlice(mustDecodeHex(s))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// MustDeserializeECDSAPrivateKeyHex parses a hex encoded 32 byte private key into an ECDSAPrivateKey.
+// It panics on error, and should only be used in tests.
+func MustDeserializeECDSAPrivateKeyHex(s string) *secp256k1.ECDSAPrivateKey {
+	key, err := secp256k1.DeserializeECDSAPrivateKeyFromSlice(mustDecodeHex(s))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// MustDeserializeSchnorrPubKeyHex parses a hex encoded 32 byte x-only public key.
+// It panics on error, and should only be used in tests.
+func MustDeserializeSchnorrPubKeyHex(s string) *secp256k1.SchnorrPublicKey {
+	key, err := secp256k1.DeserializeSchnorrPubKey(mustDecodeHex(s))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// MustDeserializeECDSAPubKeyHex parses a hex encoded 33 byte compressed public key.
+// It panics on error, and should only be used in tests.
+func MustDeserializeECDSAPubKeyHex(s string) *secp256k1.ECDSAPublicKey {
+	key, err := secp256k1.DeserializeECDSAPubKey(mustDecodeHex(s))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// MustDeserializeSchnorrSignatureHex parses a hex encoded 64 byte schnorr signature.
+// It panics on error, and should only be used in tests.
+func MustDeserializeSchnorrSignatureHex(s string) *secp256k1.SchnorrSignature {
+	sig, err := secp256k1.DeserializeSchnorrSignatureFromSlice(mustDecodeHex(s))
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+// MustDeserializeECDSASignatureHex parses a hex encoded 64 byte compact ECDSA signature.
+// It panics on error, and should only be used in tests.
+func MustDeserializeECDSASignatureHex(s string) *secp256k1.ECDSASignature {
+	sig, err := secp256k1.DeserializeECDSASignatureFromSlice(mustDecodeHex(s))
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+// testKeyPairPrefix is hashed with the index by TestKeyPair
+const testKeyPairPrefix = "go-secp256k1-test"
+
+// TestKeyPair deterministically derives the keypair number index, with the private key
+// `SHA256("go-secp256k1-test" || index as 8 bytes big endian) % Group Order`, so tests can use stable keys like `alice := TestKeyPair(0)`.
+// The private keys are trivially computable by anyone, never use these keys outside of tests.
+func TestKeyPair(index int) *secp256k1.SchnorrKeyPair {
+	var serializedIndex [8]byte
+	binary.BigEndian.PutUint64(serializedIndex[:], uint64(index))
+	hash := sha256.Sum256(append([]byte(testKeyPairPrefix), serializedIndex[:]...))
+	privateKey := secp256k1.SerializedPrivateKey(secp256k1.ScalarAdd(hash, [32]byte{}))
+	key, err := secp256k1.DeserializeSchnorrPrivateKey(&privateKey)
+	if err != nil {
+		panic("the test private key is zero. Should never happen")
+	}
+	return key
+}
@@ -0,0 +1,86 @@
+package secp256k1test
+
+import (
+	"testing"
+
+	"github.com/apsaknet/go-secp256k1"
+)
+
+func TestMustDeserialize(t *testing.T) {
+	// BIP-340 test vector 1
+	keypair := MustDeserializePrivateKeyHex("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF")
+	pubkey := MustDeserializeSchnorrPubKeyHex("DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659")
+	sig := MustDeserializeSchnorrSignatureHex("6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A")
+	expectedPubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pubkey.IsEqual(expectedPubkey) {
+		t.Fatalf("Expected %s == %s", pubkey, expectedPubkey)
+	}
+	var msg secp256k1.Hash
+	err = msg.SetBytes(mustDecodeHex("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pubkey.SchnorrVerify(&msg, sig) {
+		t.Fatalf("Failed verifying the test vector signature")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected a panic on an invalid private key")
+		}
+	}()
+	MustDeserializePrivateKeyHex("00")
+}
+
+func TestAssertConstantTimeSign(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	key, err := secp256k1.GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertConstantTimeSign(t, key)
+}
+
+func TestWelchT(t *testing.T) {
+	same := []float64{1, 2, 3, 4, 5}
+	if welchT(same, same) != 0 {
+		t.Fatalf("Expected t = 0 for identical samples")
+	}
+	different := []float64{21, 22, 23, 24, 25}
+	if tValue := welchT(same, different); tValue > -constantTimeThreshold {
+		t.Fatalf("Expected a large negative t for clearly different samples, got %f", tValue)
+	}
+}
+
+func TestTestKeyPair(t *testing.T) {
+	key := TestKeyPair(0)
+	expectedPrivateKey := "3c6e9645c36f4295be839225353b2a054da31ec0541bcc05c3c7e642b290fd43"
+	if privateKey := key.SerializePrivateKey().String(); privateKey != expectedPrivateKey {
+		t.Fatalf("Expected the private key %s, got %s", expectedPrivateKey, privateKey)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPubKey := MustDeserializeSchnorrPubKeyHex("088a4fd27fb89fe19c4ff665ec7ab39261c868175d0bad3dd2a6e432456f45e6")
+	if !pubkey.IsEqual(expectedPubKey) {
+		t.Fatalf("Expected the public key %s, got %s", expectedPubKey, pubkey)
+	}
+
+	seen := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		privateKey := TestKeyPair(i).SerializePrivateKey().String()
+		if privateKey != TestKeyPair(i).SerializePrivateKey().String() {
+			t.Fatalf("Expected TestKeyPair(%d) to be stable", i)
+		}
+		if j, ok := seen[privateKey]; ok {
+			t.Fatalf("TestKeyPair(%d) and TestKeyPair(%d) are the same key", j, i)
+		}
+		seen[privateKey] = i
+	}
+}
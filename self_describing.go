@@ -0,0 +1,42 @@
+package secp256k1
+
+import "github.com/pkg/errors"
+
+// SelfDescribingSignatureSize is the length in bytes of a signature created by SignSelfDescribing
+const SelfDescribingSignatureSize = SerializedSchnorrPublicKeySize + SerializedSchnorrSignatureSize
+
+// SignSelfDescribing signs the hash and returns `x-only public key (32 bytes) || schnorr signature (64 bytes)`,
+// so a verifier that doesn't know the signer in advance can verify it with VerifySelfDescribing.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func (key *SchnorrKeyPair) SignSelfDescribing(hash *Hash) ([]byte, error) {
+	signature, pubkey, err := key.SignAccountable(hash)
+	if err != nil {
+		return nil, err
+	}
+	serializedPubkey, err := pubkey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, 0, SelfDescribingSignatureSize)
+	blob = append(blob, serializedPubkey[:]...)
+	return append(blob, signature.signature[:]...), nil
+}
+
+// VerifySelfDescribing parses a signature created by SignSelfDescribing and verifies it over the hash,
+// returning the public key it carries. It's an error if the blob has the wrong length or the public key is invalid.
+// Notice: anyone can create a valid blob with their own key, so the returned public key has to be checked
+// against the keys the verifier trusts.
+func VerifySelfDescribing(hash *Hash, blob []byte) (*SchnorrPublicKey, bool, error) {
+	if len(blob) != SelfDescribingSignatureSize {
+		return nil, false, errors.Errorf("a self describing signature has to be %d bytes, instead got %d", SelfDescribingSignatureSize, len(blob))
+	}
+	pubkey, err := DeserializeSchnorrPubKey(blob[:SerializedSchnorrPublicKeySize])
+	if err != nil {
+		return nil, false, err
+	}
+	signature, err := DeserializeSchnorrSignatureFromSlice(blob[SerializedSchnorrPublicKeySize:])
+	if err != nil {
+		return nil, false, err
+	}
+	return pubkey, pubkey.SchnorrVerify(hash, signature), nil
+}
@@ -0,0 +1,67 @@
+package secp256k1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestSelfDescribingSignature(t *testing.T) {
+	r := rand.New(rand.NewSource(191))
+	keypair := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedPubkey, err := pubkey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+
+	blob, err := keypair.SignSelfDescribing(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blob) != SelfDescribingSignatureSize || !bytes.Equal(blob[:32], serializedPubkey[:]) {
+		t.Fatalf("Expected a %d byte blob starting with the public key, got %x", SelfDescribingSignatureSize, blob)
+	}
+	recovered, valid, err := VerifySelfDescribing(&hash, blob)
+	if err != nil || !valid {
+		t.Fatalf("Expected the blob to verify, got: %t, %v", valid, err)
+	}
+	if !recovered.IsEqual(pubkey) {
+		t.Fatalf("Expected the blob's public key to be %s, got %s", pubkey, recovered)
+	}
+
+	otherHash := Hash{1}
+	_, valid, err = VerifySelfDescribing(&otherHash, blob)
+	if err != nil || valid {
+		t.Fatalf("Expected the blob to not verify over another hash, got: %t, %v", valid, err)
+	}
+	otherKeypair := mustSchnorrKeyPair(t, r)
+	otherBlob, err := otherKeypair.SignSelfDescribing(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := append(append([]byte{}, otherBlob[:32]...), blob[32:]...)
+	_, valid, err = VerifySelfDescribing(&hash, swapped)
+	if err != nil || valid {
+		t.Fatalf("Expected the signature to not verify with another public key, got: %t, %v", valid, err)
+	}
+
+	invalidKey := append([]byte{}, blob...)
+	for i := 0; i < 32; i++ {
+		invalidKey[i] = 0xff
+	}
+	for _, malformed := range [][]byte{nil, blob[:95], append(blob[:96:96], 0), invalidKey} {
+		_, _, err := VerifySelfDescribing(&hash, malformed)
+		if err == nil {
+			t.Fatalf("Expected an error verifying a malformed blob %x", malformed)
+		}
+	}
+	if _, err := new(SchnorrKeyPair).SignSelfDescribing(&hash); err == nil {
+		t.Fatalf("Expected an error signing with an uninitialized keypair")
+	}
+}
@@ -0,0 +1,125 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+)
+
+// selfTestSchnorrVectors are BIP-340 test vectors (https://github.com/bitcoin/bips/blob/master/bip-0340/test-vectors.csv)
+// an empty private key means the vector is only verified.
+var selfTestSchnorrVectors = []struct {
+	privateKey, publicKey, auxRand, message, signature string
+	valid                                              bool
+}{
+	{
+		"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+		"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		"6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+		true,
+	},
+	{
+		"C90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B14E5C9",
+		"DD308AFEC5777E13121FA72B9CC1B7CC0139715309B086C960E18FD969774EB8",
+		"C87AA53824B4D7AE2EB035A2B5BBBCCC080E76CDC6D1692C4B0B62D798E6D906",
+		"7E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+		"5831AAEED7B44BB74E5EAB94BA9D4294C49BCF2A60728D8B4C200F50DD313C1BAB745879A5AD954A72C45A91C3A51D3C7ADEA98D82F8481E0E1E03674A6F3FB7",
+		true,
+	},
+	{ // negated message
+		"",
+		"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"",
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		"1FA62E331EDBC21C394792D2AB1100A7B432B013DF3F6FF4F99FCB33E0E1515F28890B3EDB6E7189B630448B515CE4F8622A954CFE545735AAEA5134FCCDB2BD",
+		false,
+	},
+}
+
+// selfTestECDSAVector is the RFC6979 signature of SHA256("Satoshi Nakamoto") with the private key 1, as in Bitcoin Core's tests.
+var selfTestECDSAVector = struct {
+	privateKey, message, signature string
+}{
+	"0000000000000000000000000000000000000000000000000000000000000001",
+	"Satoshi Nakamoto",
+	"934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d82442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5",
+}
+
+// SelfTest signs and verifies a few known BIP-340 and ECDSA test vectors, and returns an error if any of them fails.
+// It's meant to be called on startup, to catch a miscompiled libsecp256k1 (e.g. a bad cross compile) before it's used.
+func SelfTest() error {
+	for i, vector := range selfTestSchnorrVectors {
+		pubkey, err := DeserializeSchnorrPubKey(selfTestDecodeHex(vector.publicKey))
+		if err != nil {
+			return errors.Wrapf(err, "self test: failed parsing the public key of schnorr vector %d", i)
+		}
+		message := Hash{}
+		copy(message[:], selfTestDecodeHex(vector.message))
+		expectedSignature, err := DeserializeSchnorrSignatureFromSlice(selfTestDecodeHex(vector.signature))
+		if err != nil {
+			return errors.Wrapf(err, "self test: failed parsing the signature of schnorr vector %d", i)
+		}
+		if pubkey.SchnorrVerify(&message, expectedSignature) != vector.valid {
+			return errors.Errorf("self test: schnorr vector %d verified as %t instead of %t", i, !vector.valid, vector.valid)
+		}
+		if vector.privateKey == "" {
+			continue
+		}
+		keypair, err := DeserializeSchnorrPrivateKeyFromSlice(selfTestDecodeHex(vector.privateKey))
+		if err != nil {
+			return errors.Wrapf(err, "self test: failed parsing the private key of schnorr vector %d", i)
+		}
+		keypairPubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			return errors.Wrapf(err, "self test: failed getting the public key of schnorr vector %d", i)
+		}
+		if !keypairPubkey.IsEqual(pubkey) {
+			return errors.Errorf("self test: schnorr vector %d derived the public key %s instead of %s", i, keypairPubkey, pubkey)
+		}
+		auxRand := [32]byte{}
+		copy(auxRand[:], selfTestDecodeHex(vector.auxRand))
+		signature, err := keypair.schnorrSignInternal(&message, &auxRand)
+		if err != nil {
+			return errors.Wrapf(err, "self test: failed signing schnorr vector %d", i)
+		}
+		if !signature.IsEqual(expectedSignature) {
+			return errors.Errorf("self test: schnorr vector %d signed as %s instead of %s", i, signature, expectedSignature)
+		}
+	}
+
+	privateKey, err := DeserializeECDSAPrivateKeyFromSlice(selfTestDecodeHex(selfTestECDSAVector.privateKey))
+	if err != nil {
+		return errors.Wrap(err, "self test: failed parsing the private key of the ECDSA vector")
+	}
+	pubkey, err := privateKey.ECDSAPublicKey()
+	if err != nil {
+		return errors.Wrap(err, "self test: failed getting the public key of the ECDSA vector")
+	}
+	message := Hash(sha256.Sum256([]byte(selfTestECDSAVector.message)))
+	signature, err := privateKey.ecdsaSignInternal(&message, nil)
+	if err != nil {
+		return errors.Wrap(err, "self test: failed signing the ECDSA vector")
+	}
+	if signature.String() != selfTestECDSAVector.signature {
+		return errors.Errorf("self test: the ECDSA vector signed as %s instead of %s", signature, selfTestECDSAVector.signature)
+	}
+	if !pubkey.ECDSAVerify(&message, signature) {
+		return errors.New("self test: the ECDSA vector's signature didn't verify")
+	}
+	message[0] ^= 1
+	if pubkey.ECDSAVerify(&message, signature) {
+		return errors.New("self test: the ECDSA vector's signature verified over another message")
+	}
+	return nil
+}
+
+// selfTestDecodeHex decodes the hardcoded hex strings of the self test vectors.
+func selfTestDecodeHex(hexStr string) []byte {
+	decoded, err := hex.DecodeString(hexStr)
+	if err != nil {
+		panic("invalid hex string in the self test vectors. Should never happen")
+	}
+	return decoded
+}
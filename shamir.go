@@ -0,0 +1,217 @@
+package secp256k1
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// SecretShare is one share of a private key split with SchnorrKeyPair.Split:
+// the evaluation of the secret sharing polynomial at Index, which is never zero.
+type SecretShare struct {
+	Index uint32
+	Value [32]byte
+}
+
+// Split splits the private key into total Shamir secret shares, any threshold of which combine back into the key with CombineShares.
+// The polynomial `f(x) = key + a_1*x + ... + a_(threshold-1)*x^(threshold-1)` has random coefficients and is evaluated
+// at x = 1..total modulo the group order, so fewer than threshold shares reveal nothing about the key.
+// The threshold has to be at least 2 and at most total.
+func (key *SchnorrKeyPair) Split(threshold, total int) ([]SecretShare, error) {
+	shares, _, err := key.split(threshold, total, false)
+	return shares, err
+}
+
+// SplitVerifiable splits the private key like Split, and also returns the Feldman VSS commitments to the polynomial's coefficients,
+// `a_i*Generator` for i = 0..threshold-1, so every shareholder can check its share with VerifyShare.
+// Notice: the first commitment is the keypair's full (not x-only) public key.
+func (key *SchnorrKeyPair) SplitVerifiable(threshold, total int) ([]SecretShare, []*ECDSAPublicKey, error) {
+	return key.split(threshold, total, true)
+}
+
+func (key *SchnorrKeyPair) split(threshold, total int, commit bool) ([]SecretShare, []*ECDSAPublicKey, error) {
+	if !key.init {
+		return nil, nil, errors.WithStack(errNonInitializedKey)
+	}
+	if threshold < 2 || threshold > total {
+		return nil, nil, errors.Errorf("the threshold has to be between 2 and the total amount of shares (%d), instead got %d", total, threshold)
+	}
+	if uint64(total) > uint64(^uint32(0)) {
+		return nil, nil, errors.Errorf("can't split into more than %d shares", ^uint32(0))
+	}
+	privateKey := key.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	coefficients := make([][32]byte, threshold)
+	defer func() {
+		for i := range coefficients {
+			coefficients[i] = [32]byte{}
+		}
+	}()
+	coefficients[0] = *privateKey
+	for i := 1; i < threshold; i++ {
+		coefficient, err := generatePrivateKey()
+		if err != nil {
+			return nil, nil, err
+		}
+		coefficients[i] = *coefficient
+		*coefficient = SerializedPrivateKey{}
+	}
+
+	shares := make([]SecretShare, total)
+	for i := range shares {
+		shares[i].Index = uint32(i + 1)
+		x := shareIndexScalar(shares[i].Index)
+		// Horner's method, from the highest degree coefficient
+		value := coefficients[threshold-1]
+		for j := threshold - 2; j >= 0; j-- {
+			value = ScalarAdd(ScalarMul(value, x), coefficients[j])
+		}
+		shares[i].Value = value
+	}
+
+	var commitments []*ECDSAPublicKey
+	if commit {
+		commitments = make([]*ECDSAPublicKey, threshold)
+		for i := range commitments {
+			var err error
+			commitments[i], err = TweakPublicKey(coefficients[i])
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return shares, commitments, nil
+}
+
+// VerifyShare returns true if the share is consistent with the Feldman VSS commitments from SplitVerifiable:
+// `Value*Generator == C_0 + Index*C_1 + ... + Index^(threshold-1)*C_(threshold-1)`.
+// It returns false for a zero index, no commitments or an uninitialized commitment.
+func VerifyShare(share SecretShare, commitments []*ECDSAPublicKey) bool {
+	if share.Index == 0 || len(commitments) == 0 {
+		return false
+	}
+	for _, commitment := range commitments {
+		if commitment == nil || !commitment.init {
+			return false
+		}
+	}
+	expected, ok := evaluateCommitments(share.Index, commitments)
+	if !ok {
+		return false
+	}
+	actual, err := TweakPublicKey(share.Value)
+	if err != nil {
+		return false
+	}
+	return actual.IsEqual(expected)
+}
+
+// VerifyGroupKey checks the output of a threshold key setup (e.g. a FROST DKG or SplitVerifiable) before trusting it:
+// that the constant term of the Feldman commitments is the group key (as an x-only key), and that every participant's
+// verification share `f(i)*Generator` is the committed polynomial evaluated at their index in the exponent.
+// participantShares[i] is the verification share of the participant with index i+1, like the shares of Split.
+// It returns false if any check fails, and an error if the arguments are missing or uninitialized.
+func VerifyGroupKey(groupKey *SchnorrPublicKey, participantShares []*ECDSAPublicKey, commitments []*ECDSAPublicKey) (bool, error) {
+	if groupKey == nil || !groupKey.init {
+		return false, errors.WithStack(errNonInitializedKey)
+	}
+	if len(commitments) == 0 {
+		return false, errors.New("there are no commitments")
+	}
+	if uint64(len(participantShares)) > uint64(^uint32(0)) {
+		return false, errors.Errorf("can't verify more than %d participants", ^uint32(0))
+	}
+	for i, commitment := range commitments {
+		if commitment == nil || !commitment.init {
+			return false, errors.Errorf("the commitment at index %d isn't initialized", i)
+		}
+	}
+	for i, share := range participantShares {
+		if share == nil || !share.init {
+			return false, errors.Errorf("the verification share of participant %d isn't initialized", i+1)
+		}
+	}
+	constantTerm, err := commitments[0].ToSchnorr()
+	if err != nil {
+		return false, err
+	}
+	if !constantTerm.IsEqual(groupKey) {
+		return false, nil
+	}
+	for i, share := range participantShares {
+		expected, ok := evaluateCommitments(uint32(i+1), commitments)
+		if !ok || !share.IsEqual(expected) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evaluateCommitments evaluates the polynomial committed to by the Feldman commitments at the index in the exponent:
+// `sum(index^i * commitments[i])`. It returns false if the result is the point at infinity.
+func evaluateCommitments(index uint32, commitments []*ECDSAPublicKey) (*ECDSAPublicKey, bool) {
+	x := shareIndexScalar(index)
+	powers := make([][32]byte, len(commitments))
+	powers[0] = [32]byte{31: 1}
+	for i := 1; i < len(powers); i++ {
+		powers[i] = ScalarMul(powers[i-1], x)
+	}
+	result, isInfinity, err := multiScalarMultInternal(nil, powers, commitments)
+	if err != nil || isInfinity {
+		return nil, false
+	}
+	return result, true
+}
+
+// CombineShares reconstructs a private key from threshold or more shares created by SchnorrKeyPair.Split,
+// by Lagrange interpolation of the polynomial at zero modulo the group order.
+// It's an error if a share has the index zero or two shares have the same index.
+// Notice: combining fewer shares than the threshold silently gives a different, unrelated, key.
+func CombineShares(shares []SecretShare) (*SchnorrKeyPair, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares to combine")
+	}
+	seen := make(map[uint32]struct{}, len(shares))
+	for i, share := range shares {
+		if share.Index == 0 {
+			return nil, errors.Errorf("share number %d has the invalid index 0", i)
+		}
+		if _, ok := seen[share.Index]; ok {
+			return nil, errors.Errorf("the index %d appears in more than one share", share.Index)
+		}
+		seen[share.Index] = struct{}{}
+	}
+
+	var secret [32]byte
+	defer func() { secret = [32]byte{} }()
+	for i, share := range shares {
+		// The Lagrange basis polynomial of share i at zero: the product of x_j / (x_j - x_i) for j != i
+		xi := shareIndexScalar(share.Index)
+		numerator := [32]byte{31: 1}
+		denominator := [32]byte{31: 1}
+		for j, other := range shares {
+			if i == j {
+				continue
+			}
+			xj := shareIndexScalar(other.Index)
+			numerator = ScalarMul(numerator, xj)
+			denominator = ScalarMul(denominator, ScalarAdd(xj, ScalarNegate(xi)))
+		}
+		basis := ScalarMul(numerator, ScalarInverse(denominator))
+		secret = ScalarAdd(secret, ScalarMul(share.Value, basis))
+	}
+	privateKey := SerializedPrivateKey(secret)
+	defer func() { privateKey = SerializedPrivateKey{} }()
+	key, err := DeserializeSchnorrPrivateKey(&privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "the shares combine to an invalid private key")
+	}
+	return key, nil
+}
+
+// shareIndexScalar returns the share index as a 32 byte big endian scalar
+func shareIndexScalar(index uint32) [32]byte {
+	var scalar [32]byte
+	binary.BigEndian.PutUint32(scalar[28:], index)
+	return scalar
+}
@@ -0,0 +1,173 @@
+package secp256k1
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestShamirSplitAndCombine(t *testing.T) {
+	r := rand.New(rand.NewSource(222))
+	key := mustSchnorrKeyPair(t, r)
+	const threshold, total = 3, 5
+	shares, err := key.Split(threshold, total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != total {
+		t.Fatalf("Expected %d shares, got %d", total, len(shares))
+	}
+
+	// Every subset of at least threshold shares gives back the key.
+	for subset := 0; subset < 1<<total; subset++ {
+		var selected []SecretShare
+		for i := 0; i < total; i++ {
+			if subset&(1<<i) != 0 {
+				selected = append(selected, shares[i])
+			}
+		}
+		if len(selected) == 0 {
+			continue
+		}
+		r.Shuffle(len(selected), func(i, j int) { selected[i], selected[j] = selected[j], selected[i] })
+		combined, err := CombineShares(selected)
+		if len(selected) < threshold {
+			if err == nil && *combined.SerializePrivateKey() == *key.SerializePrivateKey() {
+				t.Fatalf("Expected %d shares to not give back the key", len(selected))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *combined.SerializePrivateKey() != *key.SerializePrivateKey() {
+			t.Fatalf("Expected the shares %v to give back the key", subset)
+		}
+	}
+
+	if _, err := CombineShares([]SecretShare{shares[0], shares[1], shares[0]}); err == nil {
+		t.Fatalf("Expected an error for a duplicate share index")
+	}
+	if _, err := CombineShares([]SecretShare{shares[0], {Index: 0, Value: shares[1].Value}}); err == nil {
+		t.Fatalf("Expected an error for the index zero")
+	}
+	if _, err := CombineShares(nil); err == nil {
+		t.Fatalf("Expected an error for no shares")
+	}
+	for _, params := range [][2]int{{1, 5}, {6, 5}, {0, 0}} {
+		if _, err := key.Split(params[0], params[1]); err == nil {
+			t.Fatalf("Expected an error splitting with threshold %d of %d", params[0], params[1])
+		}
+	}
+	if _, err := (&SchnorrKeyPair{}).Split(2, 3); err == nil {
+		t.Fatalf("Expected an error splitting an uninitialized keypair")
+	}
+}
+
+func TestSplitVerifiable(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares, commitments, err := key.SplitVerifiable(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commitments) != 3 {
+		t.Fatalf("Expected 3 commitments, got %d", len(commitments))
+	}
+	publicKey, err := TweakPublicKey(*key.SerializePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !commitments[0].IsEqual(publicKey) {
+		t.Fatalf("Expected the first commitment to be the public key")
+	}
+	for _, share := range shares {
+		if !VerifyShare(share, commitments) {
+			t.Fatalf("Expected share %d to verify", share.Index)
+		}
+	}
+	combined, err := CombineShares(shares[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *combined.SerializePrivateKey() != *key.SerializePrivateKey() {
+		t.Fatalf("Expected the verifiable shares to combine back into the key")
+	}
+
+	tampered := shares[0]
+	tampered.Value = ScalarAdd(tampered.Value, [32]byte{31: 1})
+	if VerifyShare(tampered, commitments) {
+		t.Fatalf("Expected a tampered share to not verify")
+	}
+	wrongIndex := shares[0]
+	wrongIndex.Index = shares[1].Index
+	if VerifyShare(wrongIndex, commitments) {
+		t.Fatalf("Expected a share with the wrong index to not verify")
+	}
+	if VerifyShare(shares[0], commitments[:2]) {
+		t.Fatalf("Expected a share to not verify against truncated commitments")
+	}
+	if VerifyShare(shares[0], nil) || VerifyShare(shares[0], []*ECDSAPublicKey{commitments[0], nil, commitments[2]}) {
+		t.Fatalf("Expected missing commitments to not verify")
+	}
+}
+
+func TestVerifyGroupKey(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupKey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares, commitments, err := key.SplitVerifiable(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verificationShares := make([]*ECDSAPublicKey, len(shares))
+	for i, share := range shares {
+		verificationShares[i], err = TweakPublicKey(share.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	valid, err := VerifyGroupKey(groupKey, verificationShares, commitments)
+	if err != nil || !valid {
+		t.Fatalf("Expected the group key and verification shares to verify: %v", err)
+	}
+
+	other, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := other.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := VerifyGroupKey(otherKey, verificationShares, commitments); err != nil || valid {
+		t.Fatalf("Expected a different group key to not verify: %v", err)
+	}
+	swapped := append([]*ECDSAPublicKey{}, verificationShares...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	if valid, err := VerifyGroupKey(groupKey, swapped, commitments); err != nil || valid {
+		t.Fatalf("Expected swapped verification shares to not verify: %v", err)
+	}
+	if valid, err := VerifyGroupKey(groupKey, verificationShares, commitments[:2]); err != nil || valid {
+		t.Fatalf("Expected truncated commitments to not verify: %v", err)
+	}
+
+	if _, err := VerifyGroupKey(nil, verificationShares, commitments); err == nil {
+		t.Fatalf("Expected an error for a nil group key")
+	}
+	if _, err := VerifyGroupKey(groupKey, verificationShares, nil); err == nil {
+		t.Fatalf("Expected an error for no commitments")
+	}
+	if _, err := VerifyGroupKey(groupKey, []*ECDSAPublicKey{verificationShares[0], nil}, commitments); err == nil {
+		t.Fatalf("Expected an error for a nil verification share")
+	}
+	if _, err := VerifyGroupKey(groupKey, verificationShares, []*ECDSAPublicKey{commitments[0], new(ECDSAPublicKey)}); err == nil {
+		t.Fatalf("Expected an error for an uninitialized commitment")
+	}
+}
@@ -0,0 +1,48 @@
+package secp256k1
+
+import "github.com/pkg/errors"
+
+// RemoteSigner is a schnorr signer whose private key is held somewhere else, e.g. in an HSM or a cloud KMS.
+// A SchnorrKeyPair can be used as one via AsRemoteSigner.
+type RemoteSigner interface {
+	// PublicKey returns the x-only public key the signatures verify against.
+	PublicKey() (*SchnorrPublicKey, error)
+	// Sign creates a BIP-340 schnorr signature over the hash.
+	Sign(hash *Hash) (*SchnorrSignature, error)
+}
+
+// keyPairSigner is the RemoteSigner implementation for a local SchnorrKeyPair
+type keyPairSigner struct {
+	key *SchnorrKeyPair
+}
+
+func (signer keyPairSigner) PublicKey() (*SchnorrPublicKey, error) {
+	return signer.key.SchnorrPublicKey()
+}
+
+func (signer keyPairSigner) Sign(hash *Hash) (*SchnorrSignature, error) {
+	return signer.key.SchnorrSign(hash)
+}
+
+// AsRemoteSigner returns a RemoteSigner that signs with the keypair, so it can be used with the helpers that accept a RemoteSigner.
+func (key *SchnorrKeyPair) AsRemoteSigner() RemoteSigner {
+	return keyPairSigner{key: key}
+}
+
+// SignWithSigner signs the hash with the signer and verifies the returned signature against the signer's public key,
+// so a faulty or misbehaving remote signer can't hand back a signature that doesn't verify.
+// Notice: the [32] byte array *MUST* be a hash of a message.
+func SignWithSigner(signer RemoteSigner, hash *Hash) (*SchnorrSignature, error) {
+	pubkey, err := signer.PublicKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting the signer's public key")
+	}
+	signature, err := signer.Sign(hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "the signer failed signing")
+	}
+	if signature == nil || !pubkey.SchnorrVerify(hash, signature) {
+		return nil, errors.New("the signer returned a signature that doesn't verify against its public key")
+	}
+	return signature, nil
+}
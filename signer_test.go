@@ -0,0 +1,65 @@
+package secp256k1
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// badSigner signs with a different key than the public key it reports
+type badSigner struct {
+	pubkey *SchnorrPublicKey
+	key    *SchnorrKeyPair
+}
+
+func (signer badSigner) PublicKey() (*SchnorrPublicKey, error) {
+	return signer.pubkey, nil
+}
+
+func (signer badSigner) Sign(hash *Hash) (*SchnorrSignature, error) {
+	return signer.key.SchnorrSign(hash)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	r := rand.New(rand.NewSource(52))
+	keypair := mustSchnorrKeyPair(t, r)
+	other := mustSchnorrKeyPair(t, r)
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := Hash{}
+	r.Read(hash[:])
+
+	signer := keypair.AsRemoteSigner()
+	signerPubkey, err := signer.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signerPubkey.IsEqual(pubkey) {
+		t.Fatalf("Expected the signer's public key to be '%s', got '%s'", pubkey, signerPubkey)
+	}
+	signature, err := SignWithSigner(signer, &hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pubkey.SchnorrVerify(&hash, signature) {
+		t.Fatalf("Expected a valid signature")
+	}
+	msg, aad := []byte("message"), []byte("aad")
+	signature, err = SignWithContextRemote(signer, msg, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pubkey.VerifyWithContext(msg, aad, signature) {
+		t.Fatalf("Expected a valid signature with the associated data")
+	}
+
+	_, err = SignWithSigner(badSigner{pubkey: pubkey, key: other}, &hash)
+	if err == nil {
+		t.Fatalf("Expected an error for a signer whose signature doesn't verify")
+	}
+	_, err = SignWithSigner(new(SchnorrKeyPair).AsRemoteSigner(), &hash)
+	if err == nil {
+		t.Fatalf("Expected an error for an uninitialized keypair")
+	}
+}
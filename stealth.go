@@ -0,0 +1,66 @@
+package secp256k1
+
+import "github.com/pkg/errors"
+
+// stealthTag is the tagged hash tag of the stealth address tweak
+const stealthTag = "go-secp256k1/stealth"
+
+// StealthOneTimeKey computes the one-time public key of a dual-key stealth payment:
+// `recipientSpend + TaggedHash("go-secp256k1/stealth", senderEphemeral*recipientScan)*Generator`, where the shared point is compressed.
+// The sender publishes senderEphemeral's public key, which the recipient passes to StealthRecover to get the one-time private key.
+// Notice: senderEphemeral *MUST* be fresh for every payment, reusing it gives the same one-time key.
+func StealthOneTimeKey(senderEphemeral *ECDSAPrivateKey, recipientScan, recipientSpend *ECDSAPublicKey) (*ECDSAPublicKey, error) {
+	if senderEphemeral == nil || !senderEphemeral.init || recipientScan == nil || !recipientScan.init ||
+		recipientSpend == nil || !recipientSpend.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	tweak, err := stealthTweak(recipientScan, &senderEphemeral.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	oneTimeKey := *recipientSpend
+	err = oneTimeKey.Add(tweak)
+	if err != nil {
+		return nil, err
+	}
+	return &oneTimeKey, nil
+}
+
+// StealthRecover computes the private key of a one-time public key created by StealthOneTimeKey,
+// from the recipient's scan private key, spend keypair and the sender's ephemeral public key.
+// The scan key only finds the payments, the spend keypair is needed to spend them.
+func StealthRecover(recipientScan *ECDSAPrivateKey, recipientSpend *SchnorrKeyPair, senderEphemeralPub *ECDSAPublicKey) (*SchnorrKeyPair, error) {
+	if recipientScan == nil || !recipientScan.init || recipientSpend == nil || !recipientSpend.init ||
+		senderEphemeralPub == nil || !senderEphemeralPub.init {
+		return nil, errors.WithStack(errNonInitializedKey)
+	}
+	tweak, err := stealthTweak(senderEphemeralPub, &recipientScan.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	// The tweak is added to the private key as is (unlike SchnorrKeyPair.Add), since the sender added it to the full spend public key.
+	spendPrivateKey, err := DeserializeECDSAPrivateKey(recipientSpend.SerializePrivateKey())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { spendPrivateKey.privateKey = [32]byte{} }()
+	err = spendPrivateKey.Add(tweak)
+	if err != nil {
+		return nil, err
+	}
+	return spendPrivateKey.ToSchnorr()
+}
+
+// stealthTweak computes `TaggedHash("go-secp256k1/stealth", privateKey*point)` with the point compressed.
+func stealthTweak(point *ECDSAPublicKey, privateKey *[SerializedPrivateKeySize]byte) ([32]byte, error) {
+	shared := *point
+	err := shared.Mul(*privateKey)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	serialized, err := shared.Serialize()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return *TaggedHash(stealthTag, serialized[:]), nil
+}
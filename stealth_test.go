@@ -0,0 +1,112 @@
+package secp256k1
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestStealth(t *testing.T) {
+	r := rand.New(rand.NewSource(185))
+	scanPrivateKey := mustECDSAPrivateKey(t, r)
+	scanPublicKey, err := scanPrivateKey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendKeyPair := mustSchnorrKeyPair(t, r)
+	spendPrivateKey, err := DeserializeECDSAPrivateKey(spendKeyPair.SerializePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendPublicKey, err := spendPrivateKey.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[SerializedECDSAPublicKey]struct{})
+	for i := 0; i < loopsN; i++ {
+		ephemeral := mustECDSAPrivateKey(t, r)
+		ephemeralPublicKey, err := ephemeral.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		oneTimeKey, err := StealthOneTimeKey(ephemeral, scanPublicKey, spendPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := oneTimeKey.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := seen[*serialized]; ok {
+			t.Fatalf("Expected a distinct one-time key for every ephemeral key")
+		}
+		seen[*serialized] = struct{}{}
+
+		recovered, err := StealthRecover(scanPrivateKey, spendKeyPair, ephemeralPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recoveredPrivateKey, err := DeserializeECDSAPrivateKey(recovered.SerializePrivateKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		recoveredPublicKey, err := recoveredPrivateKey.ECDSAPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !recoveredPublicKey.IsEqual(oneTimeKey) {
+			t.Fatalf("Expected the recovered private key to match the one-time key %s, got %s", oneTimeKey, recoveredPublicKey)
+		}
+
+		// Recovering with the wrong scan key gives another key.
+		wrong, err := StealthRecover(spendPrivateKey, spendKeyPair, ephemeralPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *wrong.SerializePrivateKey() == *recovered.SerializePrivateKey() {
+			t.Fatalf("Expected the wrong scan key to not recover the one-time key")
+		}
+	}
+
+	if _, err := StealthOneTimeKey(new(ECDSAPrivateKey), scanPublicKey, spendPublicKey); err == nil {
+		t.Fatalf("Expected an error with an uninitialized ephemeral key")
+	}
+	if _, err := StealthOneTimeKey(scanPrivateKey, &ECDSAPublicKey{}, spendPublicKey); err == nil {
+		t.Fatalf("Expected an error with an uninitialized scan key")
+	}
+	if _, err := StealthRecover(scanPrivateKey, new(SchnorrKeyPair), scanPublicKey); err == nil {
+		t.Fatalf("Expected an error with an uninitialized spend key")
+	}
+
+	oneTimeKeyArgs := []struct {
+		ephemeral   *ECDSAPrivateKey
+		scan, spend *ECDSAPublicKey
+	}{
+		{nil, scanPublicKey, spendPublicKey},
+		{scanPrivateKey, nil, spendPublicKey},
+		{scanPrivateKey, scanPublicKey, nil},
+	}
+	for i, args := range oneTimeKeyArgs {
+		_, err := StealthOneTimeKey(args.ephemeral, args.scan, args.spend)
+		if !errors.Is(err, errNonInitializedKey) {
+			t.Errorf("Test %d: expected errNonInitializedKey with a nil key, got: '%v'", i, err)
+		}
+	}
+	recoverArgs := []struct {
+		scan         *ECDSAPrivateKey
+		spend        *SchnorrKeyPair
+		ephemeralPub *ECDSAPublicKey
+	}{
+		{nil, spendKeyPair, scanPublicKey},
+		{scanPrivateKey, nil, scanPublicKey},
+		{scanPrivateKey, spendKeyPair, nil},
+		{scanPrivateKey, spendKeyPair, new(ECDSAPublicKey)},
+	}
+	for i, args := range recoverArgs {
+		_, err := StealthRecover(args.scan, args.spend, args.ephemeralPub)
+		if !errors.Is(err, errNonInitializedKey) {
+			t.Errorf("Test %d: expected errNonInitializedKey with a nil or uninitialized key, got: '%v'", i, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+)
+
+// TaggedHash computes the BIP-340 tagged hash of the data: `SHA256(SHA256(tag) || SHA256(tag) || data...)`.
+// The data slices are hashed one after the other, as if they were concatenated.
+func TaggedHash(tag string, data ...[]byte) *Hash {
+	tagHash := sha256.Sum256([]byte(tag))
+	hasher := sha256.New()
+	hasher.Write(tagHash[:])
+	hasher.Write(tagHash[:])
+	for _, d := range data {
+		hasher.Write(d)
+	}
+	hash := Hash{}
+	hasher.Sum(hash[:0])
+	return &hash
+}
+
+const (
+	// sha256MarshaledMagic is the prefix of a crypto/sha256 digest serialized with MarshalBinary
+	sha256MarshaledMagic = "sha\x03"
+	// sha256MarshaledSize is the size of a crypto/sha256 digest serialized with MarshalBinary:
+	// the magic, the 8 state words, the unprocessed block and the amount of bytes hashed so far.
+	sha256MarshaledSize = len(sha256MarshaledMagic) + 8*4 + sha256.BlockSize + 8
+)
+
+// TagMidstate returns the SHA256 midstate of a BIP-340 tagged hash, after hashing the 64 byte `SHA256(tag) || SHA256(tag)` prefix.
+// It's serialized as the 8 state words in big endian, the same way libsecp256k1 hardcodes the midstate of its tags.
+func TagMidstate(tag string) [32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	hasher := sha256.New()
+	hasher.Write(tagHash[:])
+	hasher.Write(tagHash[:])
+	marshaled, err := hasher.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil || len(marshaled) != sha256MarshaledSize {
+		panic("failed getting the SHA256 midstate. Should never happen")
+	}
+	midstate := [32]byte{}
+	copy(midstate[:], marshaled[len(sha256MarshaledMagic):])
+	return midstate
+}
+
+// TaggedHashWithPrecomputedMidstate computes the tagged hash of the data like TaggedHash,
+// starting from a tag's midstate (see TagMidstate) instead of the tag itself.
+func TaggedHashWithPrecomputedMidstate(midstate [32]byte, data ...[]byte) *Hash {
+	marshaled := make([]byte, sha256MarshaledSize)
+	copy(marshaled, sha256MarshaledMagic)
+	copy(marshaled[len(sha256MarshaledMagic):], midstate[:])
+	// The midstate comes after hashing exactly one block, so there are no unprocessed bytes.
+	binary.BigEndian.PutUint64(marshaled[sha256MarshaledSize-8:], sha256.BlockSize)
+	hasher := sha256.New()
+	err := hasher.(encoding.BinaryUnmarshaler).UnmarshalBinary(marshaled)
+	if err != nil {
+		panic("failed restoring the SHA256 midstate. Should never happen")
+	}
+	for _, d := range data {
+		hasher.Write(d)
+	}
+	hash := Hash{}
+	hasher.Sum(hash[:0])
+	return &hash
+}
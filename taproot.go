@@ -0,0 +1,149 @@
+package secp256k1
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// taprootLeafVersionMask clears the parity bit from the first byte of a control block, leaving the leaf version.
+const taprootLeafVersionMask = 0xfe
+
+// taprootMaxMerkleProofLength is the maximum depth of a taproot script tree, see BIP-341.
+const taprootMaxMerkleProofLength = 128
+
+const (
+	// taprootAnnexTag is the first byte of the optional annex, the last element of a witness with at least two elements.
+	taprootAnnexTag = 0x50
+	// taprootControlBlockBaseSize is the size of a control block without a merkle proof: the leaf version and parity byte and the internal key.
+	taprootControlBlockBaseSize = 1 + SerializedSchnorrPublicKeySize
+	// sigHashSingle is the SIGHASH_SINGLE sighash type, the highest of the base types (SIGHASH_ALL, SIGHASH_NONE, SIGHASH_SINGLE)
+	sigHashSingle = 0x03
+	// sigHashAnyoneCanPay is the SIGHASH_ANYONECANPAY flag, which can be combined with any of the base types
+	sigHashAnyoneCanPay = 0x80
+)
+
+// VerifyTaprootKeyPath verifies a BIP-341 key path spend: a BIP-340 schnorr signature by the output key over the signature hash.
+// It's the same as outputKey.SchnorrVerify, the output key already commits to the internal key and the script tree (if any),
+// so unlike VerifyTaprootScriptPath nothing else has to be checked.
+// Notice: computing sigHash (including the sighash type convention of 64 and 65 byte signatures) is up to the caller.
+func VerifyTaprootKeyPath(outputKey *SchnorrPublicKey, sigHash *Hash, sig *SchnorrSignature) bool {
+	return outputKey.SchnorrVerify(sigHash, sig)
+}
+
+// VerifyTaprootScriptPath verifies a BIP-341 script path commitment.
+// It computes the merkle root from the leaf `TapLeaf(leafVersion || compact size(script) || script)` and the merkle proof,
+// computes the tweak `TapTweak(internalKey || merkle root)` and checks that outputKey is internalKey tweaked by it,
+// with parity being the Y parity of the output key (the lowest bit of the control block's first byte).
+func VerifyTaprootScriptPath(outputKey *SchnorrPublicKey, parity bool, internalKey *SchnorrPublicKey, leafVersion byte,
+	script []byte, merkleProof [][32]byte) bool {
+
+	if leafVersion&taprootLeafVersionMask != leafVersion || len(merkleProof) > taprootMaxMerkleProofLength {
+		return false
+	}
+	serializedInternalKey, err := internalKey.Serialize()
+	if err != nil {
+		return false
+	}
+	node := TapLeafHash(leafVersion, script)
+	for i := range merkleProof {
+		node = TapBranchHash(node, (*Hash)(&merkleProof[i]))
+	}
+	tweak := TaggedHash("TapTweak", serializedInternalKey[:], node[:])
+	return internalKey.TweakAddCheck(outputKey, parity, *tweak)
+}
+
+// ValidateTaprootWitness structurally validates a BIP-341 witness stack spending outputKey, and returns whether it's a key path spend.
+// After removing the annex (if any), a single element is a key path spend: a 64 byte signature, or 65 bytes with one of
+// the sighash types BIP-341 defines (0x01-0x03 and 0x81-0x83), which is verified against sigHash. Two or more elements are a script path spend, whose last two elements
+// are the script and the control block, and the script's commitment in outputKey is verified with VerifyTaprootScriptPath.
+// It returns an error if the witness is malformed or doesn't verify.
+// Notice: the script isn't executed, so the rest of a script path witness (e.g. the signatures the script checks) isn't verified,
+// sigHash is only used for a key path spend and computing it (committing to the sighash type and annex) is up to the caller.
+func ValidateTaprootWitness(outputKey *SchnorrPublicKey, sigHash *Hash, witness [][]byte) (keyPath bool, err error) {
+	if len(witness) >= 2 && len(witness[len(witness)-1]) > 0 && witness[len(witness)-1][0] == taprootAnnexTag {
+		witness = witness[:len(witness)-1]
+	}
+	switch len(witness) {
+	case 0:
+		return false, errors.New("the taproot witness is empty")
+	case 1:
+		sig := witness[0]
+		if len(sig) == SerializedSchnorrSignatureSize+1 {
+			// A 65 byte signature has an explicit sighash type, which can't be the default one (0x00) of 64 byte signatures.
+			sigHashType := sig[SerializedSchnorrSignatureSize]
+			if sigHashType == sigHashDefault {
+				return true, errors.New("a 65 byte taproot signature can't have the default sighash type")
+			}
+			if baseType := sigHashType &^ sigHashAnyoneCanPay; baseType < sigHashAll || baseType > sigHashSingle {
+				return true, errors.Errorf("invalid taproot sighash type 0x%02x", sigHashType)
+			}
+			sig = sig[:SerializedSchnorrSignatureSize]
+		}
+		signature, err := DeserializeSchnorrSignatureFromSlice(sig)
+		if err != nil {
+			return true, err
+		}
+		if !VerifyTaprootKeyPath(outputKey, sigHash, signature) {
+			return true, errors.New("the taproot key path signature is invalid")
+		}
+		return true, nil
+	default:
+		script := witness[len(witness)-2]
+		controlBlock := witness[len(witness)-1]
+		if len(controlBlock) < taprootControlBlockBaseSize || (len(controlBlock)-taprootControlBlockBaseSize)%32 != 0 ||
+			(len(controlBlock)-taprootControlBlockBaseSize)/32 > taprootMaxMerkleProofLength {
+			return false, errors.Errorf("invalid taproot control block length %d", len(controlBlock))
+		}
+		internalKey, err := DeserializeSchnorrPubKey(controlBlock[1:taprootControlBlockBaseSize])
+		if err != nil {
+			return false, errors.Wrap(err, "invalid taproot internal key")
+		}
+		merkleProof := make([][32]byte, (len(controlBlock)-taprootControlBlockBaseSize)/32)
+		for i := range merkleProof {
+			copy(merkleProof[i][:], controlBlock[taprootControlBlockBaseSize+32*i:])
+		}
+		leafVersion := controlBlock[0] & taprootLeafVersionMask
+		parity := controlBlock[0]&1 == 1
+		if !VerifyTaprootScriptPath(outputKey, parity, internalKey, leafVersion, script, merkleProof) {
+			return false, errors.New("the taproot control block doesn't commit to the script in the output key")
+		}
+		return false, nil
+	}
+}
+
+// TapLeafHash computes the BIP-341 leaf hash `TaggedHash("TapLeaf", leafVersion || compact size(script) || script)`
+// The leaf version of tapscript is 0xc0.
+func TapLeafHash(leafVersion byte, script []byte) *Hash {
+	return TaggedHash("TapLeaf", []byte{leafVersion}, compactSize(uint64(len(script))), script)
+}
+
+// TapBranchHash computes the BIP-341 branch hash `TaggedHash("TapBranch", min(a, b) || max(a, b))`
+// The children are sorted lexicographically, so the order of a and b doesn't matter.
+func TapBranchHash(a, b *Hash) *Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return TaggedHash("TapBranch", a[:], b[:])
+}
+
+// compactSize encodes n as a bitcoin variable length integer.
+func compactSize(n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return []byte{byte(n)}
+	case n <= 0xffff:
+		buf := []byte{0xfd, 0, 0}
+		binary.LittleEndian.PutUint16(buf[1:], uint16(n))
+		return buf
+	case n <= 0xffffffff:
+		buf := []byte{0xfe, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(buf[1:], uint32(n))
+		return buf
+	default:
+		buf := []byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint64(buf[1:], n)
+		return buf
+	}
+}
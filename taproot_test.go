@@ -0,0 +1,228 @@
+package secp256k1
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestVerifyTaprootScriptPath(t *testing.T) {
+	// Script path spends taken from Bitcoin Core's taproot functional test data (as vendored in btcd's txscript/data/taproot-ref)
+	tests := []struct {
+		outputKey    []byte
+		script       []byte
+		controlBlock []byte
+	}{
+		{
+			decodeHex("c7cc4d9ecf94fd1d6052a234c093a72236440d0ef34d0ac6810605a4931ceb69"),
+			decodeHex("6a50"),
+			decodeHex("c17d732801de7e0c866f2462f29c14b63e555159b62ba93a5d5963d1c04795f93667eba4a75e30ef7cf22fbfc1113fbdf039a8bb23353b5bb581506d48372cca6d"),
+		},
+		{
+			decodeHex("860597d3b29a47949c68e53703a7c358236fede9036ee1439f49b54ea72cb70b"),
+			decodeHex("0020871bf677dcc1eeea213f60505c1c9f1695f8b7d2ee8bbacb3ba246e9f1e57e20ba5187ab"),
+			decodeHex("c17d732801de7e0c866f2462f29c14b63e555159b62ba93a5d5963d1c04795f9363ca46e263a260b65760ba16fc7221d8949b643b52b6000a40fc66cf5b479cf31754943580dc1bd6713260228477cc107c802e16d4edc27befd908a8bf6eb3629"),
+		},
+		{ // unknown leaf version
+			decodeHex("7c531fdbcbb17294861c2fe9842b59c23605dbbb4aeaae1baaa0907152d9a970"),
+			decodeHex("61"),
+			decodeHex("417d732801de7e0c866f2462f29c14b63e555159b62ba93a5d5963d1c04795f936571460ba76479f128232c1e7f3cb97430b20436d33add020c19a42e032587e00ebfb5abead622ee588f8a14df4b864e849bfb1ffa426a7f0fc441a7ea7f9f3e8819e00a9246c8c145cff8a91ff4546d478c6c8e3d7b4e3f7e61102a4388494af"),
+		},
+		{ // even output key
+			decodeHex("eeb645229ded9c683f00135b937b2e4e86df68d251777aa040a582f59863bb1e"),
+			decodeHex("d14c"),
+			decodeHex("c07d732801de7e0c866f2462f29c14b63e555159b62ba93a5d5963d1c04795f93699aaf103cceb41d9bc37ec231aca89b984b5fd3c65977ce764d51033ac65adb4595f1c75585029ef5fafe40c7b455be7b6317879deb123e683907f6588babc52172c8da9bdd43b70cbab8912ef1aa7926e5ad7e47a4f7b71ac936200cc947dd0f9b27230787fc79bd718ce7ac07558dd4f31dfc3ae0570acbd1df01407b1d4ec"),
+		},
+	}
+
+	for i, test := range tests {
+		outputKey, err := DeserializeSchnorrPubKey(test.outputKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leafVersion := test.controlBlock[0] & 0xfe
+		parity := test.controlBlock[0]&1 == 1
+		internalKey, err := DeserializeSchnorrPubKey(test.controlBlock[1:33])
+		if err != nil {
+			t.Fatal(err)
+		}
+		merkleProof := make([][32]byte, (len(test.controlBlock)-33)/32)
+		for j := range merkleProof {
+			copy(merkleProof[j][:], test.controlBlock[33+32*j:])
+		}
+
+		if !VerifyTaprootScriptPath(outputKey, parity, internalKey, leafVersion, test.script, merkleProof) {
+			t.Errorf("Test '%d': expected a valid script path", i)
+		}
+		if VerifyTaprootScriptPath(outputKey, !parity, internalKey, leafVersion, test.script, merkleProof) {
+			t.Errorf("Test '%d': expected the wrong parity to fail", i)
+		}
+		if VerifyTaprootScriptPath(outputKey, parity, internalKey, leafVersion^2, test.script, merkleProof) {
+			t.Errorf("Test '%d': expected the wrong leaf version to fail", i)
+		}
+		if VerifyTaprootScriptPath(outputKey, parity, internalKey, leafVersion|1, test.script, merkleProof) {
+			t.Errorf("Test '%d': expected an odd leaf version to fail", i)
+		}
+		if VerifyTaprootScriptPath(outputKey, parity, internalKey, leafVersion, append(test.script, 0x51), merkleProof) {
+			t.Errorf("Test '%d': expected a modified script to fail", i)
+		}
+		if VerifyTaprootScriptPath(outputKey, parity, internalKey, leafVersion, test.script, merkleProof[1:]) {
+			t.Errorf("Test '%d': expected a truncated merkle proof to fail", i)
+		}
+		if VerifyTaprootScriptPath(outputKey, parity, new(SchnorrPublicKey), leafVersion, test.script, merkleProof) {
+			t.Errorf("Test '%d': expected a zeroed internal key to fail", i)
+		}
+	}
+}
+
+func TestTapLeafAndBranchHash(t *testing.T) {
+	script := make([]byte, 0xfd)
+	for i := range script {
+		script[i] = byte(i)
+	}
+	expected := TaggedHash("TapLeaf", append([]byte{0xc0, 0xfd, 0xfd, 0x00}, script...))
+	leaf := TapLeafHash(0xc0, script)
+	if !leaf.IsEqual(expected) {
+		t.Fatalf("Expected %s, got %s", expected, leaf)
+	}
+
+	other := TapLeafHash(0xc0, []byte{0x51})
+	branch := TapBranchHash(leaf, other)
+	if !branch.IsEqual(TapBranchHash(other, leaf)) {
+		t.Fatalf("Expected TapBranchHash not to depend on the order of the children")
+	}
+	low, high := leaf, other
+	if low.String() > high.String() {
+		low, high = high, low
+	}
+	if expected := TaggedHash("TapBranch", low[:], high[:]); !branch.IsEqual(expected) {
+		t.Fatalf("Expected %s, got %s", expected, branch)
+	}
+}
+
+func TestCompactSize(t *testing.T) {
+	tests := []struct {
+		n        uint64
+		expected []byte
+	}{
+		{0, decodeHex("00")},
+		{0xfc, decodeHex("fc")},
+		{0xfd, decodeHex("fdfd00")},
+		{0xffff, decodeHex("fdffff")},
+		{0x10000, decodeHex("fe00000100")},
+		{0xffffffff, decodeHex("feffffffff")},
+		{0x100000000, decodeHex("ff0000000001000000")},
+	}
+	for _, test := range tests {
+		if encoded := compactSize(test.n); string(encoded) != string(test.expected) {
+			t.Errorf("Expected compactSize(%d) to be %x, got %x", test.n, test.expected, encoded)
+		}
+	}
+}
+
+func TestVerifyTaprootKeyPath(t *testing.T) {
+	r := rand.New(rand.NewSource(181))
+	keypair := mustSchnorrKeyPair(t, r)
+	internalKey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedInternalKey, err := internalKey.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A key path only output, the tweak commits to the internal key alone.
+	err = keypair.Add(*TaggedHash("TapTweak", serializedInternalKey[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputKey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigHash := Hash{}
+	r.Read(sigHash[:])
+	sig := mustSchnorrSign(t, keypair, &sigHash)
+
+	if !VerifyTaprootKeyPath(outputKey, &sigHash, sig) {
+		t.Fatalf("Expected a valid key path spend")
+	}
+	if VerifyTaprootKeyPath(internalKey, &sigHash, sig) {
+		t.Fatalf("Expected a key path spend to not verify against the internal key")
+	}
+	otherSigHash := Hash{1}
+	if VerifyTaprootKeyPath(outputKey, &otherSigHash, sig) {
+		t.Fatalf("Expected a key path spend to not verify over another signature hash")
+	}
+}
+
+func TestValidateTaprootWitness(t *testing.T) {
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputKey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigHash := Hash{0x54, 0x61, 0x70}
+	sig := mustSchnorrSign(t, key, &sigHash)
+	annex := []byte{taprootAnnexTag, 0x01}
+
+	keyPathTests := []struct {
+		name      string
+		witness   [][]byte
+		expectErr bool
+	}{
+		{"64 byte signature", [][]byte{sig.Serialize()[:]}, false},
+		{"65 byte signature", [][]byte{append(sig.Serialize()[:], 0x01)}, false},
+		{"with an annex", [][]byte{sig.Serialize()[:], annex}, false},
+		{"SIGHASH_SINGLE|SIGHASH_ANYONECANPAY", [][]byte{append(sig.Serialize()[:], 0x83)}, false},
+		{"default sighash type in a 65 byte signature", [][]byte{append(sig.Serialize()[:], 0x00)}, true},
+		{"undefined sighash type", [][]byte{append(sig.Serialize()[:], 0x04)}, true},
+		{"SIGHASH_ANYONECANPAY without a base type", [][]byte{append(sig.Serialize()[:], 0x80)}, true},
+		{"undefined sighash type with SIGHASH_ANYONECANPAY", [][]byte{append(sig.Serialize()[:], 0x84)}, true},
+		{"short signature", [][]byte{sig.Serialize()[:63]}, true},
+		{"wrong signature", [][]byte{sig.NegateS().Serialize()[:]}, true},
+	}
+	for _, test := range keyPathTests {
+		keyPath, err := ValidateTaprootWitness(outputKey, &sigHash, test.witness)
+		if !keyPath {
+			t.Errorf("%s: expected a key path spend", test.name)
+		}
+		if (err != nil) != test.expectErr {
+			t.Errorf("%s: expected an error: %t, got: '%v'", test.name, test.expectErr, err)
+		}
+	}
+
+	// The first script path vector of TestVerifyTaprootScriptPath
+	scriptOutputKey, err := DeserializeSchnorrPubKey(decodeHex("c7cc4d9ecf94fd1d6052a234c093a72236440d0ef34d0ac6810605a4931ceb69"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := decodeHex("6a50")
+	controlBlock := decodeHex("c17d732801de7e0c866f2462f29c14b63e555159b62ba93a5d5963d1c04795f93667eba4a75e30ef7cf22fbfc1113fbdf039a8bb23353b5bb581506d48372cca6d")
+	scriptPathTests := []struct {
+		name      string
+		witness   [][]byte
+		expectErr bool
+	}{
+		{"script and control block", [][]byte{script, controlBlock}, false},
+		{"with script inputs and an annex", [][]byte{{0x01}, sig.Serialize()[:], script, controlBlock, annex}, false},
+		{"wrong script", [][]byte{append(script, 0x51), controlBlock}, true},
+		{"truncated control block", [][]byte{script, controlBlock[:len(controlBlock)-1]}, true},
+		{"control block without a merkle proof", [][]byte{script, controlBlock[:taprootControlBlockBaseSize]}, true},
+	}
+	for _, test := range scriptPathTests {
+		keyPath, err := ValidateTaprootWitness(scriptOutputKey, &sigHash, test.witness)
+		if keyPath {
+			t.Errorf("%s: expected a script path spend", test.name)
+		}
+		if (err != nil) != test.expectErr {
+			t.Errorf("%s: expected an error: %t, got: '%v'", test.name, test.expectErr, err)
+		}
+	}
+
+	if _, err := ValidateTaprootWitness(outputKey, &sigHash, nil); err == nil {
+		t.Errorf("Expected an error for an empty witness")
+	}
+}
@@ -0,0 +1,86 @@
+package secp256k1
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"math/big"
+)
+
+// transcriptDomain is the domain separator of every Transcript's initial state
+const transcriptDomain = "go-secp256k1/transcript"
+
+// The kind of each absorbed item, so e.g. a point and bytes with the same encoding never absorb the same.
+const (
+	transcriptProtocol byte = iota
+	transcriptPoint
+	transcriptScalar
+	transcriptBytes
+	transcriptChallenge
+)
+
+// Transcript is a Fiat-Shamir transcript for sigma protocols: the prover and verifier absorb the same labeled
+// points, scalars and bytes in the same order, and derive the same challenges from everything absorbed so far.
+// It's a SHA512 hash chain `state = SHA512(state || kind || len(label) || label || len(data) || data)`
+// where the lengths are 8 bytes big endian, so every sequence of items absorbs to a different state.
+// Challenges also update the state, so the next challenge depends on the previous ones.
+// The zero value isn't usable, a Transcript should be created with NewTranscript. It isn't safe for concurrent use.
+type Transcript struct {
+	state [sha512.Size]byte
+}
+
+// NewTranscript creates a transcript bound to the protocol name,
+// transcripts of different protocols never produce the same challenges.
+func NewTranscript(protocol string) *Transcript {
+	transcript := &Transcript{state: sha512.Sum512([]byte(transcriptDomain))}
+	transcript.absorb(transcriptProtocol, "protocol", []byte(protocol))
+	return transcript
+}
+
+// AppendPoint absorbs the compressed serialization of the point.
+// A nil or uninitialized point is absorbed as empty data, which is different from every valid point.
+func (transcript *Transcript) AppendPoint(label string, p *ECDSAPublicKey) {
+	var data []byte
+	if p != nil {
+		if serialized, err := p.Serialize(); err == nil {
+			data = serialized[:]
+		}
+	}
+	transcript.absorb(transcriptPoint, label, data)
+}
+
+// AppendScalar absorbs the 32 byte big endian scalar.
+func (transcript *Transcript) AppendScalar(label string, s [32]byte) {
+	transcript.absorb(transcriptScalar, label, s[:])
+}
+
+// AppendBytes absorbs arbitrary bytes, e.g. a message or public parameters.
+func (transcript *Transcript) AppendBytes(label string, b []byte) {
+	transcript.absorb(transcriptBytes, label, b)
+}
+
+// Challenge derives a challenge scalar from everything absorbed so far, reduced modulo the group order.
+// The 512 bit output is reduced so the bias is negligible. Calling Challenge twice with the same label gives different challenges.
+func (transcript *Transcript) Challenge(label string) [32]byte {
+	transcript.absorb(transcriptChallenge, label, nil)
+	output := sha512.Sum512(append(transcript.state[:], "challenge"...))
+	reduced := new(big.Int).SetBytes(output[:])
+	reduced.Mod(reduced, secp256k1GroupOrder)
+	challenge := [32]byte{}
+	reducedBytes := reduced.Bytes()
+	copy(challenge[32-len(reducedBytes):], reducedBytes)
+	return challenge
+}
+
+func (transcript *Transcript) absorb(kind byte, label string, data []byte) {
+	var size [8]byte
+	hasher := sha512.New()
+	hasher.Write(transcript.state[:])
+	hasher.Write([]byte{kind})
+	binary.BigEndian.PutUint64(size[:], uint64(len(label)))
+	hasher.Write(size[:])
+	hasher.Write([]byte(label))
+	binary.BigEndian.PutUint64(size[:], uint64(len(data)))
+	hasher.Write(size[:])
+	hasher.Write(data)
+	hasher.Sum(transcript.state[:0])
+}
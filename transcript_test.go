@@ -0,0 +1,117 @@
+package secp256k1
+
+import "testing"
+
+func TestTranscript(t *testing.T) {
+	key, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	point, err := key.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serializedPoint, err := point.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	build := func(protocol string) *Transcript {
+		transcript := NewTranscript(protocol)
+		transcript.AppendPoint("P", point)
+		transcript.AppendScalar("s", [32]byte{31: 7})
+		transcript.AppendBytes("msg", []byte("hello"))
+		return transcript
+	}
+
+	prover, verifier := build("test"), build("test")
+	challenge := prover.Challenge("e")
+	if verifier.Challenge("e") != challenge {
+		t.Fatalf("Expected the same transcript to produce the same challenge")
+	}
+	if ScalarAdd(challenge, [32]byte{}) != challenge {
+		t.Fatalf("Expected the challenge to be reduced modulo the group order")
+	}
+	if prover.Challenge("e") == challenge {
+		t.Fatalf("Expected a second challenge to differ from the first")
+	}
+	if build("other").Challenge("e") == challenge {
+		t.Fatalf("Expected a different protocol to produce a different challenge")
+	}
+	if build("test").Challenge("f") == challenge {
+		t.Fatalf("Expected a different challenge label to produce a different challenge")
+	}
+
+	// Items are framed with their kind and lengths, so shifting bytes between labels and data or items changes the challenge.
+	different := []func(*Transcript){
+		func(transcript *Transcript) { transcript.AppendBytes("a", []byte("bc")) },
+		func(transcript *Transcript) { transcript.AppendBytes("ab", []byte("c")) },
+		func(transcript *Transcript) {
+			transcript.AppendBytes("a", []byte("b"))
+			transcript.AppendBytes("", []byte("c"))
+		},
+		func(transcript *Transcript) { transcript.AppendPoint("P", point) },
+		func(transcript *Transcript) { transcript.AppendBytes("P", serializedPoint[:]) },
+		func(transcript *Transcript) { transcript.AppendPoint("P", nil) },
+		func(transcript *Transcript) { transcript.AppendBytes("P", nil) },
+		func(transcript *Transcript) {},
+	}
+	seen := make(map[[32]byte]int)
+	for i, appendItems := range different {
+		transcript := NewTranscript("test")
+		appendItems(transcript)
+		challenge := transcript.Challenge("e")
+		if j, ok := seen[challenge]; ok {
+			t.Fatalf("Expected transcripts %d and %d to produce different challenges", j, i)
+		}
+		seen[challenge] = i
+	}
+}
+
+// TestTranscriptSchnorrProof uses a transcript for a non-interactive proof of knowledge of a discrete logarithm,
+// `R = k*G, e = Challenge(P, R), s = k + e*x`, verified by checking `s*G == R + e*P`.
+func TestTranscriptSchnorrProof(t *testing.T) {
+	x, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	P, err := x.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	R, err := k.ECDSAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prover := NewTranscript("dlog-proof")
+	prover.AppendPoint("P", P)
+	prover.AppendPoint("R", R)
+	e := prover.Challenge("e")
+	s := ScalarAdd(*k.Serialize(), ScalarMul(e, *x.Serialize()))
+
+	verifier := NewTranscript("dlog-proof")
+	verifier.AppendPoint("P", P)
+	verifier.AppendPoint("R", R)
+	if verifier.Challenge("e") != e {
+		t.Fatalf("Expected the verifier to derive the prover's challenge")
+	}
+	sG, err := TweakPublicKey(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eP := *P
+	err = eP.Mul(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := combineECDSAPublicKeys([]*ECDSAPublicKey{R, &eP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sG.IsEqual(expected) {
+		t.Fatalf("Expected the proof to verify")
+	}
+}
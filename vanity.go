@@ -0,0 +1,73 @@
+package secp256k1
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// GenerateVanityKey generates random keypairs until one has an x-only public key whose serialization starts with prefix,
+// and returns it with the total amount of keypairs that were generated. It's an error if none was found in maxAttempts attempts.
+// The search runs on GOMAXPROCS goroutines, which all stop as soon as one of them finds a key, so the total also counts
+// the attempts the other goroutines were in the middle of.
+// Every byte of the prefix makes the expected amount of attempts 256 times bigger.
+func GenerateVanityKey(prefix []byte, maxAttempts uint64) (*SchnorrKeyPair, uint64, error) {
+	if len(prefix) > SerializedSchnorrPublicKeySize {
+		return nil, 0, errors.Errorf("the prefix can be at most %d bytes, instead got %d", SerializedSchnorrPublicKeySize, len(prefix))
+	}
+	var attempts, completed uint64
+	var stop int32
+	var once sync.Once
+	var found *SchnorrKeyPair
+	var foundErr error
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				attempt := atomic.AddUint64(&attempts, 1)
+				if attempt > maxAttempts {
+					return
+				}
+				keypair, matches, err := vanityAttempt(prefix)
+				atomic.AddUint64(&completed, 1)
+				if !matches && err == nil {
+					continue
+				}
+				once.Do(func() {
+					found, foundErr = keypair, err
+					atomic.StoreInt32(&stop, 1)
+				})
+				return
+			}
+		}()
+	}
+	wg.Wait()
+	if foundErr != nil {
+		return nil, 0, foundErr
+	}
+	if found == nil {
+		return nil, completed, errors.Errorf("failed finding a public key with the prefix %x in %d attempts", prefix, maxAttempts)
+	}
+	return found, completed, nil
+}
+
+// vanityAttempt generates a random keypair and returns true if its x-only public key starts with prefix.
+func vanityAttempt(prefix []byte) (*SchnorrKeyPair, bool, error) {
+	keypair, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		return nil, false, err
+	}
+	pubkey, err := keypair.SchnorrPublicKey()
+	if err != nil {
+		return nil, false, err
+	}
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		return nil, false, err
+	}
+	return keypair, bytes.HasPrefix(serialized[:], prefix), nil
+}
@@ -0,0 +1,48 @@
+package secp256k1
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+)
+
+func TestGenerateVanityKey(t *testing.T) {
+	for _, prefix := range [][]byte{nil, {0xab}, {0x00}} {
+		keypair, attempts, err := GenerateVanityKey(prefix, 1<<20)
+		if err != nil {
+			t.Fatalf("Failed finding a key with the prefix %x: '%s'", prefix, err)
+		}
+		if attempts == 0 || attempts > 1<<20 {
+			t.Fatalf("Expected the attempts to be between 1 and the maximum, got %d", attempts)
+		}
+		// Every key matches an empty prefix, so every goroutine stops after at most one attempt.
+		if prefix == nil && attempts > uint64(runtime.GOMAXPROCS(0)) {
+			t.Fatalf("Expected at most one attempt per goroutine for an empty prefix, got %d", attempts)
+		}
+		pubkey, err := keypair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := pubkey.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(serialized[:], prefix) {
+			t.Fatalf("Expected the public key %s to start with %x", serialized, prefix)
+		}
+	}
+
+	// The chance of a random key matching a 16 byte prefix in 100 attempts is negligible.
+	_, attempts, err := GenerateVanityKey(bytes.Repeat([]byte{0xff}, 16), 100)
+	if err == nil || attempts != 100 {
+		t.Fatalf("Expected an error after 100 attempts, got: %d, %v", attempts, err)
+	}
+	_, _, err = GenerateVanityKey(make([]byte, 33), 100)
+	if err == nil {
+		t.Fatalf("Expected an error for a prefix longer than a public key")
+	}
+	_, _, err = GenerateVanityKey(nil, 0)
+	if err == nil {
+		t.Fatalf("Expected an error with no attempts")
+	}
+}
@@ -0,0 +1,102 @@
+package secp256k1
+
+import "github.com/pkg/errors"
+
+const (
+	// vrfDST is the hash to curve domain separation tag of the VRF input point
+	vrfDST = "go-secp256k1/vrf_secp256k1_XMD:SHA-256_SSWU_RO_"
+	// vrfOutputTag is the tagged hash tag of the VRF output
+	vrfOutputTag = "go-secp256k1/vrf/output"
+
+	// VRFProofSize is the length in bytes of a VRF proof, `Gamma (33 bytes) || DLEQ proof (64 bytes)`
+	VRFProofSize = SerializedECDSAPublicKeySize + SerializedDLEQProofSize
+)
+
+// VRFProve evaluates the verifiable random function on alpha, returning the pseudorandom output beta and a proof
+// that anyone with the x-only public key can check with VRFVerify. It's built like ECVRF (RFC 9381):
+// `H = HashToCurve(P || alpha)` with the tag "go-secp256k1/vrf_secp256k1_XMD:SHA-256_SSWU_RO_", `Gamma = x*H`,
+// `beta = TaggedHash("go-secp256k1/vrf/output", Gamma)`, and the proof is Gamma followed by a DLEQ proof (see ProveDLEQ)
+// that Gamma and P have the same discrete logarithm, where x is the private key of P's even Y point.
+// beta only depends on the key and alpha, but the DLEQ proof is randomized so proofs of the same alpha differ.
+// Notice: RFC 9381 doesn't define a secp256k1 suite, so this isn't interoperable with other ECVRF implementations.
+func (key *SchnorrKeyPair) VRFProve(alpha []byte) (beta [32]byte, proof []byte, err error) {
+	pubkey, isOdd, err := key.schnorrPublicKeyInternal()
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	evenKey := key
+	if isOdd {
+		privateKey := key.SerializePrivateKey()
+		negated := SerializedPrivateKey(ScalarNegate(*privateKey))
+		*privateKey = SerializedPrivateKey{}
+		evenKey, err = DeserializeSchnorrPrivateKey(&negated)
+		negated = SerializedPrivateKey{}
+		if err != nil {
+			return [32]byte{}, nil, err
+		}
+		// Only wiped here, otherwise evenKey is the caller's keypair.
+		defer func() { *evenKey = SchnorrKeyPair{} }()
+	}
+	H, err := vrfInputPoint(pubkey, alpha)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	privateKey := evenKey.SerializePrivateKey()
+	defer func() { *privateKey = SerializedPrivateKey{} }()
+	gamma := *H
+	err = gamma.Mul(*privateKey)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	dleqProof, err := ProveDLEQ(evenKey, H, &gamma)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	serializedGamma, err := gamma.Serialize()
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	proof = make([]byte, 0, VRFProofSize)
+	proof = append(proof, serializedGamma[:]...)
+	proof = append(proof, dleqProof.Serialize()[:]...)
+	return *TaggedHash(vrfOutputTag, serializedGamma[:]), proof, nil
+}
+
+// VRFVerify verifies a VRFProve proof that beta is the VRF output of the public key on alpha.
+// It returns an error if the proof is malformed, and false without an error if it doesn't prove beta.
+func (key *SchnorrPublicKey) VRFVerify(alpha []byte, beta [32]byte, proof []byte) (bool, error) {
+	if len(proof) != VRFProofSize {
+		return false, errors.Errorf("a VRF proof has to be %d bytes, instead got %d", VRFProofSize, len(proof))
+	}
+	gamma, err := DeserializeECDSAPubKeyCompressedOnly(proof[:SerializedECDSAPublicKeySize])
+	if err != nil {
+		return false, errors.Wrap(err, "invalid VRF proof Gamma")
+	}
+	serializedDLEQProof := SerializedDLEQProof{}
+	copy(serializedDLEQProof[:], proof[SerializedECDSAPublicKeySize:])
+	dleqProof, err := DeserializeDLEQProof(&serializedDLEQProof)
+	if err != nil {
+		return false, err
+	}
+	P, err := key.toECDSA()
+	if err != nil {
+		return false, err
+	}
+	H, err := vrfInputPoint(key, alpha)
+	if err != nil {
+		return false, err
+	}
+	if !VerifyDLEQ(P, H, gamma, dleqProof) {
+		return false, nil
+	}
+	return *TaggedHash(vrfOutputTag, proof[:SerializedECDSAPublicKeySize]) == beta, nil
+}
+
+// vrfInputPoint computes the VRF input point `H = HashToCurve(P || alpha)`
+func vrfInputPoint(pubkey *SchnorrPublicKey, alpha []byte) (*ECDSAPublicKey, error) {
+	serialized, err := pubkey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return HashToCurve(append(serialized[:], alpha...), []byte(vrfDST))
+}
@@ -0,0 +1,75 @@
+package secp256k1
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestVRF(t *testing.T) {
+	r := rand.New(rand.NewSource(215))
+	alpha := []byte("round 42")
+	for i := 0; i < 10; i++ {
+		key := mustSchnorrKeyPair(t, r)
+		pubkey, err := key.SchnorrPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		beta, proof, err := key.VRFProve(alpha)
+		if err != nil {
+			t.Fatal(err)
+		}
+		valid, err := pubkey.VRFVerify(alpha, beta, proof)
+		if err != nil || !valid {
+			t.Fatalf("Expected the VRF proof to verify, got %t, '%v'", valid, err)
+		}
+
+		// The output is unique, even though the proofs are randomized.
+		otherBeta, otherProof, err := key.VRFProve(alpha)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if otherBeta != beta {
+			t.Fatalf("Expected the same output for the same alpha, got %x and %x", beta, otherBeta)
+		}
+		valid, err = pubkey.VRFVerify(alpha, beta, otherProof)
+		if err != nil || !valid {
+			t.Fatalf("Expected the second VRF proof to verify, got %t, '%v'", valid, err)
+		}
+
+		valid, err = pubkey.VRFVerify([]byte("round 43"), beta, proof)
+		if err != nil || valid {
+			t.Fatalf("Expected the proof to not verify for another alpha, got %t, '%v'", valid, err)
+		}
+		wrongBeta := beta
+		wrongBeta[0] ^= 1
+		valid, err = pubkey.VRFVerify(alpha, wrongBeta, proof)
+		if err != nil || valid {
+			t.Fatalf("Expected the proof to not verify for another output, got %t, '%v'", valid, err)
+		}
+		tampered := append([]byte{}, proof...)
+		tampered[VRFProofSize-1] ^= 1
+		valid, err = pubkey.VRFVerify(alpha, beta, tampered)
+		if err != nil || valid {
+			t.Fatalf("Expected a tampered proof to not verify, got %t, '%v'", valid, err)
+		}
+	}
+
+	key, err := GenerateSchnorrKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := key.SchnorrPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	beta, proof, err := key.VRFProve(alpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pubkey.VRFVerify(alpha, beta, proof[:VRFProofSize-1]); err == nil {
+		t.Fatalf("Expected an error for a short proof")
+	}
+	if _, _, err := (&SchnorrKeyPair{}).VRFProve(alpha); err == nil {
+		t.Fatalf("Expected an error for an uninitialized keypair")
+	}
+}
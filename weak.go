@@ -0,0 +1,76 @@
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math"
+)
+
+// weakKeyZeroBytes is the amount of leading zero bytes that make a private key (or its negation) weak,
+// a private key smaller than 2^64 can be found by brute force.
+const weakKeyZeroBytes = 24
+
+// weakBrainwalletPhrases are passphrases whose SHA256 was famously used as a "brainwallet" private key, and whose funds were stolen.
+var weakBrainwalletPhrases = []string{
+	"",
+	"password",
+	"bitcoin",
+	"satoshi",
+	"satoshi nakamoto",
+	"hello",
+	"hello world",
+	"test",
+	"correct horse battery staple",
+	"how much wood could a woodchuck chuck if a woodchuck could chuck wood",
+}
+
+// IsWeak returns true if the private key is a small number (e.g. 1 or 2) or the negation of one (e.g. Group Order - 1),
+// or the SHA256 of a well known brainwallet passphrase. Such keys were surely found by others, and anything they protect should be considered stolen.
+// It returns false for uninitialized keys.
+// Notice: this only catches a handful of famous mistakes, a key that isn't weak by this check can still come from a low entropy source.
+func (key *SchnorrKeyPair) IsWeak() bool {
+	if !key.init {
+		return false
+	}
+	privateKey := key.SerializePrivateKey()
+	negated := ScalarNegate(*privateKey)
+	defer func() {
+		*privateKey = SerializedPrivateKey{}
+		negated = [32]byte{}
+	}()
+
+	var zeros [weakKeyZeroBytes]byte
+	if bytes.Equal(privateKey[:weakKeyZeroBytes], zeros[:]) || bytes.Equal(negated[:weakKeyZeroBytes], zeros[:]) {
+		return true
+	}
+	for _, phrase := range weakBrainwalletPhrases {
+		if sha256.Sum256([]byte(phrase)) == *privateKey {
+			return true
+		}
+	}
+	return false
+}
+
+// LowEntropyThreshold is the EstimateEntropy score below which a private key looks hand made.
+// With 32 random bytes a score this low is expected less than once in a million keys.
+const LowEntropyThreshold = 4.0
+
+// EstimateEntropy returns the Shannon entropy of the private key's byte values in bits per byte, a heuristic score between 0 and 5
+// (32 bytes have at most 32 distinct values). Random keys usually score above 4.5, keys with repeating bytes or patterns
+// (e.g. typed by hand) score lower, see LowEntropyThreshold.
+// Notice: this isn't a measure of the key's strength, the SHA256 of a dictionary word scores like a random key (see IsWeak).
+func (data *SerializedPrivateKey) EstimateEntropy() float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}